@@ -1,4 +1,4 @@
-import { handleCall, handleAbort } from "wapc-guest-as";
+import { handleCall } from "wapc-guest-as";
 import {
   Tests,
   Required,
@@ -6,13 +6,51 @@ import {
   Maps,
   Lists,
   Thing,
+  ThingSummary,
+  Enums,
+  Unions,
+  Trees,
+  Collections,
+  Times,
+  Aliases,
+  Defaults,
+  Validated,
+  NamespaceResults,
+  Host,
+  StorageHost,
+  LogHost,
+  ThingStreamWriter,
+  ThingStreamReader,
   Handlers,
 } from "./module";
+import { Value } from "@wapc/as-msgpack";
 
 export function wapc_init(): void {
   Handlers.registerTestFunction(testFunction);
   Handlers.registerTestUnary(testUnary);
   Handlers.registerTestDecode(testDecode);
+  Handlers.registerTestHostCall(testHostCall);
+  Handlers.registerTestEnums(testEnums);
+  Handlers.registerTestUnions(testUnions);
+  Handlers.registerTestRecursion(testRecursion);
+  Handlers.registerTestCollections(testCollections);
+  Handlers.registerTestTimes(testTimes);
+  Handlers.registerTestAliases(testAliases);
+  Handlers.registerTestDefaults(testDefaults);
+  Handlers.registerTestValidation(testValidation);
+  Handlers.registerTestNoArgs(testNoArgs);
+  Handlers.registerTestNoArgsVoid(testNoArgsVoid);
+  Handlers.registerTestVoid(testVoid);
+  Handlers.registerTestUnaryString(testUnaryString);
+  Handlers.registerTestUnaryU64(testUnaryU64);
+  Handlers.registerTestUnaryBool(testUnaryBool);
+  Handlers.registerTestUnaryBytes(testUnaryBytes);
+  Handlers.registerTestReturnList(testReturnList);
+  Handlers.registerTestReturnMap(testReturnMap);
+  Handlers.registerTestReturnOptional(testReturnOptional);
+  Handlers.registerTestNamespaces(testNamespaces);
+  Handlers.registerStreamThings(streamThings);
+  Handlers.registerCollectThings(collectThings);
 }
 
 function testFunction(
@@ -22,12 +60,12 @@ function testFunction(
   lists: Lists
 ): Tests {
   // Echo arguments
-  return Tests.newBuilder()
-    .withRequired(required)
-    .withOptional(optional)
-    .withMaps(maps)
-    .withLists(lists)
-    .build();
+  const tests = new Tests();
+  tests.required = required;
+  tests.optional = optional;
+  tests.maps = maps;
+  tests.lists = lists;
+  return tests;
 }
 
 function testUnary(tests: Tests): Tests {
@@ -55,18 +93,189 @@ function testDecode(tests: Tests): string {
   return ret;
 }
 
+function testHostCall(tests: Tests): Tests {
+  // Round trip through the host
+  return new Host("").testUnary(tests);
+}
+
+function testEnums(enums: Enums): Enums {
+  // Echo input
+  return enums;
+}
+
+function testUnions(unions: Unions): Unions {
+  // Echo input
+  return unions;
+}
+
+function testRecursion(trees: Trees): Trees {
+  // Echo input
+  return trees;
+}
+
+function testCollections(collections: Collections): Collections {
+  // Echo input
+  return collections;
+}
+
+function testTimes(times: Times): Times {
+  // Echo input
+  return times;
+}
+
+function testAliases(aliases: Aliases): Aliases {
+  // Echo input
+  return aliases;
+}
+
+function testDefaults(defaults: Defaults): Defaults {
+  // Echo input
+  return defaults;
+}
+
+function testValidation(validated: Validated): Validated {
+  // Echo input
+  return validated;
+}
+
+function testNoArgs(): string {
+  return "ok";
+}
+
+function testNoArgsVoid(): void {}
+
+function testVoid(value: string): void {
+  if (value == "error") {
+    throw new Error("testVoid failed");
+  }
+}
+
+function testUnaryString(value: string): string {
+  // Echo input
+  return value;
+}
+
+function testUnaryU64(value: u64): u64 {
+  // Echo input
+  return value;
+}
+
+function testUnaryBool(value: bool): bool {
+  // Echo input
+  return value;
+}
+
+function testUnaryBytes(value: ArrayBuffer): ArrayBuffer {
+  // Echo input
+  return value;
+}
+
+// MAX_LIST_COUNT bounds the count of testReturnList, which comes from the
+// client, so that it cannot make the guest run out of memory.
+const MAX_LIST_COUNT: u32 = 10000;
+
+function testReturnList(prefix: string, count: u32): Array<string> {
+  if (count > MAX_LIST_COUNT) {
+    throw new Error("count " + count.toString() + " is above " + MAX_LIST_COUNT.toString());
+  }
+  const list = new Array<string>();
+  for (let i: u32 = 0; i < count; i++) {
+    list.push(prefix + i.toString());
+  }
+  return list;
+}
+
+function testReturnMap(keys: Array<string>): Map<string, u64> {
+  const lengths = new Map<string, u64>();
+  for (let i = 0; i < keys.length; i++) {
+    lengths.set(keys[i], <u64>String.UTF8.byteLength(keys[i]));
+  }
+  return lengths;
+}
+
+function testReturnOptional(value: Value<string> | null): Value<string> | null {
+  // Echo input
+  return value;
+}
+
+function testNamespaces(key: string, value: string): NamespaceResults {
+  const storage = new StorageHost("");
+  const log = new LogHost("");
+  storage.set(key, value);
+  const index = log.write(key + "=" + value);
+  // Both namespaces have a get operation.
+  const results = new NamespaceResults();
+  results.stored = storage.get(key);
+  results.logged = log.get(index);
+  results.index = index;
+  return results;
+}
+
+function streamThings(
+  prefix: string,
+  count: u32,
+  failAt: Value<u32> | null,
+  stream: ThingStreamWriter
+): void {
+  for (let i: u32 = 0; i < count; i++) {
+    if (failAt !== null && failAt!.value == i) {
+      // The error aborts the guest, so send the things before it first.
+      stream.flush();
+      throw new Error("failed at " + i.toString());
+    }
+    const thing = new Thing();
+    thing.value = prefix + i.toString();
+    stream.send(thing);
+  }
+}
+
+function collectThings(
+  label: string,
+  failAt: Value<u32> | null,
+  things: ThingStreamReader
+): ThingSummary {
+  const summary = new ThingSummary();
+  summary.label = label;
+  // FNV-1a
+  let checksum: u32 = 0x811c9dc5;
+  while (true) {
+    if (failAt !== null && failAt!.value == summary.count) {
+      // The error aborts the guest, so stop the stream of the host first.
+      things.cancel();
+      throw new Error("failed at " + summary.count.toString());
+    }
+    if (!things.next()) {
+      break;
+    }
+    const value = String.UTF8.encode(things.item().value);
+    const bytes = Uint8Array.wrap(value);
+    summary.count++;
+    summary.size += <u64>bytes.length;
+    for (let i = 0; i < bytes.length; i++) {
+      checksum = (checksum ^ <u32>bytes[i]) * 0x01000193;
+    }
+  }
+  summary.checksum = checksum;
+  return summary;
+}
+
 // Boilerplate code for waPC.  Do not remove.
 
 export function __guest_call(operation_size: usize, payload_size: usize): bool {
   return handleCall(operation_size, payload_size);
 }
 
-// Abort function
+@external("wapc", "__guest_error")
+declare function __guest_error(ptr: usize, len: usize): void;
+
+// Abort function. Errors abort the guest, so it reports their message as
+// is, which the host returns as the error of the operation.
 function abort(
   message: string | null,
   fileName: string | null,
   lineNumber: u32,
   columnNumber: u32
 ): void {
-  handleAbort(message, fileName, lineNumber, columnNumber);
+  const error = String.UTF8.encode(message !== null ? message! : "abort");
+  __guest_error(changetype<usize>(error), error.byteLength);
 }
@@ -0,0 +1,461 @@
+// RegExp matches strings against the @pattern constraints of the schema, with
+// the semantics of Go's regexp package for the subset of its syntax that the
+// code generator accepts: literals, escaped punctuation, the \n, \t, \r, \f
+// and \v escapes, ".", character classes with ranges, groups, alternation,
+// the *, +, ? and {n,m} quantifiers and the ^ and $ anchors.
+// It runs the compiled pattern as an NFA, in time linear in the length of
+// the string.
+
+const OP_CHAR = 0;
+const OP_SPLIT = 1;
+const OP_JUMP = 2;
+const OP_BEGIN = 3;
+const OP_END = 4;
+const OP_MATCH = 5;
+
+const NODE_CHAR = 0;
+const NODE_BEGIN = 1;
+const NODE_END = 2;
+const NODE_CONCAT = 3;
+const NODE_ALTERNATE = 4;
+const NODE_REPEAT = 5;
+
+// MAX_REPEAT bounds the counts of the {n,m} quantifier, as Go does.
+const MAX_REPEAT = 1000;
+
+const MAX_RUNE = 0x10ffff;
+
+// Class is a set of characters, as ranges of code points.
+class Class {
+  ranges: Array<i32> = new Array<i32>();
+  negated: bool = false;
+
+  add(lo: i32, hi: i32): void {
+    this.ranges.push(lo);
+    this.ranges.push(hi);
+  }
+
+  matches(c: i32): bool {
+    let found = false;
+    for (let i = 0; i < this.ranges.length; i += 2) {
+      if (c >= this.ranges[i] && c <= this.ranges[i + 1]) {
+        found = true;
+        break;
+      }
+    }
+    return found != this.negated;
+  }
+}
+
+class Node {
+  kind: i32;
+  chars: Class | null = null;
+  children: Array<Node> = new Array<Node>();
+  min: i32 = 0;
+  // max is -1 for an unbounded repetition.
+  max: i32 = 0;
+
+  constructor(kind: i32) {
+    this.kind = kind;
+  }
+}
+
+class Inst {
+  op: i32;
+  chars: Class | null = null;
+  x: i32 = 0;
+  y: i32 = 0;
+
+  constructor(op: i32) {
+    this.op = op;
+  }
+}
+
+// codePoints returns the code points of `s`, decoding surrogate pairs.
+function codePoints(s: string): Array<i32> {
+  const points = new Array<i32>();
+  for (let i = 0; i < s.length; i++) {
+    let c = s.charCodeAt(i);
+    if (c >= 0xd800 && c <= 0xdbff && i + 1 < s.length) {
+      const low = s.charCodeAt(i + 1);
+      if (low >= 0xdc00 && low <= 0xdfff) {
+        c = 0x10000 + ((c - 0xd800) << 10) + (low - 0xdc00);
+        i++;
+      }
+    }
+    points.push(c);
+  }
+  return points;
+}
+
+// Parser parses a pattern into a tree of nodes.
+class Parser {
+  pattern: string;
+  chars: Array<i32>;
+  pos: i32 = 0;
+
+  constructor(pattern: string) {
+    this.pattern = pattern;
+    this.chars = codePoints(pattern);
+  }
+
+  fail(message: string): void {
+    throw new Error("invalid pattern " + this.pattern + ": " + message);
+  }
+
+  more(): bool {
+    return this.pos < this.chars.length;
+  }
+
+  peek(): i32 {
+    return this.chars[this.pos];
+  }
+
+  next(): i32 {
+    if (!this.more()) {
+      this.fail("unexpected end");
+    }
+    const c = this.chars[this.pos];
+    this.pos++;
+    return c;
+  }
+
+  parse(): Node {
+    const node = this.parseAlternate();
+    if (this.more()) {
+      this.fail("unexpected )");
+    }
+    return node;
+  }
+
+  parseAlternate(): Node {
+    const node = new Node(NODE_ALTERNATE);
+    node.children.push(this.parseConcat());
+    while (this.more() && this.peek() == 0x7c) {
+      // |
+      this.pos++;
+      node.children.push(this.parseConcat());
+    }
+    if (node.children.length == 1) {
+      return node.children[0];
+    }
+    return node;
+  }
+
+  parseConcat(): Node {
+    const node = new Node(NODE_CONCAT);
+    while (this.more() && this.peek() != 0x7c && this.peek() != 0x29) {
+      node.children.push(this.parseRepeat());
+    }
+    return node;
+  }
+
+  parseRepeat(): Node {
+    let node = this.parseAtom();
+    while (this.more()) {
+      const c = this.peek();
+      let min = 0;
+      let max = -1;
+      if (c == 0x2a) {
+        // *
+        this.pos++;
+      } else if (c == 0x2b) {
+        // +
+        this.pos++;
+        min = 1;
+      } else if (c == 0x3f) {
+        // ?
+        this.pos++;
+        max = 1;
+      } else if (c == 0x7b && this.isCount()) {
+        // {n}, {n,} or {n,m}
+        this.pos++;
+        min = this.parseNumber();
+        max = min;
+        if (this.peek() == 0x2c) {
+          this.pos++;
+          max = this.peek() == 0x7d ? -1 : this.parseNumber();
+        }
+        this.pos++;
+        if (min > MAX_REPEAT || max > MAX_REPEAT || (max >= 0 && max < min)) {
+          this.fail("invalid repeat count");
+        }
+      } else {
+        break;
+      }
+      if (node.kind == NODE_REPEAT || node.kind == NODE_BEGIN || node.kind == NODE_END) {
+        this.fail("invalid nested repetition operator");
+      }
+      // Non-greedy repetitions match the same strings.
+      if (this.more() && this.peek() == 0x3f) {
+        this.pos++;
+      }
+      const repeat = new Node(NODE_REPEAT);
+      repeat.children.push(node);
+      repeat.min = min;
+      repeat.max = max;
+      node = repeat;
+    }
+    return node;
+  }
+
+  // isCount reports whether the { at the current position starts a
+  // {n}, {n,} or {n,m} quantifier. Otherwise it is a literal, as in Go.
+  isCount(): bool {
+    let i = this.pos + 1;
+    let digits = 0;
+    while (i < this.chars.length && this.chars[i] >= 0x30 && this.chars[i] <= 0x39) {
+      i++;
+      digits++;
+    }
+    if (digits == 0 || i >= this.chars.length) {
+      return false;
+    }
+    if (this.chars[i] == 0x2c) {
+      i++;
+      while (i < this.chars.length && this.chars[i] >= 0x30 && this.chars[i] <= 0x39) {
+        i++;
+      }
+    }
+    return i < this.chars.length && this.chars[i] == 0x7d;
+  }
+
+  parseNumber(): i32 {
+    let n = 0;
+    while (this.more() && this.peek() >= 0x30 && this.peek() <= 0x39) {
+      n = n * 10 + (this.next() - 0x30);
+      if (n > MAX_REPEAT) {
+        this.fail("invalid repeat count");
+      }
+    }
+    return n;
+  }
+
+  parseAtom(): Node {
+    const c = this.next();
+    if (c == 0x28) {
+      // (
+      if (this.more() && this.peek() == 0x3f) {
+        this.pos++;
+        if (this.next() != 0x3a) {
+          this.fail("flags are not supported");
+        }
+      }
+      const node = this.parseAlternate();
+      if (this.next() != 0x29) {
+        this.fail("missing )");
+      }
+      return node;
+    }
+    if (c == 0x5e) {
+      return new Node(NODE_BEGIN);
+    }
+    if (c == 0x24) {
+      return new Node(NODE_END);
+    }
+    if (c == 0x2a || c == 0x2b || c == 0x3f) {
+      this.fail("missing argument to repetition operator");
+    }
+    const node = new Node(NODE_CHAR);
+    const chars = new Class();
+    node.chars = chars;
+    if (c == 0x2e) {
+      // . matches any character but a newline.
+      chars.add(0, 0x09);
+      chars.add(0x0b, MAX_RUNE);
+    } else if (c == 0x5b) {
+      this.parseClass(chars);
+    } else if (c == 0x5c) {
+      this.parseEscape(chars);
+    } else {
+      chars.add(c, c);
+    }
+    return node;
+  }
+
+  // parseEscape adds the characters of the escape after a backslash.
+  parseEscape(chars: Class): void {
+    const c = this.next();
+    if (c == 0x6e) {
+      chars.add(0x0a, 0x0a);
+    } else if (c == 0x74) {
+      chars.add(0x09, 0x09);
+    } else if (c == 0x72) {
+      chars.add(0x0d, 0x0d);
+    } else if (c == 0x66) {
+      chars.add(0x0c, 0x0c);
+    } else if (c == 0x76) {
+      chars.add(0x0b, 0x0b);
+    } else if (c < 0x80 && !(c >= 0x30 && c <= 0x39) && !(c >= 0x41 && c <= 0x5a) && !(c >= 0x61 && c <= 0x7a)) {
+      // Escaped punctuation stands for itself.
+      chars.add(c, c);
+    } else {
+      this.fail("unsupported escape \\" + String.fromCharCode(c));
+    }
+  }
+
+  // parseClass parses a class after its [.
+  parseClass(chars: Class): void {
+    if (this.more() && this.peek() == 0x5e) {
+      this.pos++;
+      chars.negated = true;
+    }
+    let first = true;
+    while (true) {
+      let c = this.next();
+      if (c == 0x5d && !first) {
+        return;
+      }
+      first = false;
+      if (c == 0x5c) {
+        const escaped = new Class();
+        this.parseEscape(escaped);
+        c = escaped.ranges[0];
+      }
+      let hi = c;
+      if (this.more() && this.peek() == 0x2d && this.pos + 1 < this.chars.length && this.chars[this.pos + 1] != 0x5d) {
+        // A range such as a-z.
+        this.pos++;
+        hi = this.next();
+        if (hi == 0x5c) {
+          const escaped = new Class();
+          this.parseEscape(escaped);
+          hi = escaped.ranges[0];
+        }
+        if (hi < c) {
+          this.fail("invalid class range");
+        }
+      }
+      chars.add(c, hi);
+    }
+  }
+}
+
+export class RegExp {
+  private program: Array<Inst> = new Array<Inst>();
+
+  // The constructor aborts if `pattern` is invalid or uses unsupported
+  // syntax.
+  constructor(pattern: string) {
+    this.compile(new Parser(pattern).parse());
+    this.emit(OP_MATCH);
+  }
+
+  private emit(op: i32): i32 {
+    this.program.push(new Inst(op));
+    return this.program.length - 1;
+  }
+
+  private compile(node: Node): void {
+    if (node.kind == NODE_CHAR) {
+      this.program[this.emit(OP_CHAR)].chars = node.chars;
+    } else if (node.kind == NODE_BEGIN) {
+      this.emit(OP_BEGIN);
+    } else if (node.kind == NODE_END) {
+      this.emit(OP_END);
+    } else if (node.kind == NODE_CONCAT) {
+      for (let i = 0; i < node.children.length; i++) {
+        this.compile(node.children[i]);
+      }
+    } else if (node.kind == NODE_ALTERNATE) {
+      const jumps = new Array<i32>();
+      for (let i = 0; i < node.children.length - 1; i++) {
+        const split = this.emit(OP_SPLIT);
+        this.program[split].x = split + 1;
+        this.compile(node.children[i]);
+        jumps.push(this.emit(OP_JUMP));
+        this.program[split].y = this.program.length;
+      }
+      this.compile(node.children[node.children.length - 1]);
+      for (let i = 0; i < jumps.length; i++) {
+        this.program[jumps[i]].x = this.program.length;
+      }
+    } else {
+      this.compileRepeat(node);
+    }
+  }
+
+  private compileRepeat(node: Node): void {
+    const child = node.children[0];
+    for (let i = 0; i < node.min; i++) {
+      this.compile(child);
+    }
+    if (node.max < 0) {
+      // Loop: split to the child or out, and jump back after the child.
+      const split = this.emit(OP_SPLIT);
+      this.program[split].x = split + 1;
+      this.compile(child);
+      this.program[this.emit(OP_JUMP)].x = split;
+      this.program[split].y = this.program.length;
+      return;
+    }
+    // Each optional copy may be skipped, which skips the copies after it.
+    const splits = new Array<i32>();
+    for (let i = node.min; i < node.max; i++) {
+      const split = this.emit(OP_SPLIT);
+      this.program[split].x = split + 1;
+      splits.push(split);
+      this.compile(child);
+    }
+    for (let i = 0; i < splits.length; i++) {
+      this.program[splits[i]].y = this.program.length;
+    }
+  }
+
+  // test reports whether `s` contains a match of the pattern.
+  test(s: string): bool {
+    const input = codePoints(s);
+    const marks = new Array<i32>(this.program.length);
+    let current = new Array<i32>();
+    let generation = 1;
+    for (let pos = 0; ; pos++) {
+      // A match may start at any position.
+      if (this.add(current, marks, generation, 0, pos, input.length)) {
+        return true;
+      }
+      if (pos == input.length) {
+        return false;
+      }
+      const c = input[pos];
+      const next = new Array<i32>();
+      generation++;
+      for (let i = 0; i < current.length; i++) {
+        const pc = current[i];
+        if (this.program[pc].chars!.matches(c) && this.add(next, marks, generation, pc + 1, pos + 1, input.length)) {
+          return true;
+        }
+      }
+      current = next;
+    }
+    return false;
+  }
+
+  // add adds the thread at `pc` to `threads`, following the instructions
+  // that do not read a character, and reports whether it reaches a match.
+  // `marks` holds the generation of the list each instruction was last
+  // added to.
+  private add(threads: Array<i32>, marks: Array<i32>, generation: i32, pc: i32, pos: i32, length: i32): bool {
+    if (marks[pc] == generation) {
+      return false;
+    }
+    marks[pc] = generation;
+    const inst = this.program[pc];
+    if (inst.op == OP_MATCH) {
+      return true;
+    }
+    if (inst.op == OP_JUMP) {
+      return this.add(threads, marks, generation, inst.x, pos, length);
+    }
+    if (inst.op == OP_SPLIT) {
+      return this.add(threads, marks, generation, inst.x, pos, length) || this.add(threads, marks, generation, inst.y, pos, length);
+    }
+    if (inst.op == OP_BEGIN) {
+      return pos == 0 && this.add(threads, marks, generation, pc + 1, pos, length);
+    }
+    if (inst.op == OP_END) {
+      return pos == length && this.add(threads, marks, generation, pc + 1, pos, length);
+    }
+    threads.push(pc);
+    return false;
+  }
+}
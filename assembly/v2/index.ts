@@ -0,0 +1,48 @@
+import { handleCall } from "wapc-guest-as";
+import { Tests, Required, Optional, Maps, Lists, Handlers } from "./module";
+
+export function wapc_init(): void {
+  Handlers.registerTestFunction(testFunction);
+  Handlers.registerTestUnary(testUnary);
+}
+
+function testFunction(
+  required: Required,
+  optional: Optional,
+  maps: Maps,
+  lists: Lists
+): Tests {
+  // Echo arguments
+  const tests = new Tests();
+  tests.required = required;
+  tests.optional = optional;
+  tests.maps = maps;
+  tests.lists = lists;
+  return tests;
+}
+
+function testUnary(tests: Tests): Tests {
+  // Echo input
+  return tests;
+}
+
+// Boilerplate code for waPC.  Do not remove.
+
+export function __guest_call(operation_size: usize, payload_size: usize): bool {
+  return handleCall(operation_size, payload_size);
+}
+
+@external("wapc", "__guest_error")
+declare function __guest_error(ptr: usize, len: usize): void;
+
+// Abort function. Errors abort the guest, so it reports their message as
+// is, which the host returns as the error of the operation.
+function abort(
+  message: string | null,
+  fileName: string | null,
+  lineNumber: u32,
+  columnNumber: u32
+): void {
+  const error = String.UTF8.encode(message !== null ? message! : "abort");
+  __guest_error(changetype<usize>(error), error.byteLength);
+}
@@ -1,13 +1,52 @@
 #!/bin/sh
 echo "Generating code"
-wapc generate codegen.yaml
+go run ./cmd/codegen
+
+# manifest records the hashes of a guest, its schema and its sources so the
+# tests can warn when the guest is stale.
+manifest() {
+  go run ./cmd/manifest "$@"
+}
+
+# The versions of the waPC guest libraries the guests are built with, and of
+# the MsgPack libraries the AssemblyScript and Rust bindings use.
+tinygo_sdk=$(go list -m -f '{{.Path}}={{.Version}}' github.com/wapc/wapc-guest-tinygo)
+as_sdk="wapc-guest-as=$(node -p 'require("wapc-guest-as/package.json").version')"
+as_msgpack="@wapc/as-msgpack=$(node -p 'require("@wapc/as-msgpack/package.json").version')"
+rust_sdk="wapc-guest=$(cargo pkgid --manifest-path=rust/Cargo.toml wapc-guest | sed 's/.*[#@]//')"
+rust_msgpack="rmp-serde=$(cargo pkgid --manifest-path=rust/Cargo.toml rmp-serde | sed 's/.*[#@]//')"
 
 echo "Building AssemblyScript module"
-npm run build
+npm run build && \
+  manifest -toolchain "asc=$(npx asc --version)" -sdk "$as_sdk" -sdk "$as_msgpack" \
+    build/assemblyscript.wasm assembly/module.ts assembly/regexp.ts assembly/index.ts package-lock.json
+
+echo "Building AssemblyScript schema version 2 module"
+npm run build:v2 && \
+  manifest -schema schema.v2.widl -toolchain "asc=$(npx asc --version)" -sdk "$as_sdk" -sdk "$as_msgpack" \
+    build/assemblyscript-v2.wasm assembly/v2/module.ts assembly/v2/index.ts package-lock.json
 
 echo "Building TinyGo module"
-tinygo build -o build/tinygo.wasm -target wasm -no-debug tinygo/main.go
+tinygo build -o build/tinygo.wasm -target wasm -no-debug tinygo/main.go && \
+  manifest -toolchain "tinygo=$(tinygo version)" -sdk "$tinygo_sdk" \
+    build/tinygo.wasm tinygo/module/module.go tinygo/main.go
+
+echo "Building TinyGo schema version 2 module"
+tinygo build -o build/tinygo-v2.wasm -target wasm -no-debug tinygo/v2/main.go && \
+  manifest -schema schema.v2.widl -toolchain "tinygo=$(tinygo version)" -sdk "$tinygo_sdk" \
+    build/tinygo-v2.wasm tinygo/v2/module/module.go tinygo/v2/main.go
 
 echo "Building Rust module"
 cargo build --target wasm32-unknown-unknown --release --manifest-path=rust/Cargo.toml && \
-  cp rust/target/wasm32-unknown-unknown/release/rust_codegen_test.wasm build/rust.wasm
+  cp rust/target/wasm32-unknown-unknown/release/rust_codegen_test.wasm build/rust.wasm && \
+  manifest -toolchain "rustc=$(rustc --version)" -toolchain "cargo=$(cargo --version)" -sdk "$rust_sdk" -sdk "$rust_msgpack" \
+    build/rust.wasm rust/src/generated.rs rust/src/lib.rs rust/Cargo.toml
+
+echo "Building Rust schema version 2 module"
+cargo build --target wasm32-unknown-unknown --release --manifest-path=rust/v2/Cargo.toml && \
+  cp rust/v2/target/wasm32-unknown-unknown/release/rust_codegen_test_v2.wasm build/rust-v2.wasm && \
+  manifest -schema schema.v2.widl -toolchain "rustc=$(rustc --version)" -toolchain "cargo=$(cargo --version)" -sdk "$rust_sdk" -sdk "$rust_msgpack" \
+    build/rust-v2.wasm rust/v2/src/generated.rs rust/v2/src/lib.rs rust/v2/Cargo.toml
+
+echo "Checking module sizes"
+go run ./cmd/wasmsize
@@ -0,0 +1,134 @@
+// Package build embeds the guests built by build.sh, so that tests and other
+// packages can load them by name wherever they run.
+package build
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+
+	"github.com/wapc/language-tests/pkg/manifest"
+)
+
+//go:embed *.wasm *.json
+var files embed.FS
+
+// ErrNotBuilt is returned by Get for a known guest that build.sh has not built.
+var ErrNotBuilt = errors.New("build: guest has not been built")
+
+// Guest is a built guest.
+type Guest struct {
+	// Name is the name of the Wasm file without its extension, such as
+	// "tinygo".
+	Name     string
+	Language string
+	// SDK is the waPC guest library the guest uses.
+	SDK string
+	// SDKVersion is the version of SDK recorded in the manifest of the
+	// guest, empty if the manifest records none.
+	SDKVersion string
+	// Schema is the schema the guest was generated from.
+	Schema string
+	// SchemaHash is the SHA-256 of the schema recorded in the manifest of the
+	// guest.
+	SchemaHash string
+	Code       []byte
+}
+
+// File returns the name of the Wasm file of the guest, such as "tinygo.wasm".
+func (g *Guest) File() string {
+	return g.Name + ".wasm"
+}
+
+var guests = []Guest{
+	{
+		Name:     "tinygo",
+		Language: "TinyGo",
+		SDK:      "github.com/wapc/wapc-guest-tinygo",
+		Schema:   "schema.widl",
+	},
+	{
+		Name:     "assemblyscript",
+		Language: "AssemblyScript",
+		SDK:      "wapc-guest-as",
+		Schema:   "schema.widl",
+	},
+	{
+		Name:     "rust",
+		Language: "Rust",
+		SDK:      "wapc-guest",
+		Schema:   "schema.widl",
+	},
+	{
+		Name:     "tinygo-v2",
+		Language: "TinyGo",
+		SDK:      "github.com/wapc/wapc-guest-tinygo",
+		Schema:   "schema.v2.widl",
+	},
+	{
+		Name:     "assemblyscript-v2",
+		Language: "AssemblyScript",
+		SDK:      "wapc-guest-as",
+		Schema:   "schema.v2.widl",
+	},
+	{
+		Name:     "rust-v2",
+		Language: "Rust",
+		SDK:      "wapc-guest",
+		Schema:   "schema.v2.widl",
+	},
+}
+
+// Names returns the names of the known guests, built or not.
+func Names() []string {
+	names := make([]string, len(guests))
+	for i, g := range guests {
+		names[i] = g.Name
+	}
+	return names
+}
+
+// Get returns the guest named `name`. The error is ErrNotBuilt for a known
+// guest missing from this package, and an error for a guest without a
+// manifest.
+func Get(name string) (*Guest, error) {
+	for _, g := range guests {
+		if g.Name != name {
+			continue
+		}
+		code, err := files.ReadFile(g.File())
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotBuilt
+		}
+		if err != nil {
+			return nil, err
+		}
+		g.Code = code
+		data, err := files.ReadFile(manifest.Path(g.File()))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, errors.New("build: " + g.File() + " has no manifest")
+		}
+		if err != nil {
+			return nil, err
+		}
+		m, err := manifest.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		g.SchemaHash = m.Schema.SHA256
+		g.SDKVersion = m.SDK[g.SDK]
+		return &g, nil
+	}
+	return nil, errors.New("build: unknown guest " + name)
+}
+
+// All returns the guests that have been built.
+func All() []*Guest {
+	var built []*Guest
+	for _, name := range Names() {
+		if g, err := Get(name); err == nil {
+			built = append(built, g)
+		}
+	}
+	return built
+}
@@ -0,0 +1,48 @@
+package build_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wapc/language-tests/build"
+	"github.com/wapc/language-tests/pkg/manifest"
+)
+
+func TestGet(t *testing.T) {
+	g, err := build.Get("tinygo")
+	require.NoError(t, err)
+	assert.Equal(t, "TinyGo", g.Language)
+	assert.Equal(t, "tinygo.wasm", g.File())
+	assert.Equal(t, "schema.widl", g.Schema)
+	assert.True(t, bytes.HasPrefix(g.Code, []byte("\x00asm")))
+
+	_, err = build.Get("unknown")
+	assert.EqualError(t, err, "build: unknown guest unknown")
+}
+
+func TestAll(t *testing.T) {
+	var names []string
+	for _, g := range build.All() {
+		names = append(names, g.Name)
+		assert.NotEmpty(t, g.Code)
+		assert.Len(t, g.SchemaHash, 64)
+	}
+	assert.Subset(t, build.Names(), names)
+	for _, g := range build.All() {
+		// build.sh records the version of the library the guest uses.
+		m, err := manifest.Read(g.File())
+		require.NoError(t, err)
+		if len(m.SDK) > 0 {
+			assert.NotEmptyf(t, g.SDKVersion, "%s records the version of another SDK than %s", g.Name, g.SDK)
+		}
+	}
+	assert.Subset(t, names, []string{"tinygo", "assemblyscript", "rust"})
+	for _, name := range build.Names() {
+		if _, err := build.Get(name); err != nil {
+			assert.Equal(t, build.ErrNotBuilt, err, name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Command codegen generates the bindings of the schemas, from the root of the
+// repository:
+//
+//	go run ./cmd/codegen
+//
+// With -check, it writes nothing and fails if a generated file is not up to
+// date with its schema.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wapc/language-tests/pkg/codegen"
+	"github.com/wapc/language-tests/pkg/widl"
+)
+
+// target is a file generated from a schema.
+type target struct {
+	schema   string
+	output   string
+	generate func(*widl.Document, codegen.Config) ([]byte, error)
+	config   codegen.Config
+}
+
+var targets = []target{
+	{"schema.widl", "pkg/module/module.go", codegen.Host, codegen.Config{
+		Package: "module",
+		Types:   map[string]codegen.Type{"UUID": {Import: "github.com/wapc/language-tests/pkg/scalar", Name: "scalar.UUID"}},
+	}},
+	{"schema.widl", "tinygo/module/module.go", codegen.TinyGo, codegen.Config{
+		Package: "module",
+		Types:   map[string]codegen.Type{"UUID": {Import: "github.com/wapc/language-tests/tinygo/scalar", Name: "scalar.UUID"}},
+	}},
+	{"schema.widl", "assembly/module.ts", codegen.AssemblyScript, codegen.Config{}},
+	{"schema.widl", "rust/src/generated.rs", codegen.Rust, codegen.Config{}},
+	{"schema.v2.widl", "pkg/modulev2/module.go", codegen.Host, codegen.Config{Package: "modulev2"}},
+	{"schema.v2.widl", "tinygo/v2/module/module.go", codegen.TinyGo, codegen.Config{Package: "module"}},
+	{"schema.v2.widl", "assembly/v2/module.ts", codegen.AssemblyScript, codegen.Config{}},
+	{"schema.v2.widl", "rust/v2/src/generated.rs", codegen.Rust, codegen.Config{}},
+}
+
+func main() {
+	check := flag.Bool("check", false, "fail if a generated file is not up to date instead of writing it")
+	flag.Parse()
+	stale := false
+	for _, t := range targets {
+		code, err := generate(t)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if *check {
+			current, err := os.ReadFile(t.output)
+			if err != nil || !bytes.Equal(current, code) {
+				fmt.Fprintln(os.Stderr, t.output, "is not up to date with", t.schema)
+				stale = true
+			}
+			continue
+		}
+		if err := os.WriteFile(t.output, code, 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if stale {
+		os.Exit(1)
+	}
+}
+
+func generate(t target) ([]byte, error) {
+	src, err := os.ReadFile(t.schema)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := widl.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", t.schema, err)
+	}
+	code, err := t.generate(doc, t.config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", t.output, err)
+	}
+	return code, nil
+}
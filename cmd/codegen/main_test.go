@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUpToDate fails when a generated file was edited by hand or not
+// regenerated after changing its schema: run `go run ./cmd/codegen`.
+func TestUpToDate(t *testing.T) {
+	require.NoError(t, os.Chdir("../.."))
+	for _, target := range targets {
+		code, err := generate(target)
+		require.NoError(t, err)
+		current, err := os.ReadFile(target.output)
+		require.NoError(t, err)
+		assert.Equal(t, string(code), string(current), "%s is not up to date with %s", target.output, target.schema)
+	}
+}
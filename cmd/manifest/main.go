@@ -0,0 +1,56 @@
+// Command manifest writes the manifest of a guest after build.sh builds it:
+//
+//	go run ./cmd/manifest -schema schema.widl -toolchain "tinygo=$(tinygo version)" \
+//	  -sdk github.com/wapc/wapc-guest-tinygo=v0.3.1 \
+//	  build/tinygo.wasm tinygo/module/module.go tinygo/main.go
+//
+// The first argument is the guest and the others are the sources it was built
+// from. Paths are relative to the root of the repository, the working
+// directory.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wapc/language-tests/pkg/manifest"
+)
+
+// versions maps names to versions, from repeated name=version flags.
+type versions map[string]string
+
+func (t versions) String() string {
+	return fmt.Sprint(map[string]string(t))
+}
+
+func (t versions) Set(value string) error {
+	i := strings.IndexByte(value, '=')
+	if i < 0 {
+		return fmt.Errorf("%q is not name=version", value)
+	}
+	t[value[:i]] = strings.TrimSpace(value[i+1:])
+	return nil
+}
+
+func main() {
+	schema := flag.String("schema", "schema.widl", "the schema the guest was generated from")
+	tools := versions{}
+	flag.Var(tools, "toolchain", "a `name=version` of a tool used to build the guest (repeatable)")
+	sdk := versions{}
+	flag.Var(sdk, "sdk", "the `name=version` of the waPC guest library of the guest")
+	flag.Parse()
+	if flag.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "usage: manifest [-schema file] [-toolchain name=version]... [-sdk name=version] wasm [source]...")
+		os.Exit(2)
+	}
+	m, err := manifest.New(".", flag.Arg(0), *schema, flag.Args()[1:], tools, sdk)
+	if err == nil {
+		err = m.Write(".")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
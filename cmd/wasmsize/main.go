@@ -0,0 +1,91 @@
+// Command wasmsize reports the section sizes of the guests and compares them
+// with the baseline in build/sizes.json:
+//
+//	go run ./cmd/wasmsize build/*.wasm
+//
+// It exits with status 1 if a guest grew more than the threshold. After an
+// intended change in size, update the baseline with -update, which refuses to
+// record a guest whose manifest is missing or stale.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/wapc/language-tests/pkg/manifest"
+	"github.com/wapc/language-tests/pkg/size"
+)
+
+func main() {
+	baselineFile := flag.String("baseline", "build/sizes.json", "the committed sizes")
+	threshold := flag.Float64("threshold", size.DefaultThreshold, "the allowed growth in `percent`")
+	update := flag.Bool("update", false, "write the current sizes to the baseline")
+	flag.Parse()
+	files := flag.Args()
+	if len(files) == 0 {
+		files, _ = filepath.Glob("build/*.wasm")
+	}
+
+	baseline, err := size.ReadBaseline(*baselineFile)
+	if os.IsNotExist(err) {
+		baseline, err = size.Baseline{}, nil
+	}
+	if err != nil {
+		fail(err)
+	}
+	current := size.Baseline{}
+	for _, file := range files {
+		code, err := ioutil.ReadFile(file)
+		if err != nil {
+			fail(err)
+		}
+		if current[filepath.Base(file)], err = size.Measure(code); err != nil {
+			fail(fmt.Errorf("%s: %v", file, err))
+		}
+	}
+	if *update {
+		for _, file := range files {
+			if err := manifest.Check(".", file); err != nil {
+				fail(fmt.Errorf("not updating the baseline: %v; rebuild it with build.sh", err))
+			}
+		}
+		for name, sizes := range current {
+			baseline[name] = sizes
+		}
+		if err := baseline.Write(*baselineFile); err != nil {
+			fail(err)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "guest\ttotal\tcode\tdata\tcustom\tother\tbaseline\t")
+	var problems []string
+	for _, name := range current.Names() {
+		s := current[name]
+		was, ok := baseline[name]
+		delta := "none"
+		if ok {
+			delta = size.Delta(was.Total, s.Total)
+			for _, p := range size.Compare(was, s, *threshold) {
+				problems = append(problems, name+": "+p)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%s\t\n", name, s.Total, s.Code, s.Data, s.Custom, s.Other, delta)
+	}
+	w.Flush()
+	for _, p := range problems {
+		fmt.Fprintln(os.Stderr, p)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
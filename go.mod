@@ -1,13 +1,28 @@
 module github.com/wapc/language-tests
 
-go 1.15
+go 1.18
 
 require (
 	github.com/AlekSi/pointer v1.1.0
 	github.com/stretchr/testify v1.6.1
 	github.com/vmihailenco/msgpack/v4 v4.3.12
-	github.com/vmihailenco/tagparser v0.1.2 // indirect
 	github.com/wapc/tinygo-msgpack v0.0.0-20201027001802-3eaeb9a9f930
 	github.com/wapc/wapc-go v0.2.1
 	github.com/wapc/wapc-guest-tinygo v0.3.1-0.20201004151320-30e64592db53
 )
+
+require (
+	github.com/Workiva/go-datastructures v1.0.52 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/protobuf v1.3.4 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/vmihailenco/tagparser v0.1.2 // indirect
+	github.com/wasmerio/go-ext-wasm v0.3.1 // indirect
+	golang.org/x/net v0.0.0-20200301022130-244492dfa37a // indirect
+	google.golang.org/appengine v1.6.5 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
+
+// The fork adds extension types to tinygo-msgpack, see its README.
+replace github.com/wapc/tinygo-msgpack => ./third_party/tinygo-msgpack
@@ -0,0 +1,51 @@
+// Package uuid parses and formats the canonical form of UUIDs for both the
+// host and the TinyGo scalar packages, so that they accept the same strings.
+// It has no dependencies that TinyGo cannot build.
+package uuid
+
+const hexDigits = "0123456789abcdef"
+
+// Parse parses the canonical form of a UUID, lowercase hexadecimal digits
+// grouped 8-4-4-4-12, such as "6ba7b810-9dad-11d1-80b4-00c04fd430c8". Any
+// other form, including uppercase digits, is rejected so that formatting the
+// UUID gives back `s`.
+func Parse(s string) ([16]byte, bool) {
+	var u [16]byte
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, false
+	}
+	for i, j := 0, 0; i < len(u); i, j = i+1, j+2 {
+		if j == 8 || j == 13 || j == 18 || j == 23 {
+			j++
+		}
+		hi, ok := digit(s[j])
+		lo, ok2 := digit(s[j+1])
+		if !ok || !ok2 {
+			return u, false
+		}
+		u[i] = hi<<4 | lo
+	}
+	return u, true
+}
+
+func digit(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	}
+	return 0, false
+}
+
+// Format returns the canonical form of `u`.
+func Format(u [16]byte) string {
+	b := make([]byte, 0, 36)
+	for i, v := range u {
+		if i == 4 || i == 6 || i == 8 || i == 10 {
+			b = append(b, '-')
+		}
+		b = append(b, hexDigits[v>>4], hexDigits[v&0xf])
+	}
+	return string(b)
+}
@@ -0,0 +1,128 @@
+// Package abi reads the imports and exports of a guest and checks them
+// against the waPC ABI implemented by the host, so that a guest importing a
+// function the host does not provide fails the tests instead of failing to
+// instantiate in production.
+package abi
+
+import (
+	"sort"
+)
+
+// Imports are the functions the waPC host provides, by module and name.
+var Imports = map[string]map[string]Signature{
+	"wapc": {
+		"__guest_request":     sig(i32s(2)),
+		"__guest_response":    sig(i32s(2)),
+		"__guest_error":       sig(i32s(2)),
+		"__host_call":         sig(i32s(8), I32),
+		"__host_response_len": sig(nil, I32),
+		"__host_response":     sig(i32s(1)),
+		"__host_error_len":    sig(nil, I32),
+		"__host_error":        sig(i32s(1)),
+		"__console_log":       sig(i32s(2)),
+	},
+	// The host only implements writing to standard out.
+	"wasi_unstable": {
+		"fd_write": sig(i32s(4), I32),
+	},
+}
+
+// RequiredImports are the functions of the `wapc` module that every guest
+// must import to receive a call and answer it. The other functions of
+// Imports are allowed but not required: a guest that never calls the host,
+// such as the schema version 2 guest, may leave out `__host_call` and the
+// functions reading its result.
+var RequiredImports = []string{
+	"__guest_request",
+	"__guest_response",
+	"__guest_error",
+}
+
+// GuestCall is the signature of the `__guest_call` export, which takes the
+// sizes of the operation name and payload and returns 1 on success.
+var GuestCall = sig(i32s(2), I32)
+
+// InitFunctions are the exports the host calls to initialize an instance. A
+// guest must export at least one of them.
+var InitFunctions = []string{"wapc_init", "_start"}
+
+// Check returns the differences between the imports and exports of `m` and
+// the waPC ABI. The problems of the imports, in module order, come first,
+// then the missing imports, in the order of RequiredImports, then the
+// problems of the exports.
+func Check(m *Module) []string {
+	var problems []string
+	imported := map[string]bool{}
+	for _, i := range m.Imports {
+		if i.Kind != Func {
+			problems = append(problems, "import "+i.String()+" is not a function")
+			continue
+		}
+		want, ok := Imports[i.Module][i.Name]
+		switch {
+		case !ok:
+			problems = append(problems, "import "+i.Module+"."+i.Name+" is not provided by the host")
+		case !i.Signature.Equal(want):
+			problems = append(problems, "import "+i.Module+"."+i.Name+" is "+i.Signature.String()+", want "+want.String())
+		}
+		if i.Module == "wapc" {
+			imported[i.Name] = true
+		}
+	}
+	for _, name := range RequiredImports {
+		if !imported[name] {
+			problems = append(problems, "no wapc."+name+" import")
+		}
+	}
+
+	exports := map[string]Export{}
+	for _, e := range m.Exports {
+		exports[e.Name] = e
+	}
+	if e, ok := exports["memory"]; !ok || e.Kind != Memory {
+		problems = append(problems, "no memory export")
+	}
+	if e, ok := exports["__guest_call"]; !ok || e.Kind != Func {
+		problems = append(problems, "no __guest_call export")
+	} else if !e.Signature.Equal(GuestCall) {
+		problems = append(problems, "export __guest_call is "+e.Signature.String()+", want "+GuestCall.String())
+	}
+	init := false
+	for _, name := range InitFunctions {
+		e, ok := exports[name]
+		if !ok || e.Kind != Func {
+			continue
+		}
+		init = true
+		if want := sig(nil); !e.Signature.Equal(want) {
+			problems = append(problems, "export "+name+" is "+e.Signature.String()+", want "+want.String())
+		}
+	}
+	if !init {
+		problems = append(problems, "no wapc_init or _start export")
+	}
+	return problems
+}
+
+// Report is the ABI of a guest along with its problems.
+type Report struct {
+	Imports  []Import
+	Exports  []Export
+	Problems []string
+}
+
+// NewReport parses `code` and checks it. Imports and exports are sorted by
+// name.
+func NewReport(code []byte) (*Report, error) {
+	m, err := Parse(code)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{Imports: m.Imports, Exports: m.Exports, Problems: Check(m)}
+	sort.SliceStable(r.Imports, func(i, j int) bool {
+		a, b := r.Imports[i], r.Imports[j]
+		return a.Module < b.Module || a.Module == b.Module && a.Name < b.Name
+	})
+	sort.SliceStable(r.Exports, func(i, j int) bool { return r.Exports[i].Name < r.Exports[j].Name })
+	return r, nil
+}
@@ -0,0 +1,211 @@
+package abi_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wapc/language-tests/pkg/abi"
+)
+
+// builder writes a WebAssembly binary with the sections read by abi.Parse.
+// Exported functions are numbered after all imported ones.
+type builder struct {
+	types, imports, funcs, exports [][]byte
+	importedFuncs                  int
+	exportedFuncs                  []string
+}
+
+func leb(v int) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func name(s string) []byte {
+	return append(leb(len(s)), s...)
+}
+
+func (b *builder) typ(params, results int) int {
+	t := append([]byte{0x60}, leb(params)...)
+	for i := 0; i < params; i++ {
+		t = append(t, byte(abi.I32))
+	}
+	t = append(t, leb(results)...)
+	for i := 0; i < results; i++ {
+		t = append(t, byte(abi.I32))
+	}
+	b.types = append(b.types, t)
+	return len(b.types) - 1
+}
+
+func (b *builder) importFunc(module, field string, params, results int) {
+	i := append(name(module), name(field)...)
+	i = append(append(i, byte(abi.Func)), leb(b.typ(params, results))...)
+	b.imports = append(b.imports, i)
+	b.importedFuncs++
+}
+
+func (b *builder) importMemory(module, field string) {
+	i := append(name(module), name(field)...)
+	b.imports = append(b.imports, append(i, byte(abi.Memory), 0, 1))
+}
+
+func (b *builder) exportFunc(field string, params, results int) {
+	b.funcs = append(b.funcs, leb(b.typ(params, results)))
+	b.exportedFuncs = append(b.exportedFuncs, field)
+}
+
+func (b *builder) exportMemory() {
+	b.exports = append(b.exports, append(name("memory"), byte(abi.Memory), 0))
+}
+
+func (b *builder) bytes() []byte {
+	code := []byte("\x00asm\x01\x00\x00\x00")
+	exports := b.exports
+	for i, field := range b.exportedFuncs {
+		e := append(name(field), byte(abi.Func))
+		exports = append(exports, append(e, leb(b.importedFuncs+i)...))
+	}
+	for _, section := range []struct {
+		id      byte
+		entries [][]byte
+	}{{1, b.types}, {2, b.imports}, {3, b.funcs}, {7, exports}} {
+		body := leb(len(section.entries))
+		for _, e := range section.entries {
+			body = append(body, e...)
+		}
+		code = append(append(append(code, section.id), leb(len(body))...), body...)
+	}
+	return code
+}
+
+func guest() *builder {
+	b := &builder{}
+	b.importFunc("wapc", "__guest_request", 2, 0)
+	b.importFunc("wapc", "__guest_response", 2, 0)
+	b.importFunc("wapc", "__guest_error", 2, 0)
+	b.importFunc("wapc", "__host_call", 8, 1)
+	b.importFunc("wapc", "__host_response_len", 0, 1)
+	b.importFunc("wapc", "__host_response", 1, 0)
+	b.importFunc("wapc", "__host_error_len", 0, 1)
+	b.importFunc("wapc", "__host_error", 1, 0)
+	b.exportMemory()
+	b.exportFunc("__guest_call", 2, 1)
+	b.exportFunc("wapc_init", 0, 0)
+	return b
+}
+
+func TestParse(t *testing.T) {
+	m, err := abi.Parse(guest().bytes())
+	require.NoError(t, err)
+	require.Len(t, m.Imports, 8)
+	assert.Equal(t, "wapc.__host_call (i32, i32, i32, i32, i32, i32, i32, i32) -> (i32)", m.Imports[3].String())
+	require.Len(t, m.Exports, 3)
+	assert.Equal(t, "memory (memory)", m.Exports[0].String())
+	assert.Equal(t, "__guest_call (i32, i32) -> (i32)", m.Exports[1].String())
+	assert.Equal(t, "wapc_init () -> ()", m.Exports[2].String())
+	assert.Empty(t, abi.Check(m))
+
+	_, err = abi.Parse([]byte("not wasm"))
+	assert.EqualError(t, err, "abi: not a WebAssembly module")
+	code := guest().bytes()
+	_, err = abi.Parse(code[:len(code)-3])
+	assert.EqualError(t, err, "abi: truncated module")
+}
+
+func TestCheck(t *testing.T) {
+	b := &builder{}
+	b.importFunc("wasi_snapshot_preview1", "proc_exit", 1, 0)
+	b.importFunc("wasi_unstable", "fd_write", 4, 1)
+	b.importFunc("wapc", "__host_call", 6, 1)
+	b.importMemory("env", "memory")
+	b.exportFunc("__guest_call", 2, 0)
+	b.exportFunc("_start", 1, 0)
+	m, err := abi.Parse(b.bytes())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"import wasi_snapshot_preview1.proc_exit is not provided by the host",
+		"import wapc.__host_call is (i32, i32, i32, i32, i32, i32) -> (i32), want (i32, i32, i32, i32, i32, i32, i32, i32) -> (i32)",
+		"import env.memory (memory) is not a function",
+		"no wapc.__guest_request import",
+		"no wapc.__guest_response import",
+		"no wapc.__guest_error import",
+		"no memory export",
+		"export __guest_call is (i32, i32) -> (), want (i32, i32) -> (i32)",
+		"export _start is (i32) -> (), want () -> ()",
+	}, abi.Check(m))
+
+	m, err = abi.Parse((&builder{}).bytes())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"no wapc.__guest_request import",
+		"no wapc.__guest_response import",
+		"no wapc.__guest_error import",
+		"no memory export",
+		"no __guest_call export",
+		"no wapc_init or _start export",
+	}, abi.Check(m))
+}
+
+func TestReport(t *testing.T) {
+	b := guest()
+	b.importFunc("wapc", "__console_log", 2, 0)
+	r, err := abi.NewReport(b.bytes())
+	require.NoError(t, err)
+	var imports []string
+	for _, i := range r.Imports {
+		imports = append(imports, i.Name)
+	}
+	assert.Equal(t, []string{
+		"__console_log", "__guest_error", "__guest_request", "__guest_response",
+		"__host_call", "__host_error", "__host_error_len", "__host_response", "__host_response_len",
+	}, imports)
+	assert.Equal(t, "__guest_call", r.Exports[0].Name)
+	assert.Empty(t, r.Problems)
+}
+
+// TestRequiredImports checks that a guest importing only the required
+// functions passes, and that one importing them under another module, or not
+// at all, is reported.
+func TestRequiredImports(t *testing.T) {
+	b := &builder{}
+	for _, name := range abi.RequiredImports {
+		want := abi.Imports["wapc"][name]
+		b.importFunc("wapc", name, len(want.Params), len(want.Results))
+	}
+	b.exportMemory()
+	b.exportFunc("__guest_call", 2, 1)
+	b.exportFunc("wapc_init", 0, 0)
+	m, err := abi.Parse(b.bytes())
+	require.NoError(t, err)
+	assert.Empty(t, abi.Check(m), "a guest that never calls the host")
+
+	for _, missing := range abi.RequiredImports {
+		b := &builder{}
+		for _, name := range abi.RequiredImports {
+			module := "wapc"
+			if name == missing {
+				module = "env"
+			}
+			want := abi.Imports["wapc"][name]
+			b.importFunc(module, name, len(want.Params), len(want.Results))
+		}
+		b.exportMemory()
+		b.exportFunc("__guest_call", 2, 1)
+		b.exportFunc("_start", 0, 0)
+		m, err := abi.Parse(b.bytes())
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"import env." + missing + " is not provided by the host",
+			"no wapc." + missing + " import",
+		}, abi.Check(m), missing)
+	}
+}
@@ -0,0 +1,333 @@
+package abi
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ValueType is a WebAssembly value type.
+type ValueType byte
+
+const (
+	I32 ValueType = 0x7f
+	I64 ValueType = 0x7e
+	F32 ValueType = 0x7d
+	F64 ValueType = 0x7c
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case I32:
+		return "i32"
+	case I64:
+		return "i64"
+	case F32:
+		return "f32"
+	case F64:
+		return "f64"
+	}
+	return "0x" + strconv.FormatUint(uint64(t), 16)
+}
+
+// Signature is the type of a function.
+type Signature struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+func sig(params []ValueType, results ...ValueType) Signature {
+	return Signature{params, results}
+}
+
+func i32s(n int) []ValueType {
+	types := make([]ValueType, n)
+	for i := range types {
+		types[i] = I32
+	}
+	return types
+}
+
+func (s Signature) String() string {
+	list := func(types []ValueType) string {
+		names := make([]string, len(types))
+		for i, t := range types {
+			names[i] = t.String()
+		}
+		return "(" + strings.Join(names, ", ") + ")"
+	}
+	return list(s.Params) + " -> " + list(s.Results)
+}
+
+// Equal reports whether both signatures have the same parameters and results.
+func (s Signature) Equal(other Signature) bool {
+	return s.String() == other.String()
+}
+
+// Kind is the kind of an import or export.
+type Kind byte
+
+const (
+	Func Kind = iota
+	Table
+	Memory
+	Global
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Func:
+		return "func"
+	case Table:
+		return "table"
+	case Memory:
+		return "memory"
+	case Global:
+		return "global"
+	}
+	return "kind " + strconv.Itoa(int(k))
+}
+
+// Import is an import of a module. Signature is only set for functions.
+type Import struct {
+	Module    string
+	Name      string
+	Kind      Kind
+	Signature Signature
+}
+
+func (i Import) String() string {
+	s := i.Module + "." + i.Name
+	if i.Kind != Func {
+		return s + " (" + i.Kind.String() + ")"
+	}
+	return s + " " + i.Signature.String()
+}
+
+// Export is an export of a module. Signature is only set for functions.
+type Export struct {
+	Name      string
+	Kind      Kind
+	Signature Signature
+}
+
+func (e Export) String() string {
+	if e.Kind != Func {
+		return e.Name + " (" + e.Kind.String() + ")"
+	}
+	return e.Name + " " + e.Signature.String()
+}
+
+// Module holds the imports and exports of a WebAssembly module.
+type Module struct {
+	Imports []Import
+	Exports []Export
+}
+
+var errTruncated = errors.New("abi: truncated module")
+
+type reader struct {
+	data []byte
+	err  error
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil || len(r.data) == 0 {
+		r.fail(errTruncated)
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *reader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+	r.data = nil
+}
+
+// u32 reads an unsigned LEB128 integer.
+func (r *reader) u32() uint32 {
+	var v uint32
+	for shift := uint(0); shift < 35; shift += 7 {
+		b := r.byte()
+		v |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v
+		}
+	}
+	r.fail(errors.New("abi: integer too long"))
+	return 0
+}
+
+func (r *reader) bytes(n uint32) []byte {
+	if r.err != nil || uint32(len(r.data)) < n {
+		r.fail(errTruncated)
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *reader) name() string {
+	return string(r.bytes(r.u32()))
+}
+
+func (r *reader) valueTypes() []ValueType {
+	n := r.u32()
+	if n > uint32(len(r.data)) {
+		r.fail(errTruncated)
+		return nil
+	}
+	types := make([]ValueType, n)
+	for i := range types {
+		types[i] = ValueType(r.byte())
+	}
+	return types
+}
+
+func (r *reader) limits() {
+	if r.byte()&1 != 0 {
+		r.u32()
+	}
+	r.u32()
+}
+
+// Section is a section of a module. Size is the size of its contents,
+// without the section ID and size.
+type Section struct {
+	ID byte
+	// Name is the name of custom sections and the standard name, such as
+	// "code", of the others.
+	Name string
+	Size int
+}
+
+var sectionNames = []string{"custom", "type", "import", "function", "table",
+	"memory", "global", "export", "start", "element", "code", "data", "datacount"}
+
+// Sections lists the sections of the WebAssembly binary `code` in order.
+func Sections(code []byte) ([]Section, error) {
+	r := &reader{data: code}
+	if string(r.bytes(4)) != "\x00asm" {
+		return nil, errors.New("abi: not a WebAssembly module")
+	}
+	r.bytes(4)
+	var sections []Section
+	for r.err == nil && len(r.data) > 0 {
+		s := Section{ID: r.byte()}
+		body := r.bytes(r.u32())
+		s.Size = len(body)
+		if s.ID == 0 {
+			s.Name = (&reader{data: body}).name()
+		} else if int(s.ID) < len(sectionNames) {
+			s.Name = sectionNames[s.ID]
+		} else {
+			s.Name = "section " + strconv.Itoa(int(s.ID))
+		}
+		sections = append(sections, s)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return sections, nil
+}
+
+type export struct {
+	Export
+	index uint32
+}
+
+// Parse reads the type, import, function and export sections of the
+// WebAssembly binary `code`.
+func Parse(code []byte) (*Module, error) {
+	r := &reader{data: code}
+	if string(r.bytes(4)) != "\x00asm" {
+		return nil, errors.New("abi: not a WebAssembly module")
+	}
+	r.bytes(4)
+	var (
+		m     Module
+		types []Signature
+		// funcs are the types of the functions, imported ones first.
+		funcs   []uint32
+		exports []export
+	)
+	for r.err == nil && len(r.data) > 0 {
+		id := r.byte()
+		s := &reader{data: r.bytes(r.u32())}
+		switch id {
+		case 1: // type
+			for n := s.u32(); n > 0 && s.err == nil; n-- {
+				if s.byte() != 0x60 {
+					s.fail(errors.New("abi: invalid function type"))
+				}
+				params := s.valueTypes()
+				types = append(types, Signature{params, s.valueTypes()})
+			}
+		case 2: // import
+			for n := s.u32(); n > 0 && s.err == nil; n-- {
+				i := Import{Module: s.name(), Name: s.name(), Kind: Kind(s.byte())}
+				switch i.Kind {
+				case Func:
+					funcs = append(funcs, s.u32())
+				case Table:
+					s.byte()
+					s.limits()
+				case Memory:
+					s.limits()
+				case Global:
+					s.bytes(2)
+				default:
+					s.fail(errors.New("abi: invalid import kind"))
+				}
+				m.Imports = append(m.Imports, i)
+			}
+		case 3: // function
+			for n := s.u32(); n > 0 && s.err == nil; n-- {
+				funcs = append(funcs, s.u32())
+			}
+		case 7: // export
+			for n := s.u32(); n > 0 && s.err == nil; n-- {
+				e := Export{Name: s.name(), Kind: Kind(s.byte())}
+				exports = append(exports, export{e, s.u32()})
+			}
+		}
+		if s.err != nil {
+			return nil, s.err
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	signature := func(index uint32) (Signature, error) {
+		if index >= uint32(len(funcs)) || funcs[index] >= uint32(len(types)) {
+			return Signature{}, errors.New("abi: invalid function index")
+		}
+		return types[funcs[index]], nil
+	}
+	f := 0
+	for i := range m.Imports {
+		if m.Imports[i].Kind == Func {
+			var err error
+			if m.Imports[i].Signature, err = signature(uint32(f)); err != nil {
+				return nil, err
+			}
+			f++
+		}
+	}
+	for _, e := range exports {
+		if e.Kind == Func {
+			var err error
+			if e.Signature, err = signature(e.index); err != nil {
+				return nil, err
+			}
+		}
+		m.Exports = append(m.Exports, e.Export)
+	}
+	return &m, nil
+}
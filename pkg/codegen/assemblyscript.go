@@ -0,0 +1,920 @@
+package codegen
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/wapc/language-tests/pkg/widl"
+)
+
+// AssemblyScript generates the bindings of an AssemblyScript guest: the
+// Handlers of the operations of the interface, the Host and role clients
+// calling the host, and the types of the schema with their codecs. The code
+// decodes and encodes values with @wapc/as-msgpack, and matches @pattern
+// constraints with the regexp module of the guest.
+//
+// AssemblyScript has no exceptions, so errors abort the guest with their
+// message. As in the TinyGo bindings, required fields missing from a
+// payload keep their zero value. @wapc/as-msgpack has no extension types,
+// so decoding or encoding a datetime aborts the guest.
+func AssemblyScript(doc *widl.Document, config Config) ([]byte, error) {
+	if len(config.Types) > 0 {
+		return nil, errors.New("the AssemblyScript bindings cannot map aliases to other types")
+	}
+	g, err := newGenerator(doc, config, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.checkKeys("AssemblyScript"); err != nil {
+		return nil, err
+	}
+	return spaces(g.asFile(), 2), nil
+}
+
+// asPrims maps the built-in types of WIDL to their AssemblyScript type and
+// the suffix of the msgpack methods reading and writing them.
+var asPrims = map[string][2]string{
+	"bool":   {"bool", "Bool"},
+	"u8":     {"u8", "UInt8"},
+	"u16":    {"u16", "UInt16"},
+	"u32":    {"u32", "UInt32"},
+	"u64":    {"u64", "UInt64"},
+	"i8":     {"i8", "Int8"},
+	"i16":    {"i16", "Int16"},
+	"i32":    {"i32", "Int32"},
+	"i64":    {"i64", "Int64"},
+	"f32":    {"f32", "Float32"},
+	"f64":    {"f64", "Float64"},
+	"string": {"string", "String"},
+	"bytes":  {"ArrayBuffer", "ByteArray"},
+}
+
+func (g *generator) asFile() []string {
+	ops := operations(g.doc.Interface())
+	streams := streamTypes(ops)
+	msgpack := "Decoder, Writer, Encoder, Sizer"
+	if g.walkTypes(func(t *widl.TypeRef) bool { return t.Optional && g.isValue(t) }) {
+		msgpack += ", Value"
+	}
+	out := []string{
+		generatedComment,
+		"",
+		"import { " + msgpack + " } from \"@wapc/as-msgpack\";",
+		`import { register, hostCall } from "wapc-guest-as";`,
+	}
+	if g.uses.regexp {
+		out = append(out, `import { RegExp } from "./regexp";`)
+	}
+	out = append(out, "", execute("as_missing_field", nil))
+	if len(g.constrained) > 0 {
+		out = append(out, execute("as_validation_error", nil))
+	}
+	if len(g.doc.Unions) > 0 {
+		out = append(out, execute("as_union_error", nil))
+	}
+	if len(g.recursive) > 0 {
+		out = append(out, execute("as_depth", nil))
+	}
+	if g.usesTime() {
+		out = append(out, execute("as_timestamp", nil))
+	}
+	if g.asEncodesValues(ops) {
+		out = append(out, execute("as_to_buffer", nil))
+	}
+	out = append(out, g.asPatterns()...)
+	out = append(out, g.asHost("Host", nil, g.doc.Namespace.Name, ops)...)
+	out = append(out, g.asOps(ops)...)
+	if len(streams) > 0 {
+		out = append(out, execute("as_streams", nil))
+		for _, name := range streams {
+			out = append(out, g.asClass(frameType(name), []string{
+				"// " + name + "Frame is a chunk of a stream of " + name + ". Frames are numbered from 0",
+				"// and the last one has end set.",
+			})...)
+			out = append(out, execute("as_stream", name))
+		}
+	}
+	for _, role := range g.roles() {
+		host := role.Name + "Host"
+		comment := []string{"// " + host + " calls the host operations of the " + role.Namespace() + " namespace."}
+		roleOps := operations(role)
+		out = append(out, g.asHost(host, append(comment, doc(role.Description)...), role.Namespace(), roleOps)...)
+		for _, op := range roleOps {
+			if op.hasArgs() {
+				out = append(out, g.asClass(op.argsType(), nil)...)
+			}
+		}
+	}
+	for _, t := range g.doc.Types {
+		out = append(out, g.asClass(t, doc(t.Description))...)
+	}
+	for _, u := range g.doc.Unions {
+		out = append(out, g.asUnion(u)...)
+	}
+	for _, a := range g.doc.Aliases {
+		out = append(out, doc(a.Description)...)
+		out = append(out, "export type "+a.Name+" = "+asPrims[a.Type.Name][0]+";", "")
+	}
+	for _, e := range g.doc.Enums {
+		out = append(out, doc(e.Description)...)
+		out = append(out, "export enum "+e.Name+" {")
+		for _, v := range e.Values {
+			out = append(out, "\t"+goName(v.Name)+" = "+strconv.Itoa(v.Index)+",")
+		}
+		out = append(out, "}", "")
+	}
+	return out
+}
+
+// isValue reports whether values of type t are numbers, bools or strings,
+// which an optional holds in a Value, as they cannot be null.
+func (g *generator) isValue(t *widl.TypeRef) bool {
+	if t.Kind != widl.Named {
+		return false
+	}
+	name := t.Name
+	if g.isAlias(t) {
+		name = g.aliases[name]
+	}
+	return g.isEnum(t) || (isPrim(&widl.TypeRef{Name: name}) && name != "bytes")
+}
+
+// asType returns the AssemblyScript type of t.
+func (g *generator) asType(t *widl.TypeRef) string {
+	var name string
+	switch {
+	case t.Kind == widl.List:
+		name = "Array<" + g.asType(t.Elem) + ">"
+	case t.Kind == widl.Map:
+		name = "Map<" + g.asType(t.Key) + ", " + g.asType(t.Elem) + ">"
+	case t.Kind == widl.Stream:
+		name = t.Elem.Name + "StreamReader"
+	case isPrim(t):
+		name = asPrims[t.Name][0]
+	case t.Name == "datetime":
+		name = "Timestamp"
+	default:
+		name = t.Name
+	}
+	switch {
+	case !t.Optional:
+		return name
+	case g.isValue(t):
+		return "Value<" + name + "> | null"
+	}
+	return name + " | null"
+}
+
+// asZero returns the initial value of a field of type t.
+func (g *generator) asZero(t *widl.TypeRef) string {
+	if t.Optional {
+		return "null"
+	}
+	name := t.Name
+	if g.isAlias(t) {
+		name = g.aliases[name]
+	}
+	switch {
+	case t.Kind == widl.List || t.Kind == widl.Map:
+		return "new " + g.asType(t) + "()"
+	case g.isEnum(t):
+		return "<" + t.Name + ">0"
+	case name == "bool":
+		return "false"
+	case name == "string":
+		return `""`
+	case name == "bytes":
+		return "new ArrayBuffer(0)"
+	case isPrim(&widl.TypeRef{Name: name}):
+		return "0"
+	case name == "datetime":
+		return "new Timestamp()"
+	}
+	return "new " + t.Name + "()"
+}
+
+// asDefault returns the AssemblyScript expression of the default value of a
+// field.
+func (g *generator) asDefault(f *widl.Field) string {
+	switch {
+	case f.Default.Kind == widl.Identifier && g.isEnum(f.Type):
+		return f.Type.Name + "." + goName(f.Default.Text)
+	case f.Default.Kind == widl.String:
+		return quote(f.Default.Text)
+	}
+	return f.Default.Text
+}
+
+// asRead returns the statements decoding a value of type t from `decoder`
+// and the expression of the value. The variables of the statements are
+// suffixed with `depth`.
+func (g *generator) asRead(t *widl.TypeRef, depth int) ([]string, string) {
+	s := suffix(depth)
+	if t.Optional {
+		if g.isObject(t) {
+			return nil, t.Name + ".decodeNullable(decoder)"
+		}
+		v := "v" + s
+		code, expr := g.asRead(nonOptional(t), depth+1)
+		if g.isValue(t) {
+			expr = "new Value<" + g.asType(nonOptional(t)) + ">(" + expr + ")"
+		}
+		out := []string{"let " + v + ": " + g.asType(t) + " = null;", "if (!decoder.isNextNil()) {"}
+		out = append(out, indent(code, 1)...)
+		return append(out, "\t"+v+" = "+expr+";", "}"), v
+	}
+	switch t.Kind {
+	case widl.List:
+		size, v, i := "size"+s, "v"+s, "i"+s
+		code, expr := g.asRead(t.Elem, depth+1)
+		out := []string{
+			"const " + size + " = decoder.readArraySize();",
+			"const " + v + " = new " + g.asType(t) + "();",
+			"for (let " + i + " = 0; " + i + " < " + size + "; " + i + "++) {",
+		}
+		out = append(out, indent(code, 1)...)
+		return append(out, "\t"+v+".push("+expr+");", "}"), v
+	case widl.Map:
+		size, v, i, k := "size"+s, "v"+s, "i"+s, "k"+s
+		keyCode, key := g.asRead(t.Key, depth+1)
+		code, expr := g.asRead(t.Elem, depth+1)
+		out := []string{
+			"const " + size + " = decoder.readMapSize();",
+			"const " + v + " = new " + g.asType(t) + "();",
+			"for (let " + i + " = 0; " + i + " < " + size + "; " + i + "++) {",
+		}
+		out = append(out, indent(keyCode, 1)...)
+		out = append(out, "\tconst "+k+" = "+key+";")
+		out = append(out, indent(code, 1)...)
+		return append(out, "\t"+v+".set("+k+", "+expr+");", "}"), v
+	}
+	name := t.Name
+	if g.isAlias(t) {
+		name = g.aliases[name]
+	}
+	switch {
+	case name == "datetime":
+		return nil, "Timestamp.decode(decoder)"
+	case g.isEnum(t):
+		return nil, "<" + t.Name + ">decoder.readInt32()"
+	case isPrim(&widl.TypeRef{Name: name}):
+		return nil, "decoder.read" + asPrims[name][1] + "()"
+	}
+	return nil, t.Name + ".decode(decoder)"
+}
+
+// asWrite returns the statements encoding `expr`, a value of type t, with
+// `encoder`. The variables of the statements are suffixed with `depth`,
+// and those at depth 0 are prefixed with `name`, so that the encoding of
+// each field has its own.
+func (g *generator) asWrite(t *widl.TypeRef, expr, name string, depth int) []string {
+	s := suffix(depth)
+	if t.Optional {
+		unboxed := "unboxed" + s
+		value := unboxed
+		if g.isValue(t) {
+			value += ".value"
+		}
+		out := []string{
+			"if (" + expr + " === null) {",
+			"\tencoder.writeNil();",
+			"} else {",
+			"\tconst " + unboxed + " = " + expr + "!;",
+		}
+		out = append(out, indent(g.asWrite(nonOptional(t), value, name, depth+1), 1)...)
+		return append(out, "}")
+	}
+	switch t.Kind {
+	case widl.List:
+		i := "i" + s
+		out := []string{
+			"encoder.writeArraySize(" + expr + ".length);",
+			"for (let " + i + " = 0; " + i + " < " + expr + ".length; " + i + "++) {",
+		}
+		out = append(out, indent(g.asWriteItem(t.Elem, expr+"["+i+"]", name, depth), 1)...)
+		return append(out, "}")
+	case widl.Map:
+		i, keys := "i"+s, "keys"+s
+		if depth == 0 {
+			keys = name + "Keys"
+		}
+		out := []string{
+			"const " + keys + " = " + expr + ".keys();",
+			"encoder.writeMapSize(" + keys + ".length);",
+			"for (let " + i + " = 0; " + i + " < " + keys + ".length; " + i + "++) {",
+		}
+		out = append(out, indent(g.asWrite(t.Key, keys+"["+i+"]", name, depth+1), 1)...)
+		out = append(out, indent(g.asWriteItem(t.Elem, expr+".get("+keys+"["+i+"])", name, depth), 1)...)
+		return append(out, "}")
+	}
+	base := t.Name
+	if g.isAlias(t) {
+		base = g.aliases[base]
+	}
+	switch {
+	case base == "datetime":
+		return []string{expr + ".encode(encoder);"}
+	case g.isEnum(t):
+		return []string{"encoder.writeInt32(" + expr + ");"}
+	case isPrim(&widl.TypeRef{Name: base}):
+		return []string{"encoder.write" + asPrims[base][1] + "(" + expr + ");"}
+	}
+	return []string{expr + ".encode(encoder);"}
+}
+
+// asWriteItem returns the statements encoding `expr`, an item of a
+// collection at `depth`. Items that the statements refer to more than once
+// are bound to a variable first.
+func (g *generator) asWriteItem(t *widl.TypeRef, expr, name string, depth int) []string {
+	if !t.Optional && t.Kind != widl.List && t.Kind != widl.Map {
+		return g.asWrite(t, expr, name, depth+1)
+	}
+	item := "item" + suffix(depth)
+	return append([]string{"const " + item + " = " + expr + ";"}, g.asWrite(t, item, name, depth+1)...)
+}
+
+// asClass returns the class of an object with its codec and, if it has
+// constraints, its validate method. `comment` is its doc comment.
+func (g *generator) asClass(t *widl.Type, comment []string) []string {
+	out := append([]string(nil), comment...)
+	out = append(out, "export class "+t.Name+" {")
+	for _, f := range t.Fields {
+		value := g.asZero(f.Type)
+		if f.Default != nil {
+			value = g.asDefault(f)
+		}
+		out = append(out, indent(doc(f.Description), 1)...)
+		out = append(out, "\t"+f.Name+": "+g.asType(f.Type)+" = "+value+";")
+	}
+	out = append(out, "")
+	out = append(out, indent(asDecodeMethods(t.Name), 1)...)
+	out = append(out, "\tdecode(decoder: Decoder): void {")
+	out = append(out, g.asEnterDecode(t.Name)...)
+	var checks []string
+	for _, f := range t.Fields {
+		if required(f) {
+			checks = append(checks, f.Name)
+		}
+	}
+	if len(checks) > 0 {
+		out = append(out, "\t\tvar present: u64 = 0;")
+	}
+	out = append(out, indent(asFieldLoop(), 2)...)
+	bit := 0
+	for i, f := range t.Fields {
+		els := "} else "
+		if i == 0 {
+			els = ""
+		}
+		code, expr := g.asRead(f.Type, 0)
+		out = append(out, "\t\t\t"+els+"if (field == "+strconv.Quote(f.Name)+") {")
+		out = append(out, indent(code, 4)...)
+		out = append(out, "\t\t\t\tthis."+f.Name+" = "+expr+";")
+		if required(f) {
+			out = append(out, "\t\t\t\tpresent |= "+presentBit(bit)+";")
+			bit++
+		}
+	}
+	out = append(out,
+		"\t\t\t} else {",
+		"\t\t\t\tdecoder.skip();",
+		"\t\t\t}",
+		"\t\t}")
+	out = append(out, g.asExitDecode(t.Name)...)
+	if len(checks) > 0 {
+		out = append(out, "\t\tif (requireFields) {")
+		for i, field := range checks {
+			out = append(out,
+				"\t\t\tif ((present & "+presentBit(i)+") == 0) {",
+				"\t\t\t\tthrow new Error("+strconv.Quote("missing required field "+t.Name+"."+field)+");",
+				"\t\t\t}")
+		}
+		out = append(out, "\t\t}")
+	}
+	out = append(out,
+		"\t}",
+		"",
+		"\tencode(encoder: Writer): void {",
+		"\t\tencoder.writeMapSize("+strconv.Itoa(len(t.Fields))+");")
+	for _, f := range t.Fields {
+		out = append(out, "\t\tencoder.writeString("+strconv.Quote(f.Name)+");")
+		out = append(out, indent(g.asWrite(f.Type, "this."+f.Name, f.Name, 0), 2)...)
+	}
+	out = append(out, "\t}", "")
+	out = append(out, indent(asToBuffer(), 1)...)
+	out = append(out, indent(g.asCheckMethods(t), 1)...)
+	return append(out, "}", "")
+}
+
+// asDecodeMethods returns the static methods decoding an object.
+func asDecodeMethods(name string) []string {
+	return []string{
+		"static decodeNullable(decoder: Decoder): " + name + " | null {",
+		"\tif (decoder.isNextNil()) return null;",
+		"\treturn " + name + ".decode(decoder);",
+		"}",
+		"",
+		"static decode(decoder: Decoder): " + name + " {",
+		"\tconst o = new " + name + "();",
+		"\to.decode(decoder);",
+		"\treturn o;",
+		"}",
+		"",
+	}
+}
+
+// presentBit returns the bit of the `present` mask of a decode method that
+// records the required field numbered `bit`.
+func presentBit(bit int) string {
+	return "0x" + strconv.FormatUint(1<<uint(bit), 16)
+}
+
+// asEnterDecode returns the first statements of the decode method of `name`,
+// which count the nesting of a recursive object against MAX_DEPTH.
+func (g *generator) asEnterDecode(name string) []string {
+	if !g.recursive[name] {
+		return nil
+	}
+	return []string{"\t\tenterDecode(" + strconv.Quote(name) + ");"}
+}
+
+// asExitDecode returns the last statement of the decode method of `name`.
+func (g *generator) asExitDecode(name string) []string {
+	if !g.recursive[name] {
+		return nil
+	}
+	return []string{"\t\tdecodeDepth--;"}
+}
+
+// asFieldLoop returns the loop over the fields of a payload of an object,
+// which then tests each field.
+func asFieldLoop() []string {
+	return []string{
+		"var numFields = decoder.readMapSize();",
+		"",
+		"while (numFields > 0) {",
+		"\tnumFields--;",
+		"\tconst field = decoder.readString();",
+		"",
+	}
+}
+
+func asToBuffer() []string {
+	return []string{
+		"toBuffer(): ArrayBuffer {",
+		"\tconst sizer = new Sizer();",
+		"\tthis.encode(sizer);",
+		"\tconst buffer = new ArrayBuffer(sizer.length);",
+		"\tconst encoder = new Encoder(buffer);",
+		"\tthis.encode(encoder);",
+		"\treturn buffer;",
+		"}",
+	}
+}
+
+// asUnion returns the class of a union, with a nullable field for each
+// member, and its codec. Payloads and values must hold exactly one variant.
+func (g *generator) asUnion(u *widl.Union) []string {
+	out := doc(u.Description)
+	out = append(out, "export class "+u.Name+" {")
+	for _, m := range u.Members {
+		out = append(out, "\t"+unexport(m.Name)+": "+m.Name+" | null = null;")
+	}
+	out = append(out, "")
+	out = append(out, indent(asDecodeMethods(u.Name), 1)...)
+	out = append(out,
+		"\tdecode(decoder: Decoder): void {")
+	out = append(out, g.asEnterDecode(u.Name)...)
+	out = append(out,
+		"\t\tconst variants = new Array<string>();")
+	out = append(out, indent(asFieldLoop(), 2)...)
+	for i, m := range u.Members {
+		els := "} else "
+		if i == 0 {
+			els = ""
+		}
+		field := "this." + unexport(m.Name)
+		out = append(out,
+			"\t\t\t"+els+"if (field == "+strconv.Quote(m.Name)+") {",
+			"\t\t\t\t"+field+" = "+m.Name+".decodeNullable(decoder);",
+			"\t\t\t\tif ("+field+" !== null) {",
+			"\t\t\t\t\tvariants.push(field);",
+			"\t\t\t\t}")
+	}
+	out = append(out,
+		"\t\t\t} else {",
+		"\t\t\t\tdecoder.skip();",
+		"\t\t\t\tvariants.push(field);",
+		"\t\t\t}",
+		"\t\t}",
+		"\t\tif (variants.length != 1) {",
+		"\t\t\tthrow new Error(unionError("+strconv.Quote(u.Name)+", variants));",
+		"\t\t}")
+	out = append(out, g.asExitDecode(u.Name)...)
+	out = append(out,
+		"\t}",
+		"",
+		"\tencode(encoder: Writer): void {",
+		"\t\tconst variants = this.variants();",
+		"\t\tif (variants.length != 1) {",
+		"\t\t\tthrow new Error(unionError("+strconv.Quote(u.Name)+", variants));",
+		"\t\t}",
+		"\t\tencoder.writeMapSize(1);")
+	for i, m := range u.Members {
+		els := "} else "
+		if i == 0 {
+			els = ""
+		}
+		field := "this." + unexport(m.Name)
+		out = append(out,
+			"\t\t"+els+"if ("+field+" !== null) {",
+			"\t\t\tencoder.writeString("+strconv.Quote(m.Name)+");",
+			"\t\t\t"+field+"!.encode(encoder);")
+	}
+	out = append(out, "\t\t}", "\t}", "")
+	out = append(out, indent(asToBuffer(), 1)...)
+	out = append(out,
+		"",
+		"\t// variants returns the names of the variants that are set.",
+		"\tvariants(): Array<string> {",
+		"\t\tconst variants = new Array<string>();")
+	for _, m := range u.Members {
+		out = append(out,
+			"\t\tif (this."+unexport(m.Name)+" !== null) {",
+			"\t\t\tvariants.push("+strconv.Quote(m.Name)+");",
+			"\t\t}")
+	}
+	return append(out, "\t\treturn variants;", "\t}", "}", "")
+}
+
+// asToBuffer returns the statement `stmt` followed by the encoding of
+// `expr`, a value of type t other than an object, with toBuffer.
+func (g *generator) asToBuffer(t *widl.TypeRef, expr, stmt string) []string {
+	typ := g.asType(t)
+	out := []string{stmt + "toBuffer<" + typ + ">(" + expr + ", (encoder: Writer, value: " + typ + "): void => {"}
+	out = append(out, indent(g.asWrite(t, "value", "value", 0), 1)...)
+	return append(out, "});")
+}
+
+// asEncodesValues reports whether the bindings encode values other than
+// objects: the parameters of unary host calls and the results of handlers.
+func (g *generator) asEncodesValues(ops []operation) bool {
+	for _, op := range ops {
+		if r := op.Returns; r != nil && !op.StreamResult() && !g.isStruct(r) {
+			return true
+		}
+	}
+	for _, role := range append([]*widl.Interface{g.doc.Interface()}, g.roles()...) {
+		for _, op := range operations(role) {
+			if op.Unary && op.StreamParameter() == nil && !op.StreamResult() && !g.isStruct(op.Parameters[0].Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// asParams returns the parameters of a function taking the parameters of
+// `op`, with a stream writer if it streams its result.
+func (g *generator) asParams(op operation) []string {
+	var params []string
+	for _, p := range op.Parameters {
+		params = append(params, p.Name+": "+g.asType(p.Type))
+	}
+	if op.StreamResult() {
+		params = append(params, "stream: "+op.Returns.Elem.Name+"StreamWriter")
+	}
+	return params
+}
+
+// asHandlerSig returns the type of the handler of `op`.
+func (g *generator) asHandlerSig(op operation) string {
+	result := "void"
+	if op.Returns != nil && !op.StreamResult() {
+		result = g.asType(op.Returns)
+	}
+	return "(" + strings.Join(g.asParams(op), ", ") + ") => " + result
+}
+
+// asHost returns the class `host` with a method calling each of `ops` on the
+// host.
+func (g *generator) asHost(host string, comment []string, namespace string, ops []operation) []string {
+	out := append([]string(nil), comment...)
+	out = append(out,
+		"export class "+host+" {",
+		"\tbinding: string;",
+		"",
+		"\tconstructor(binding: string) {",
+		"\t\tthis.binding = binding;",
+		"\t}")
+	for _, op := range ops {
+		if op.StreamParameter() != nil || op.StreamResult() {
+			// Streams are only between the host and the guest exporting them.
+			continue
+		}
+		result := "void"
+		if op.Returns != nil {
+			result = g.asType(op.Returns)
+		}
+		out = append(out, "")
+		out = append(out, indent(doc(op.Description), 1)...)
+		out = append(out, "\t"+op.Name+"("+strings.Join(g.asParams(op), ", ")+"): "+result+" {")
+		payload := "new ArrayBuffer(0)"
+		switch {
+		case op.Unary:
+			p := op.Parameters[0]
+			if g.isStruct(p.Type) {
+				payload = p.Name + ".toBuffer()"
+			} else {
+				out = append(out, indent(g.asToBuffer(p.Type, p.Name, "const payload = "), 2)...)
+				payload = "payload"
+			}
+		case len(op.Parameters) > 0:
+			out = append(out, "\t\tconst inputArgs = new "+op.argsName()+"();")
+			for _, p := range op.Parameters {
+				out = append(out, "\t\tinputArgs."+p.Name+" = "+p.Name+";")
+			}
+			payload = "inputArgs.toBuffer()"
+		}
+		call := "hostCall(this.binding, " + strconv.Quote(namespace) + ", " + strconv.Quote(op.Name) + ", " + payload + ")"
+		if op.Returns == nil {
+			out = append(out, "\t\t"+call+";", "\t}")
+			continue
+		}
+		code, expr := g.asRead(op.Returns, 0)
+		response := "payload"
+		if op.Unary && !g.isStruct(op.Parameters[0].Type) {
+			response = "response"
+		}
+		out = append(out,
+			"\t\tconst "+response+" = "+call+";",
+			"\t\tconst decoder = new Decoder("+response+");")
+		out = append(out, indent(code, 2)...)
+		out = append(out, "\t\treturn "+expr+";", "\t}")
+	}
+	return append(out, "}", "")
+}
+
+func (g *generator) asOps(ops []operation) []string {
+	out := []string{"export class Handlers {"}
+	for i, op := range ops {
+		if i > 0 {
+			out = append(out, "")
+		}
+		out = append(out, indent(doc(op.Description), 1)...)
+		out = append(out,
+			"\tstatic register"+op.goName()+"(handler: "+g.asHandlerSig(op)+"): void {",
+			"\t\t"+op.Name+"Handler = handler;",
+			"\t\tregister("+strconv.Quote(op.Name)+", "+op.Name+"Wrapper);",
+			"\t}")
+	}
+	out = append(out, "}", "")
+	for _, op := range ops {
+		out = append(out, g.asWrapper(op)...)
+	}
+	for _, op := range ops {
+		if op.hasArgs() {
+			out = append(out, g.asClass(op.argsType(), nil)...)
+		}
+	}
+	return out
+}
+
+// asWrapper returns the handler variable of `op` and the function that
+// decodes its payload, invokes the handler and encodes the response.
+func (g *generator) asWrapper(op operation) []string {
+	out := []string{
+		"var " + op.Name + "Handler: " + g.asHandlerSig(op) + ";",
+		"function " + op.Name + "Wrapper(payload: ArrayBuffer): ArrayBuffer {",
+	}
+	var args []string
+	request := ""
+	switch {
+	case op.Unary:
+		p := op.Parameters[0]
+		code, expr := g.asRead(p.Type, 0)
+		out = append(out, "\tconst decoder = new Decoder(payload);")
+		out = append(out, indent(code, 1)...)
+		out = append(out, "\tconst request = "+expr+";")
+		args, request = []string{"request"}, "request"
+	case len(op.args()) > 0:
+		out = append(out,
+			"\tconst decoder = new Decoder(payload);",
+			"\tconst inputArgs = "+op.argsName()+".decode(decoder);")
+		for _, p := range op.Parameters {
+			args = append(args, "inputArgs."+p.Name)
+		}
+		request = "inputArgs"
+	default:
+		for _, p := range op.Parameters {
+			args = append(args, p.Name)
+		}
+	}
+	if g.checked(op) {
+		out = append(out, "\t"+request+".validate();")
+	}
+	p := op.StreamParameter()
+	if p != nil {
+		out = append(out, "\tconst "+p.Name+" = new "+p.Type.Elem.Name+"StreamReader();")
+		for i, arg := range args {
+			if arg == "inputArgs."+p.Name {
+				args[i] = p.Name
+			}
+		}
+	}
+	call := op.Name + "Handler(" + strings.Join(args, ", ") + ")"
+	switch r := op.Returns; {
+	case op.StreamResult():
+		call = op.Name + "Handler(" + strings.Join(append(args, "stream"), ", ") + ")"
+		out = append(out,
+			"\tconst stream = new "+r.Elem.Name+"StreamWriter();",
+			"\t"+call+";",
+			"\tstream.close();",
+			"\treturn new ArrayBuffer(0);")
+	default:
+		if r == nil {
+			out = append(out, "\t"+call+";")
+		} else {
+			out = append(out, "\tconst response = "+call+";")
+		}
+		if p != nil {
+			out = append(out,
+				"\t// Stop the stream of the host if the handler returns before its end.",
+				"\t"+p.Name+".cancel();")
+		}
+		switch {
+		case r == nil:
+			out = append(out, "\treturn new ArrayBuffer(0);")
+		case g.isStruct(r):
+			out = append(out, "\treturn response.toBuffer();")
+		default:
+			out = append(out, indent(g.asToBuffer(r, "response", "return "), 1)...)
+		}
+	}
+	return append(out, "}", "")
+}
+
+func asPatternVar(object, field string) string {
+	return unexport(object) + goName(field) + "Pattern"
+}
+
+// asConcat appends a string literal to an AssemblyScript string expression.
+func asConcat(expr, literal string) string {
+	if strings.HasSuffix(expr, `"`) {
+		return expr[:len(expr)-1] + literal + `"`
+	}
+	return expr + ` + "` + literal + `"`
+}
+
+// asCheckBounds returns the statements adding a violation of `b` by `expr`
+// to errs. Numbers are compared with negated conditions so that NaN, which
+// compares false with everything, violates its bounds.
+func asCheckBounds(path, expr string, b *bounds, length bool) []string {
+	prefix := ""
+	if length {
+		prefix = "length "
+	}
+	var conds, messages []string
+	if b.min != nil {
+		if length {
+			conds = append(conds, expr+" < "+*b.min)
+		} else {
+			conds = append(conds, "!("+expr+" >= "+*b.min+")")
+		}
+		messages = append(messages, prefix+"must be at least "+*b.min)
+	}
+	if b.max != nil {
+		if length {
+			conds = append(conds, expr+" > "+*b.max)
+		} else {
+			conds = append(conds, "!("+expr+" <= "+*b.max+")")
+		}
+		messages = append(messages, prefix+"must be at most "+*b.max)
+	}
+	var out []string
+	for i, cond := range conds {
+		els := ""
+		if i > 0 {
+			els = "} else "
+		}
+		out = append(out, els+"if ("+cond+") {", "\terrs.add("+path+", \""+messages[i]+"\");")
+	}
+	return append(out, "}")
+}
+
+// asCheckValue returns the statements adding the constraint violations of
+// `expr`, a value of type t at `path`, to errs.
+func (g *generator) asCheckValue(object string, f *widl.Field, t *widl.TypeRef, c constraints, expr, path string, depth int) []string {
+	var out []string
+	name := t.Name
+	if g.isAlias(t) {
+		name = g.aliases[name]
+	}
+	if c.length != nil {
+		length := expr + ".length"
+		switch {
+		case t.Kind == widl.Map:
+			length = expr + ".size"
+		case t.Kind == widl.Named && name == "string":
+			length = "runeCount(" + expr + ")"
+		case t.Kind == widl.Named && name == "bytes":
+			length = expr + ".byteLength"
+		}
+		l := f.Name + "Length" + suffix(depth)
+		out = append(out, "const "+l+" = "+length+";")
+		out = append(out, asCheckBounds(path, l, c.length, true)...)
+	}
+	if c.rang != nil {
+		out = append(out, asCheckBounds(path, expr, c.rang, false)...)
+	}
+	if c.pattern != nil {
+		out = append(out,
+			"if (!"+asPatternVar(object, f.Name)+".test("+expr+")) {",
+			"\terrs.add("+path+", "+quote("must match "+*c.pattern)+");",
+			"}")
+	}
+	if t.Kind == widl.List {
+		if needs, _ := g.needsCheck(t.Elem, constraints{}); needs {
+			i := "i" + suffix(depth)
+			item := expr + "[" + i + "]"
+			elemPath := asConcat(asConcat(path, "[")+" + "+i+".toString()", "]")
+			var code []string
+			if t.Elem.Optional {
+				v := "v" + suffix(depth)
+				code = g.asCheckValue(object, f, nonOptional(t.Elem), constraints{}, v, elemPath, depth+1)
+				code = append(append([]string{"if (" + item + " !== null) {", "\tconst " + v + " = " + item + "!;"}, indent(code, 1)...), "}")
+			} else {
+				code = g.asCheckValue(object, f, t.Elem, constraints{}, item, elemPath, depth+1)
+			}
+			out = append(out, "for (let "+i+" = 0; "+i+" < "+expr+".length; "+i+"++) {")
+			out = append(out, indent(code, 1)...)
+			out = append(out, "}")
+		}
+	} else if g.isObject(t) && g.constrained[t.Name] {
+		out = append(out, expr+".check("+asConcat(path, ".")+", errs);")
+	}
+	return out
+}
+
+func (g *generator) asCheckField(object string, f *widl.Field) []string {
+	path := `path + "` + f.Name + `"`
+	field := "this." + f.Name
+	if !f.Type.Optional {
+		return g.asCheckValue(object, f, f.Type, fieldConstraints(f), field, path, 0)
+	}
+	code := g.asCheckValue(object, f, nonOptional(f.Type), fieldConstraints(f), f.Name, path, 0)
+	if len(code) == 0 {
+		return nil
+	}
+	value := field + "!"
+	if g.isValue(f.Type) {
+		value += ".value"
+	}
+	return append(append([]string{"if (" + field + " !== null) {", "\tconst " + f.Name + " = " + value + ";"}, indent(code, 1)...), "}")
+}
+
+// asCheckMethods returns the validate and check methods of an object with
+// constraints, after the patterns they match.
+func (g *generator) asCheckMethods(t *widl.Type) []string {
+	if !g.constrained[t.Name] {
+		return nil
+	}
+	out := []string{
+		"",
+		"// validate aborts with a ValidationError listing every field that",
+		"// violates a constraint of the schema.",
+		"validate(): void {",
+		"\tconst errs = new ValidationError();",
+		"\tthis.check(\"\", errs);",
+		"\tif (errs.fields.length > 0) {",
+		"\t\tthrow new Error(errs.toString());",
+		"\t}",
+		"}",
+		"",
+		"check(path: string, errs: ValidationError): void {",
+	}
+	for _, f := range t.Fields {
+		out = append(out, indent(g.asCheckField(t.Name, f), 1)...)
+	}
+	return append(out, "}")
+}
+
+// asPatterns returns the compiled @pattern constraints of the objects.
+func (g *generator) asPatterns() []string {
+	var out []string
+	for _, t := range g.objects() {
+		if !g.constrained[t.Name] {
+			continue
+		}
+		for _, f := range t.Fields {
+			if c := fieldConstraints(f); c.pattern != nil {
+				out = append(out, "const "+asPatternVar(t.Name, f.Name)+" = new RegExp("+quote(*c.pattern)+");")
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return append(out, "")
+}
@@ -0,0 +1,217 @@
+package codegen
+
+import (
+	"strings"
+
+	"github.com/wapc/language-tests/pkg/widl"
+)
+
+// The TinyGo code decodes and encodes values with tinygo-msgpack.
+
+// readExpr returns the expression (value, err) decoding a value of a
+// non-optional type that is not a collection.
+func (g *generator) readExpr(t *widl.TypeRef) string {
+	if p, ok := prims[t.Name]; ok {
+		return "decoder.Read" + p[1] + "()"
+	}
+	if t.Name == "datetime" {
+		return "ext.ReadTime(" + g.decoder + ")"
+	}
+	return g.codecFunc("Decode", t.Name) + "(" + g.decoder + ")"
+}
+
+// decodeLocal returns decodeValue for code where `decoder` is a
+// msgpack.Decoder rather than a pointer to one.
+func (g *generator) decodeLocal(t *widl.TypeRef, target string) []string {
+	g.decoder = "&decoder"
+	defer func() { g.decoder = "decoder" }()
+	return g.decodeValue(t, target, 0)
+}
+
+// writeStmt returns the statement encoding `expr`, a value of a type that is
+// not a collection.
+func (g *generator) writeStmt(t *widl.TypeRef, expr string) []string {
+	if p, ok := prims[t.Name]; ok {
+		return []string{"encoder.Write" + p[1] + "(" + expr + ")"}
+	}
+	if t.Name == "datetime" {
+		return []string{"ext.WriteTime(encoder, " + expr + ")"}
+	}
+	if g.isEnum(t) || g.isAlias(t) {
+		return []string{g.codecFunc("Encode", t.Name) + "(encoder, " + expr + ")"}
+	}
+	return []string{expr + ".Encode(encoder)"}
+}
+
+// decodeValue returns the statements decoding a value of type t into
+// `target`. They end with err set, and return errors of the items of
+// collections.
+func (g *generator) decodeValue(t *widl.TypeRef, target string, depth int) []string {
+	if t.Optional && !g.isNilable(t) {
+		return []string{
+			"var isNil bool",
+			"isNil, err = decoder.IsNextNil()",
+			"if err == nil {",
+			"\tif isNil {",
+			"\t\t" + target + " = nil",
+			"\t} else {",
+			"\t\tvar nonNil " + g.goType(nonOptional(t)),
+			"\t\tnonNil, err = " + g.readExpr(t),
+			"\t\t" + target + " = &nonNil",
+			"\t}",
+			"}",
+		}
+	}
+	// Required bytes may be nil too, which decodes as empty.
+	if (t.Optional || g.isNilable(t)) && t.Kind == widl.Named {
+		return []string{
+			"var isNil bool",
+			"isNil, err = decoder.IsNextNil()",
+			"if err == nil {",
+			"\tif isNil {",
+			"\t\t" + target + " = nil",
+			"\t} else {",
+			"\t\tvar nonNil " + g.goType(nonOptional(t)),
+			"\t\tnonNil, err = " + g.readExpr(t),
+			"\t\t" + target + " = nonNil",
+			"\t}",
+			"}",
+		}
+	}
+	var out []string
+	switch t.Kind {
+	case widl.Named:
+		return []string{target + ", err = " + g.readExpr(t)}
+	case widl.List, widl.Map:
+		if t.Optional {
+			out = append(out,
+				"isNil, err := decoder.IsNextNil()",
+				"if err != nil {",
+				"\treturn err",
+				"}",
+				"if isNil {",
+				"\t"+target+" = nil",
+				"\tbreak",
+				"}")
+		}
+	}
+	s := suffix(depth)
+	if t.Kind == widl.List {
+		size, item := "listSize"+s, "nonNilItem"+s
+		out = append(out,
+			size+", err := decoder.ReadArraySize()",
+			"if err != nil {",
+			"\treturn err",
+			"}",
+			target+" = make("+g.goType(nonOptional(t))+", 0, "+size+")",
+			"for "+size+" > 0 {",
+			"\t"+size+"--",
+			"\tvar "+item+" "+g.goType(t.Elem))
+		out = append(out, indent(g.decodeValue(t.Elem, item, depth+1), 1)...)
+		return append(out,
+			"\tif err != nil {",
+			"\t\treturn err",
+			"\t}",
+			"\t"+target+" = append("+target+", "+item+")",
+			"}")
+	}
+	size, key, value := "mapSize"+s, "key"+s, "value"+s
+	out = append(out,
+		size+", err := decoder.ReadMapSize()",
+		"if err != nil {",
+		"\treturn err",
+		"}",
+		target+" = make("+g.goType(nonOptional(t))+", "+size+")",
+		"for "+size+" > 0 {",
+		"\t"+size+"--",
+		"\t"+key+", err := "+g.readExpr(t.Key),
+		"\tif err != nil {",
+		"\t\treturn err",
+		"\t}")
+	if t.Elem.Kind == widl.Named && !t.Elem.Optional {
+		out = append(out, "\t"+value+", err := "+g.readExpr(t.Elem))
+	} else {
+		out = append(out, "\tvar "+value+" "+g.goType(t.Elem))
+		out = append(out, indent(g.decodeValue(t.Elem, value, depth+1), 1)...)
+	}
+	return append(out,
+		"\tif err != nil {",
+		"\t\treturn err",
+		"\t}",
+		"\t"+target+"["+key+"] = "+value,
+		"}")
+}
+
+// encodeValue returns the statements encoding `expr`, a value of type t.
+// Unless `checked`, maps are checked for nil before ranging over them.
+func (g *generator) encodeValue(t *widl.TypeRef, expr string, depth int, checked bool) []string {
+	if t.Optional && !g.isNilable(t) {
+		out := []string{"if " + expr + " == nil {", "\tencoder.WriteNil()", "} else {"}
+		if g.isObject(t) {
+			out = append(out, indent(g.writeStmt(t, expr), 1)...)
+		} else {
+			out = append(out, indent(g.writeStmt(t, "*"+expr), 1)...)
+		}
+		return append(out, "}")
+	}
+	if t.Optional {
+		out := []string{"if " + expr + " == nil {", "\tencoder.WriteNil()", "} else {"}
+		out = append(out, indent(g.encodeValue(nonOptional(t), expr, depth, true), 1)...)
+		return append(out, "}")
+	}
+	s := suffix(depth)
+	switch t.Kind {
+	case widl.List:
+		out := []string{
+			"encoder.WriteArraySize(uint32(len(" + expr + ")))",
+			"for _, v" + s + " := range " + expr + " {",
+		}
+		out = append(out, indent(g.encodeValue(t.Elem, "v"+s, depth+1, false), 1)...)
+		return append(out, "}")
+	case widl.Map:
+		out := []string{"encoder.WriteMapSize(uint32(len(" + expr + ")))"}
+		body := g.writeStmt(t.Key, "k"+s)
+		body = append(body, g.encodeValue(t.Elem, "v"+s, depth+1, false)...)
+		if checked {
+			out = append(out, "for k"+s+", v"+s+" := range "+expr+" {")
+			out = append(out, indent(body, 1)...)
+			return append(out, "}")
+		}
+		out = append(out,
+			"if "+expr+" != nil { // TinyGo bug: ranging over nil maps panics.",
+			"\tfor k"+s+", v"+s+" := range "+expr+" {")
+		out = append(out, indent(body, 2)...)
+		return append(out, "\t}", "}")
+	}
+	return g.writeStmt(t, expr)
+}
+
+// sizeAndEncode returns the statements encoding `expr` into a new buffer
+// named `buffer`, sized beforehand.
+func (g *generator) sizeAndEncode(t *widl.TypeRef, expr, buffer string) []string {
+	code := g.encodeValue(t, expr, 0, false)
+	sizer := strings.NewReplacer("encoder.", "sizer.", "(encoder", "(&sizer")
+	encoder := strings.NewReplacer("(encoder", "(&encoder")
+	out := []string{"var sizer msgpack.Sizer"}
+	for _, line := range code {
+		out = append(out, sizer.Replace(line))
+	}
+	out = append(out, "", buffer+" := make([]byte, sizer.Len())", "encoder := msgpack.NewEncoder("+buffer+")")
+	for _, line := range code {
+		out = append(out, encoder.Replace(line))
+	}
+	return out
+}
+
+// returning makes the `return err` statements of decoding code also return
+// `zero`.
+func returning(code []string, zero string) []string {
+	out := make([]string, len(code))
+	for i, line := range code {
+		if strings.TrimSpace(line) == "return err" {
+			line = strings.Replace(line, "return err", "return "+zero+", err", 1)
+		}
+		out[i] = line
+	}
+	return out
+}
@@ -0,0 +1,497 @@
+// Package codegen generates the bindings of a WIDL schema: the Module of the
+// Go host and the Handlers of the TinyGo, AssemblyScript and Rust guests. It
+// covers the syntax that pkg/widl parses, which goes beyond what
+// widl-codegen supports: enums, unions, aliases, roles, default values,
+// constraints and streams.
+//
+// cmd/codegen runs it for the schemas of this repository.
+package codegen
+
+import (
+	"embed"
+	"errors"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/wapc/language-tests/pkg/widl"
+)
+
+// generatedComment marks the generated files, so tools and reviewers know
+// not to edit them.
+const generatedComment = "// Code generated by cmd/codegen. DO NOT EDIT."
+
+// Config configures the generated code.
+type Config struct {
+	// Package is the name of the generated package.
+	Package string
+	// Types maps aliases of the schema to Go types, which then replace the
+	// type the generator declares for them. The package of the Go type must
+	// declare the functions encoding and decoding it.
+	Types map[string]Type
+}
+
+// Type is a Go type such as scalar.UUID, with the import path of its package.
+type Type struct {
+	Import string
+	Name   string
+}
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+var templates = template.Must(template.ParseFS(templateFiles, "templates/*.tmpl"))
+
+// prims maps the built-in types of WIDL to their Go type and the suffix of
+// the msgpack methods reading and writing them.
+var prims = map[string][2]string{
+	"bool":   {"bool", "Bool"},
+	"u8":     {"uint8", "Uint8"},
+	"u16":    {"uint16", "Uint16"},
+	"u32":    {"uint32", "Uint32"},
+	"u64":    {"uint64", "Uint64"},
+	"i8":     {"int8", "Int8"},
+	"i16":    {"int16", "Int16"},
+	"i32":    {"int32", "Int32"},
+	"i64":    {"int64", "Int64"},
+	"f32":    {"float32", "Float32"},
+	"f64":    {"float64", "Float64"},
+	"string": {"string", "String"},
+	"bytes":  {"[]byte", "ByteArray"},
+}
+
+// generator holds the declarations of the schema that the generated code
+// depends on.
+type generator struct {
+	doc    *widl.Document
+	config Config
+	host   bool
+	enums  map[string]bool
+	// aliases maps aliases to the built-in type they stand for.
+	aliases map[string]string
+	// constrained holds the objects with constraints, directly or in the
+	// objects they hold.
+	constrained map[string]bool
+	// recursive holds the objects that can hold themselves, directly,
+	// through other objects or in collections. Their decoders limit how
+	// deeply they nest.
+	recursive map[string]bool
+	uses      struct{ regexp, strconv, utf8, orEmpty, nestedOrEmpty bool }
+	// decoder is the expression passing the decoder to functions.
+	decoder string
+}
+
+func newGenerator(doc *widl.Document, config Config, host bool) (*generator, error) {
+	if doc.Namespace == nil || doc.Interface() == nil {
+		return nil, errors.New("the schema has no namespace or no interface")
+	}
+	g := &generator{
+		doc:     doc,
+		config:  config,
+		host:    host,
+		enums:   map[string]bool{},
+		aliases: map[string]string{},
+		decoder: "decoder",
+	}
+	for _, e := range doc.Enums {
+		g.enums[e.Name] = true
+	}
+	for _, a := range doc.Aliases {
+		if a.Type.Kind != widl.Named || !isPrim(a.Type) || a.Type.Optional {
+			return nil, errors.New("alias " + a.Name + " must stand for a built-in type")
+		}
+		g.aliases[a.Name] = a.Type.Name
+	}
+	for _, op := range doc.Interface().Operations {
+		for _, t := range []*widl.TypeRef{op.Returns, streamOf(op)} {
+			if t != nil && t.Kind == widl.Stream && (t.Elem.Kind != widl.Named || t.Elem.Optional || !g.isObject(t.Elem)) {
+				return nil, errors.New("operation " + op.Name + " must stream a type, not " + t.Elem.String())
+			}
+		}
+	}
+	for _, i := range doc.Interfaces {
+		if !i.Role {
+			continue
+		}
+		for _, op := range i.Operations {
+			if op.StreamParameter() != nil || op.StreamResult() {
+				return nil, errors.New("role " + i.Name + " cannot stream")
+			}
+		}
+	}
+	if err := g.findConstrained(); err != nil {
+		return nil, err
+	}
+	g.findRecursive()
+	return g, nil
+}
+
+func streamOf(op *widl.Operation) *widl.TypeRef {
+	if p := op.StreamParameter(); p != nil {
+		return p.Type
+	}
+	return nil
+}
+
+// source formats the generated code.
+func source(code []string) ([]byte, error) {
+	return format.Source([]byte(strings.Join(code, "\n")))
+}
+
+func execute(name string, data interface{}) string {
+	var b strings.Builder
+	if err := templates.ExecuteTemplate(&b, name+".tmpl", data); err != nil {
+		panic(err)
+	}
+	return b.String()
+}
+
+func isPrim(t *widl.TypeRef) bool {
+	_, ok := prims[t.Name]
+	return t.Kind == widl.Named && ok
+}
+
+func (g *generator) isEnum(t *widl.TypeRef) bool {
+	return t.Kind == widl.Named && g.enums[t.Name]
+}
+
+func (g *generator) isAlias(t *widl.TypeRef) bool {
+	_, ok := g.aliases[t.Name]
+	return t.Kind == widl.Named && ok
+}
+
+func (g *generator) isUnion(name string) bool {
+	for _, u := range g.doc.Unions {
+		if u.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isObject reports whether t is a type or a union, which are encoded as maps
+// and generated as structs.
+func (g *generator) isObject(t *widl.TypeRef) bool {
+	return t.Kind == widl.Named && !isPrim(t) && !g.isEnum(t) && !g.isAlias(t) && t.Name != "datetime"
+}
+
+// heldObject returns the name of the object that t holds, directly or in a
+// collection, or "" if it holds none.
+func (g *generator) heldObject(t *widl.TypeRef) string {
+	if t.Kind == widl.List || t.Kind == widl.Map {
+		return g.heldObject(t.Elem)
+	}
+	if g.isObject(t) {
+		return t.Name
+	}
+	return ""
+}
+
+// findRecursive sets g.recursive.
+func (g *generator) findRecursive() {
+	holds := map[string][]string{}
+	for _, t := range g.doc.Types {
+		for _, f := range t.Fields {
+			if name := g.heldObject(f.Type); name != "" {
+				holds[t.Name] = append(holds[t.Name], name)
+			}
+		}
+	}
+	for _, u := range g.doc.Unions {
+		for _, m := range u.Members {
+			holds[u.Name] = append(holds[u.Name], m.Name)
+		}
+	}
+	g.recursive = map[string]bool{}
+	for name := range holds {
+		seen := map[string]bool{}
+		var reaches func(from string) bool
+		reaches = func(from string) bool {
+			for _, held := range holds[from] {
+				if held == name {
+					return true
+				}
+				if !seen[held] {
+					seen[held] = true
+					if reaches(held) {
+						return true
+					}
+				}
+			}
+			return false
+		}
+		if reaches(name) {
+			g.recursive[name] = true
+		}
+	}
+}
+
+// isNilable reports whether nil of the Go type of t stands for a missing
+// optional value, so that optional values are not pointers.
+func (g *generator) isNilable(t *widl.TypeRef) bool {
+	switch {
+	case t.Kind == widl.List || t.Kind == widl.Map:
+		return true
+	case t.Kind == widl.Named && t.Name == "bytes":
+		return true
+	}
+	_, mapped := g.config.Types[t.Name]
+	return g.isAlias(t) && g.aliases[t.Name] == "bytes" && !mapped
+}
+
+// required reports whether a field is neither optional nor has a default.
+func required(f *widl.Field) bool {
+	return !f.Type.Optional && f.Default == nil
+}
+
+// nonOptional returns t without its `?`.
+func nonOptional(t *widl.TypeRef) *widl.TypeRef {
+	c := *t
+	c.Optional = false
+	return &c
+}
+
+func (g *generator) goType(t *widl.TypeRef) string {
+	switch t.Kind {
+	case widl.List:
+		return "[]" + g.goType(t.Elem)
+	case widl.Map:
+		return "map[" + g.goType(t.Key) + "]" + g.goType(t.Elem)
+	case widl.Stream:
+		if g.host {
+			return "Iterator[" + t.Elem.Name + "]"
+		}
+		return "*" + t.Elem.Name + "StreamReader"
+	}
+	if p, ok := prims[t.Name]; ok {
+		if t.Optional && t.Name != "bytes" {
+			return "*" + p[0]
+		}
+		return p[0]
+	}
+	if g.isEnum(t) || g.isAlias(t) {
+		name := t.Name
+		if m, ok := g.config.Types[t.Name]; ok {
+			name = m.Name
+		}
+		if t.Optional && !g.isNilable(t) {
+			return "*" + name
+		}
+		return name
+	}
+	if t.Name == "datetime" {
+		if t.Optional {
+			return "*time.Time"
+		}
+		return "time.Time"
+	}
+	if t.Optional {
+		return "*" + t.Name
+	}
+	return t.Name
+}
+
+// codecFunc returns the name of the function that decodes or encodes (the
+// verb) a value of a named type, in the package of the types mapped with
+// Config.Types.
+func (g *generator) codecFunc(verb, name string) string {
+	m, ok := g.config.Types[name]
+	if !ok {
+		return verb + name
+	}
+	parts := strings.SplitN(m.Name, ".", 2)
+	return parts[0] + "." + verb + parts[1]
+}
+
+// mappedImports returns the import paths of Config.Types.
+func (g *generator) mappedImports() []string {
+	var paths []string
+	seen := map[string]bool{}
+	for _, m := range g.config.Types {
+		if !seen[m.Import] {
+			seen[m.Import] = true
+			paths = append(paths, m.Import)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// usesTime reports whether the schema refers to datetime.
+func (g *generator) usesTime() bool {
+	var refers func(t *widl.TypeRef) bool
+	refers = func(t *widl.TypeRef) bool {
+		switch {
+		case t == nil:
+			return false
+		case t.Kind == widl.Named:
+			return t.Name == "datetime"
+		}
+		return refers(t.Key) || refers(t.Elem)
+	}
+	for _, t := range g.doc.Types {
+		for _, f := range t.Fields {
+			if refers(f.Type) {
+				return true
+			}
+		}
+	}
+	for _, i := range g.doc.Interfaces {
+		for _, op := range i.Operations {
+			if refers(op.Returns) {
+				return true
+			}
+			for _, p := range op.Parameters {
+				if refers(p.Type) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// goName exports a name of the schema.
+func goName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func unexport(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// defaultValue returns the Go expression of the default value of a field.
+func defaultValue(f *widl.Field) string {
+	switch {
+	case f.Type.Kind == widl.Named && f.Default.Kind == widl.Identifier:
+		return f.Type.Name + goName(f.Default.Text)
+	case f.Default.Kind == widl.String:
+		return quote(f.Default.Text)
+	}
+	return f.Default.Text
+}
+
+// quote returns a Go string literal of s.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func indent(code []string, n int) []string {
+	out := make([]string, len(code))
+	for i, line := range code {
+		if line != "" {
+			line = strings.Repeat("\t", n) + line
+		}
+		out[i] = line
+	}
+	return out
+}
+
+// suffix distinguishes the variables of nested collections.
+func suffix(depth int) string {
+	if depth == 0 {
+		return ""
+	}
+	return strconv.Itoa(depth)
+}
+
+// doc returns the comment of a description.
+func doc(description string) []string {
+	if description == "" {
+		return nil
+	}
+	return []string{"// " + description}
+}
+
+// operation is an operation of the interface or of a role. The structs of the
+// arguments of roles are prefixed with the name of the role.
+type operation struct {
+	*widl.Operation
+	prefix string
+}
+
+func operations(i *widl.Interface) []operation {
+	var ops []operation
+	for _, op := range i.Operations {
+		o := operation{Operation: op}
+		if i.Role {
+			o.prefix = i.Name
+		}
+		ops = append(ops, o)
+	}
+	return ops
+}
+
+func (o operation) goName() string {
+	return goName(o.Name)
+}
+
+// argsName is the name of the struct of the arguments of the operation.
+func (o operation) argsName() string {
+	return o.prefix + o.goName() + "Args"
+}
+
+// args returns the parameters sent in the payload, all but a stream.
+func (o operation) args() []*widl.Field {
+	var args []*widl.Field
+	for _, p := range o.Parameters {
+		if p.Type.Kind != widl.Stream {
+			args = append(args, p)
+		}
+	}
+	return args
+}
+
+// hasArgs reports whether the operation has a struct of arguments.
+func (o operation) hasArgs() bool {
+	return !o.Unary && len(o.args()) > 0
+}
+
+// argsType returns the type of the arguments struct.
+func (o operation) argsType() *widl.Type {
+	return &widl.Type{Name: o.argsName(), Fields: o.args()}
+}
+
+// streamTypes returns the names of the types streamed by `ops`, in the
+// order of the schema.
+func streamTypes(ops []operation) []string {
+	var names []string
+	for _, op := range ops {
+		for _, t := range []*widl.TypeRef{streamOf(op.Operation), op.Returns} {
+			if t == nil || t.Kind != widl.Stream {
+				continue
+			}
+			found := false
+			for _, n := range names {
+				found = found || n == t.Elem.Name
+			}
+			if !found {
+				names = append(names, t.Elem.Name)
+			}
+		}
+	}
+	return names
+}
+
+// roles returns the roles of the schema.
+func (g *generator) roles() []*widl.Interface {
+	var roles []*widl.Interface
+	for _, i := range g.doc.Interfaces {
+		if i.Role {
+			roles = append(roles, i)
+		}
+	}
+	return roles
+}
+
+// unionType returns the struct of a union, with an optional field for each
+// variant named after its type.
+func unionType(u *widl.Union) *widl.Type {
+	t := &widl.Type{Name: u.Name, Description: u.Description}
+	for _, m := range u.Members {
+		t.Fields = append(t.Fields, &widl.Field{Name: m.Name, Type: &widl.TypeRef{Kind: widl.Named, Name: m.Name, Optional: true}})
+	}
+	return t
+}
@@ -0,0 +1,256 @@
+package codegen_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wapc/language-tests/pkg/codegen"
+	"github.com/wapc/language-tests/pkg/widl"
+)
+
+func parse(t *testing.T, src string) *widl.Document {
+	doc, err := widl.Parse([]byte(src))
+	require.NoError(t, err)
+	return doc
+}
+
+// typeCheck fails if `code` is not a valid Go package.
+func typeCheck(t *testing.T, code []byte) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "gen.go", code, 0)
+	require.NoError(t, err)
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = config.Check("gen", fset, []*ast.File{f}, nil)
+	require.NoError(t, err)
+}
+
+// schema uses every feature of the generators, in the positions that the
+// schemas of the repository do not.
+const schema = `namespace "gen"
+
+interface {
+  echo{thing: Thing}: Thing
+  list(ids: [ID], after: datetime?): [Thing]
+  kinds{kinds: [Kind]}: {Kind:Thing?}
+  item{item: Item?}: Item?
+  digest{digest: Digest}: Digest
+  time(): datetime
+  nothing(): void
+  watch(prefix: string): stream Thing
+  upload(items: stream Item, label: string): u64
+}
+
+role Store @namespace("gen.store") {
+  get{id: ID}: Thing?
+  all(): [Thing]
+  put(id: ID, thing: Thing, when: datetime): void
+}
+
+type Thing {
+  id: ID
+  name: string = "unnamed" @length(max: 8) @pattern("^[a-z]+$")
+  kind: Kind = small
+  size: f64? @range(min: 0, max: 1)
+  children: [Thing]
+  optionalChildren: [Thing?]
+  byKind: {Kind:[Thing]}?
+  digest: Digest?
+}
+
+union Item = Thing | Other
+
+type Other {
+  values: [[string]]
+}
+
+alias ID = string
+
+alias Digest = bytes
+
+enum Kind {
+  small = 0
+  large = 1
+}
+`
+
+func TestGenerate(t *testing.T) {
+	doc := parse(t, schema)
+	for name, generate := range map[string]func(*widl.Document, codegen.Config) ([]byte, error){
+		"host":   codegen.Host,
+		"tinygo": codegen.TinyGo,
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, err := generate(doc, codegen.Config{Package: "gen"})
+			require.NoError(t, err)
+			typeCheck(t, code)
+			src := string(code)
+			assert.Contains(t, src, "type ID string\n")
+			assert.Contains(t, src, "KindSmall Kind = 0\n")
+			assert.Contains(t, src, `o.Children[i].check(path+"children["+strconv.Itoa(i)+"].", errs)`)
+		})
+	}
+}
+
+// TestDecodeErrors checks that the TinyGo wrappers return decoding errors
+// whether or not RequireFields is set.
+func TestDecodeErrors(t *testing.T) {
+	code, err := codegen.TinyGo(parse(t, schema), codegen.Config{Package: "gen"})
+	require.NoError(t, err)
+	src := string(code)
+	assert.Contains(t, src, "\tif err := request.Decode(&decoder); err != nil {\n\t\treturn nil, err\n")
+	assert.Contains(t, src, "\tif err := inputArgs.Decode(&decoder); err != nil {\n\t\treturn nil, err\n")
+	assert.NotContains(t, src, "err != nil && RequireFields")
+	// Thing holds itself, Other does not.
+	assert.Contains(t, src, "func (o *Thing) Decode(decoder *msgpack.Decoder) error {\n\tif decodeDepth >= MaxDepth {\n")
+	assert.NotContains(t, src, `&DepthError{"Other"}`)
+}
+
+// TestAsync checks that the host Module has Async and Batch methods for the
+// operations that do not stream, and Batch methods only for those with a
+// request.
+func TestAsync(t *testing.T) {
+	code, err := codegen.Host(parse(t, schema), codegen.Config{Package: "gen"})
+	require.NoError(t, err)
+	src := string(code)
+	assert.Contains(t, src, "func (m *Module) EchoAsync(ctx context.Context, thing Thing) *Future[Thing] {")
+	assert.Contains(t, src, "func (m *Module) EchoBatch(ctx context.Context, reqs []Thing, settings BatchSettings) []Result[Thing] {")
+	assert.Contains(t, src, "func (m *Module) ListBatch(ctx context.Context, reqs []ListArgs, settings BatchSettings) []Result[[]Thing] {")
+	assert.Contains(t, src, "func (m *Module) NothingAsync(ctx context.Context) *Future[Void] {")
+	assert.NotContains(t, src, "NothingBatch")
+	assert.NotContains(t, src, "WatchAsync")
+	assert.NotContains(t, src, "UploadBatch")
+}
+
+func TestTypes(t *testing.T) {
+	doc := parse(t, schema)
+	for name, test := range map[string]struct {
+		generate func(*widl.Document, codegen.Config) ([]byte, error)
+		path     string
+		uses     string
+	}{
+		"host":   {codegen.Host, "github.com/wapc/language-tests/pkg/scalar", "Id               scalar.UUID"},
+		"tinygo": {codegen.TinyGo, "github.com/wapc/language-tests/tinygo/scalar", "scalar.DecodeUUID(decoder)"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, err := test.generate(doc, codegen.Config{
+				Package: "gen",
+				Types:   map[string]codegen.Type{"ID": {Import: test.path, Name: "scalar.UUID"}},
+			})
+			require.NoError(t, err)
+			typeCheck(t, code)
+			src := string(code)
+			assert.Contains(t, src, `"`+test.path+`"`)
+			assert.NotContains(t, src, "type ID ")
+			assert.Contains(t, src, test.uses)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		schema string
+		err    string
+	}{
+		{`interface { f(): void }`, "the schema has no namespace or no interface"},
+		{`namespace "n"
+		  type T { v: string }`, "the schema has no namespace or no interface"},
+		{`namespace "n"
+		  interface { f(): void }
+		  alias A = [string]`, "alias A must stand for a built-in type"},
+		{`namespace "n"
+		  interface { f(): stream string }`, "operation f must stream a type, not string"},
+		{`namespace "n"
+		  interface { f(): void }
+		  role R @namespace("r") { g(): stream T }
+		  type T { v: string }`, "role R cannot stream"},
+		{`namespace "n"
+		  interface { f{t: T}: void }
+		  type T { v: {string:U} }
+		  type U { s: string @length(max: 1) }`, "constraints in map values are not supported"},
+		{`namespace "n"
+		  interface { f{t: T}: void }
+		  type T { s: string @pattern("^\\d+$") }`, `the pattern of T.s uses the escape \d, which is not portable`},
+		{`namespace "n"
+		  interface { f{t: T}: void }
+		  type T { s: string @pattern("(?i)a") }`, "the pattern of T.s uses flags or named groups, which are not portable"},
+		{`namespace "n"
+		  interface { f{t: T}: void }
+		  type T { s: string @pattern("[a[b]") }`, "the pattern of T.s uses [ in a class, which must be escaped"},
+		{`namespace "n"
+		  interface { f{t: T}: void }
+		  type T { s: string @pattern("[a-z--c]") }`, "the pattern of T.s uses -- in a class, which is not portable"},
+		{`namespace "n"
+		  interface { f{t: T}: void }
+		  type T { s: string @pattern("a{,2}") }`, "the pattern of T.s uses { outside of a quantifier, which must be escaped"},
+		{`namespace "n"
+		  interface { f{t: T}: void }
+		  type T { s: string @pattern("(a") }`, "the pattern of T.s is invalid: error parsing regexp: missing closing ): `(a`"},
+	}
+	for _, tt := range tests {
+		_, err := codegen.Host(parse(t, tt.schema), codegen.Config{Package: "n"})
+		assert.EqualError(t, err, tt.err, tt.schema)
+	}
+}
+
+// TestGuests checks the Rust and AssemblyScript bindings, which cannot be
+// compiled here, by their key lines.
+func TestGuests(t *testing.T) {
+	doc := parse(t, schema)
+	for name, test := range map[string]struct {
+		generate func(*widl.Document, codegen.Config) ([]byte, error)
+		contains []string
+	}{
+		"rust": {codegen.Rust, []string{
+			"pub type ID = String;\n",
+			"    pub const SMALL: Kind = Kind(0);\n",
+			"pub enum Item {\n    Thing(Thing),\n    Other(Other),\n}\n",
+			`            v.check(&format!("{}children[{}].", path, i), errs);`,
+			"    pub fn register_watch(f: fn(String, &mut ThingStreamWriter) -> HandlerResult<()>) {\n",
+			"    pub fn get(&self, id: ID) -> HandlerResult<Option<Thing>> {\n",
+			"    #[serde(rename = \"children\", deserialize_with = \"nested_or_empty\", default = \"Thing::missing_children\")]\n",
+			"        missing_field(\"Thing\", \"children\")\n",
+		}},
+		"assemblyscript": {codegen.AssemblyScript, []string{
+			"export type ID = string;\n",
+			"export enum Kind {\n  Small = 0,\n  Large = 1,\n}\n",
+			"  thing: Thing | null = null;\n  other: Other | null = null;\n",
+			`      this.children[i].check(path + "children[" + i.toString() + "].", errs);`,
+			"  static registerWatch(handler: (prefix: string, stream: ThingStreamWriter) => void): void {\n",
+			"  get(id: ID): Thing | null {\n",
+			"  decode(decoder: Decoder): void {\n    enterDecode(\"Thing\");\n",
+			"    if (requireFields) {\n",
+			"        throw new Error(\"missing required field Thing.children\");\n",
+		}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, err := test.generate(doc, codegen.Config{})
+			require.NoError(t, err)
+			src := string(code)
+			for _, s := range test.contains {
+				assert.Contains(t, src, s)
+			}
+		})
+	}
+}
+
+func TestGuestErrors(t *testing.T) {
+	keys := parse(t, `namespace "n"
+	  interface { f{m: {T:string}}: void }
+	  type T { v: string }`)
+	types := codegen.Config{Types: map[string]codegen.Type{"ID": {Name: "scalar.UUID"}}}
+	_, err := codegen.Rust(keys, codegen.Config{})
+	assert.EqualError(t, err, "map keys of type T are not supported in Rust")
+	_, err = codegen.Rust(parse(t, schema), types)
+	assert.EqualError(t, err, "the Rust bindings cannot map aliases to other types")
+	_, err = codegen.AssemblyScript(keys, codegen.Config{})
+	assert.EqualError(t, err, "map keys of type T are not supported in AssemblyScript")
+	_, err = codegen.AssemblyScript(parse(t, schema), types)
+	assert.EqualError(t, err, "the AssemblyScript bindings cannot map aliases to other types")
+}
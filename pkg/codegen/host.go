@@ -0,0 +1,430 @@
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/wapc/language-tests/pkg/widl"
+)
+
+// Host generates the bindings of the Go host: the Module invoking the
+// operations of the interface, the interfaces of the roles with the Router
+// serving them, and the types of the schema with msgpack struct tags.
+func Host(doc *widl.Document, config Config) ([]byte, error) {
+	g, err := newGenerator(doc, config, true)
+	if err != nil {
+		return nil, err
+	}
+	return source(g.hostFile())
+}
+
+const hostModule = `type Module struct {
+	instance      Invoker
+	requireFields bool
+	callOptions   []CallOption
+}
+
+func New(instance Invoker, options ...Option) *Module {
+	m := &Module{
+		instance: instance,
+	}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+`
+
+func (g *generator) hostFile() []string {
+	ops := operations(g.doc.Interface())
+	roles := g.roles()
+	streams := len(streamTypes(ops)) > 0
+	enums := len(g.doc.Enums) > 0
+	imports := []string{"context"}
+	if streams {
+		imports = append(imports, "errors")
+	}
+	if enums {
+		imports = append(imports, "math")
+	}
+	imports = append(imports, "reflect")
+	if g.uses.regexp {
+		imports = append(imports, "regexp")
+	}
+	if enums || g.uses.strconv || streams {
+		imports = append(imports, "strconv")
+	}
+	imports = append(imports, "strings")
+	if streams {
+		imports = append(imports, "sync")
+	}
+	if g.usesTime() {
+		imports = append(imports, "time")
+	}
+	if g.uses.utf8 {
+		imports = append(imports, "unicode/utf8")
+	}
+	imports = append(imports, "", "github.com/vmihailenco/msgpack/v4")
+	if len(roles) > 0 || streams {
+		imports = append(imports, "github.com/wapc/wapc-go")
+	}
+	imports = append(imports, g.mappedImports()...)
+	out := []string{generatedComment, "", "package " + g.config.Package, "", "import ("}
+	for _, path := range imports {
+		if path != "" {
+			path = "\t" + strconv.Quote(path)
+		}
+		out = append(out, path)
+	}
+	out = append(out, ")", "", hostModule, execute("host_call", streams), execute("missing_field_error", nil))
+	if len(g.constrained) > 0 {
+		out = append(out, execute("validation_error", nil))
+	}
+	if len(g.doc.Unions) > 0 {
+		out = append(out, execute("union_error", nil))
+	}
+	out = append(out, g.hostOps(ops)...)
+	out = append(out, execute("host_async", nil))
+	if streams {
+		out = append(out, execute("host_streams", nil))
+	}
+	for _, role := range roles {
+		out = append(out, g.hostRole(role)...)
+	}
+	if len(roles) > 0 {
+		out = append(out, g.hostRouter(roles, streams)...)
+		for _, role := range roles {
+			for _, op := range operations(role) {
+				if op.hasArgs() {
+					out = append(out, g.hostStruct(op.argsType())...)
+				}
+			}
+		}
+	} else if streams {
+		out = append(out, execute("unknown_operation_error", nil))
+	}
+	for _, t := range g.doc.Types {
+		out = append(out, g.hostStruct(t)...)
+	}
+	for _, u := range g.doc.Unions {
+		out = append(out, g.hostUnion(u)...)
+	}
+	var objects []*widl.Type
+	for _, op := range ops {
+		if op.hasArgs() {
+			objects = append(objects, op.argsType())
+		}
+	}
+	objects = append(objects, g.doc.Types...)
+	for _, u := range g.doc.Unions {
+		objects = append(objects, unionType(u))
+	}
+	out = append(out, fieldsTable(objects)...)
+	for _, a := range g.doc.Aliases {
+		if _, ok := g.config.Types[a.Name]; !ok {
+			out = append(out, doc(a.Description)...)
+			out = append(out, "type "+a.Name+" "+prims[a.Type.Name][0], "")
+		}
+	}
+	for _, e := range g.doc.Enums {
+		out = append(out, enum(e)...)
+		out = append(out,
+			"func (e "+e.Name+") EncodeMsgpack(enc *msgpack.Encoder) error {",
+			"\treturn encodeEnum(enc, int32(e))",
+			"}",
+			"")
+	}
+	if enums {
+		out = append(out, execute("host_encode_enum", nil))
+	}
+	return out
+}
+
+// hostStruct returns the struct of an object, with a DecodeMsgpack method
+// setting the default values and its Validate and Check methods.
+func (g *generator) hostStruct(t *widl.Type) []string {
+	out := []string{"type " + t.Name + " struct {"}
+	defaults := false
+	for _, f := range t.Fields {
+		out = append(out, "\t"+goName(f.Name)+" "+g.goType(f.Type)+" `msgpack:\""+f.Name+"\"`")
+		defaults = defaults || f.Default != nil
+	}
+	out = append(out, "}", "")
+	if defaults {
+		out = append(out,
+			"func (o *"+t.Name+") DecodeMsgpack(dec *msgpack.Decoder) error {",
+			"\ttype plain "+t.Name,
+			"\t*o = "+t.Name+"{")
+		for _, f := range t.Fields {
+			if f.Default != nil {
+				out = append(out, "\t\t"+goName(f.Name)+": "+defaultValue(f)+",")
+			}
+		}
+		out = append(out, "\t}", "\treturn dec.Decode((*plain)(o))", "}", "")
+	}
+	out = append(out, g.validateMethod(t)...)
+	return append(out, g.checkMethods(t)...)
+}
+
+func (g *generator) hostUnion(u *widl.Union) []string {
+	t := unionType(u)
+	out := doc(u.Description)
+	out = append(out, "type "+u.Name+" struct {")
+	for _, f := range t.Fields {
+		out = append(out, "\t"+f.Name+" *"+f.Name+" `msgpack:\""+f.Name+",omitempty\"`")
+	}
+	out = append(out,
+		"}",
+		"",
+		"func (o "+u.Name+") EncodeMsgpack(enc *msgpack.Encoder) error {",
+		"\tif variants := o.variants(); len(variants) != 1 {",
+		"\t\treturn &UnionError{"+strconv.Quote(u.Name)+", variants}",
+		"\t}",
+		"\ttype plain "+u.Name,
+		"\treturn enc.Encode(plain(o))",
+		"}",
+		"",
+		"func (o *"+u.Name+") DecodeMsgpack(dec *msgpack.Decoder) error {",
+		"\t*o = "+u.Name+"{}",
+		"\tn, err := dec.DecodeMapLen()",
+		"\tif err != nil {",
+		"\t\treturn err",
+		"\t}",
+		"\tvar variants []string",
+		"\tfor ; n > 0; n-- {",
+		"\t\tkey, err := dec.DecodeString()",
+		"\t\tif err != nil {",
+		"\t\t\treturn err",
+		"\t\t}",
+		"\t\tswitch key {")
+	for _, f := range t.Fields {
+		out = append(out,
+			"\t\tcase "+strconv.Quote(f.Name)+":",
+			"\t\t\tif err := dec.Decode(&o."+f.Name+"); err != nil {",
+			"\t\t\t\treturn err",
+			"\t\t\t}",
+			"\t\t\tif o."+f.Name+" != nil {",
+			"\t\t\t\tvariants = append(variants, key)",
+			"\t\t\t}")
+	}
+	out = append(out,
+		"\t\tdefault:",
+		"\t\t\tif err := dec.Skip(); err != nil {",
+		"\t\t\t\treturn err",
+		"\t\t\t}",
+		"\t\t\tvariants = append(variants, key)",
+		"\t\t}",
+		"\t}",
+		"\tif len(variants) != 1 || len(o.variants()) != 1 {",
+		"\t\treturn &UnionError{"+strconv.Quote(u.Name)+", variants}",
+		"\t}",
+		"\treturn nil",
+		"}",
+		"")
+	out = append(out, variantsMethod(t)...)
+	return append(out, g.validateMethod(t)...)
+}
+
+// fieldsTable returns the table describing `objects` for requireFields.
+func fieldsTable(objects []*widl.Type) []string {
+	out := []string{
+		"// fields describes the objects of the schema so that requireFields can",
+		"// check payloads for missing fields.",
+		"var fields = map[string][]field{",
+	}
+	for _, t := range objects {
+		out = append(out, "\t"+strconv.Quote(t.Name)+": {")
+		for _, f := range t.Fields {
+			out = append(out, "\t\t{"+strconv.Quote(f.Name)+", "+strconv.Quote(f.Type.String())+", "+strconv.FormatBool(required(f))+"},")
+		}
+		out = append(out, "\t},")
+	}
+	return append(out, "}", "")
+}
+
+func (g *generator) hostParams(op operation) string {
+	params := []string{"ctx context.Context"}
+	for _, p := range op.Parameters {
+		params = append(params, p.Name+" "+g.goType(p.Type))
+	}
+	return strings.Join(params, ", ")
+}
+
+func (g *generator) hostOps(ops []operation) []string {
+	var out []string
+	for _, op := range ops {
+		params := g.hostParams(op)
+		resp := "Void"
+		if op.Returns != nil {
+			resp = g.goType(op.Returns)
+		}
+		var req, arg string
+		switch {
+		case op.Unary:
+			req, arg = g.goType(op.Parameters[0].Type), op.Parameters[0].Name
+		case len(op.args()) > 0:
+			req = op.argsName()
+			arg = op.argsName() + "{\n"
+			for _, p := range op.args() {
+				arg += "\t\t" + goName(p.Name) + ": " + p.Name + ",\n"
+			}
+			arg += "\t}"
+		default:
+			req, arg = "Void", "Void{}"
+		}
+		response := ""
+		if op.Returns != nil && g.referencesObject(op.Returns) {
+			response = op.Returns.String()
+		}
+		call := "Call[" + req + ", " + resp + "](ctx, m.instance, " + strconv.Quote(op.Name) + ", " + arg + ", m.options(" + strconv.Quote(response) + ")...)"
+		switch {
+		case op.StreamResult():
+			elem := op.Returns.Elem.Name
+			out = append(out,
+				"func (m *Module) "+op.goName()+"("+params+") *StreamReader["+elem+"] {",
+				"\treturn OpenStream["+req+", "+elem+"](ctx, m.instance, "+strconv.Quote(op.Name)+", "+arg+", m.options(\"\")...)")
+		case op.StreamParameter() != nil:
+			p := op.StreamParameter()
+			out = append(out,
+				"func (m *Module) "+op.goName()+"("+params+") ("+resp+", error) {",
+				"\treturn SendStream["+req+", "+p.Type.Elem.Name+", "+resp+"](ctx, m.instance, "+strconv.Quote(op.Name)+", "+arg+", "+p.Name+", m.options("+strconv.Quote(response)+")...)")
+		case op.Returns != nil:
+			out = append(out,
+				"func (m *Module) "+op.goName()+"("+params+") ("+resp+", error) {",
+				"\treturn "+call)
+		default:
+			out = append(out,
+				"func (m *Module) "+op.goName()+"("+params+") error {",
+				"\t_, err := "+call,
+				"\treturn err")
+		}
+		out = append(out, "}", "")
+		if op.StreamResult() || op.StreamParameter() != nil {
+			continue
+		}
+		options := "m.options(" + strconv.Quote(response) + ")..."
+		out = append(out,
+			"func (m *Module) "+op.goName()+"Async("+params+") *Future["+resp+"] {",
+			"\treturn InvokeAsync["+req+", "+resp+"](ctx, m.instance, "+strconv.Quote(op.Name)+", "+arg+", "+options+")",
+			"}",
+			"")
+		if req != "Void" {
+			out = append(out,
+				"func (m *Module) "+op.goName()+"Batch(ctx context.Context, reqs []"+req+", settings BatchSettings) []Result["+resp+"] {",
+				"\treturn RunBatch["+req+", "+resp+"](ctx, m.instance, "+strconv.Quote(op.Name)+", reqs, settings, "+options+")",
+				"}",
+				"")
+		}
+	}
+	for _, op := range ops {
+		if op.hasArgs() {
+			out = append(out, g.hostStruct(op.argsType())...)
+		}
+	}
+	return out
+}
+
+// hostRole returns the interface the host implements for a role.
+func (g *generator) hostRole(role *widl.Interface) []string {
+	out := []string{"// " + role.Name + " is the host side of the " + role.Namespace() + " namespace."}
+	out = append(out, doc(role.Description)...)
+	out = append(out, "type "+role.Name+" interface {")
+	for _, op := range operations(role) {
+		out = append(out, indent(doc(op.Description), 1)...)
+		if op.Returns != nil {
+			out = append(out, "\t"+op.goName()+"("+g.hostParams(op)+") ("+g.goType(op.Returns)+", error)")
+		} else {
+			out = append(out, "\t"+op.goName()+"("+g.hostParams(op)+") error")
+		}
+	}
+	return append(out, "}", "")
+}
+
+// hostDispatch returns the method of Router that decodes the host calls to
+// a role and invokes its implementation.
+func (g *generator) hostDispatch(role *widl.Interface) []string {
+	out := []string{
+		"func (r *Router) " + unexport(role.Name) + "(ctx context.Context, operation string, payload []byte) ([]byte, error) {",
+		"\tswitch operation {",
+	}
+	for _, op := range operations(role) {
+		out = append(out, "\tcase "+strconv.Quote(op.Name)+":")
+		args := []string{"ctx"}
+		switch {
+		case op.Unary:
+			p := op.Parameters[0]
+			out = append(out,
+				"\t\tvar "+p.Name+" "+g.goType(p.Type),
+				"\t\tif err := msgpack.Unmarshal(payload, &"+p.Name+"); err != nil {",
+				"\t\t\treturn nil, err",
+				"\t\t}")
+			args = append(args, p.Name)
+		case len(op.Parameters) > 0:
+			out = append(out,
+				"\t\tvar inputArgs "+op.argsName(),
+				"\t\tif err := msgpack.Unmarshal(payload, &inputArgs); err != nil {",
+				"\t\t\treturn nil, err",
+				"\t\t}")
+			for _, p := range op.Parameters {
+				args = append(args, "inputArgs."+goName(p.Name))
+			}
+		}
+		call := "r." + role.Name + "." + op.goName() + "(" + strings.Join(args, ", ") + ")"
+		if op.Returns != nil {
+			out = append(out,
+				"\t\tret, err := "+call,
+				"\t\tif err != nil {",
+				"\t\t\treturn nil, err",
+				"\t\t}",
+				"\t\treturn msgpack.Marshal(&ret)")
+		} else {
+			out = append(out, "\t\treturn []byte{}, "+call)
+		}
+	}
+	return append(out,
+		"\t}",
+		"\treturn nil, &UnknownOperationError{"+strconv.Quote(role.Namespace())+", operation}",
+		"}",
+		"")
+}
+
+// hostRouter returns the Router dispatching host calls to the roles.
+func (g *generator) hostRouter(roles []*widl.Interface, streams bool) []string {
+	out := []string{
+		"// Router dispatches host calls to the implementation of their namespace.",
+		"// Calls to other namespaces, or to a namespace without an implementation,",
+		"// go to Fallback. Its HostCall method is the wapc.HostCallHandler.",
+		"type Router struct {",
+	}
+	for _, role := range roles {
+		out = append(out, "\t"+role.Name+" "+role.Name)
+	}
+	out = append(out,
+		"\tFallback wapc.HostCallHandler",
+		"}",
+		"",
+		"func (r *Router) HostCall(ctx context.Context, binding, namespace, operation string, payload []byte) ([]byte, error) {",
+		"\tswitch {")
+	if streams {
+		out = append(out,
+			"\tcase namespace == StreamNamespace:",
+			"\t\treturn serveStream(ctx, operation, payload)")
+	}
+	for _, role := range roles {
+		out = append(out,
+			"\tcase namespace == "+strconv.Quote(role.Namespace())+" && r."+role.Name+" != nil:",
+			"\t\treturn r."+unexport(role.Name)+"(ctx, operation, payload)")
+	}
+	out = append(out,
+		"\tcase r.Fallback != nil:",
+		"\t\treturn r.Fallback(ctx, binding, namespace, operation, payload)",
+		"\t}",
+		"\treturn nil, &UnknownOperationError{namespace, operation}",
+		"}",
+		"",
+		execute("unknown_operation_error", nil))
+	for _, role := range roles {
+		out = append(out, g.hostDispatch(role)...)
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package codegen
+
+import (
+	"errors"
+	"regexp"
+	"unicode/utf8"
+)
+
+// countPattern matches the {n}, {n,} and {n,m} quantifiers.
+var countPattern = regexp.MustCompile(`^\{[0-9]+(,[0-9]*)?\}`)
+
+// checkPattern returns an error if `pattern` is not in the subset of the
+// regular expression syntax that Go's regexp, Rust's regex and the
+// AssemblyScript guest's engine all read the same way: literals, escaped
+// punctuation, the \n, \t, \r, \f and \v escapes, ".", classes of
+// characters and ranges, groups, alternation, the *, +, ?, {n}, {n,} and
+// {n,m} quantifiers and the ^ and $ anchors. Letter escapes such as \d,
+// which Rust reads as Unicode classes, flags, POSIX classes and the class
+// operators of Rust are not in it.
+func checkPattern(pattern string) error {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return errors.New("is invalid: " + err.Error())
+	}
+	inClass := false
+	for i := 0; i < len(pattern); {
+		c, size := utf8.DecodeRuneInString(pattern[i:])
+		rest := pattern[i+size:]
+		switch {
+		case c == '\\':
+			e, n := utf8.DecodeRuneInString(rest)
+			if !portableEscape(e) {
+				return errors.New("uses the escape \\" + string(e) + ", which is not portable")
+			}
+			size += n
+		case inClass && c == '[':
+			return errors.New("uses [ in a class, which must be escaped")
+		case inClass && c == ']':
+			inClass = false
+		case inClass && (c == '&' || c == '-' || c == '~') && len(rest) > 0 && rest[0] == byte(c):
+			return errors.New("uses " + string(c) + string(c) + " in a class, which is not portable")
+		case inClass:
+		case c == '[':
+			inClass = true
+			// A ] right after [ or [^ is a literal.
+			if len(rest) > 0 && rest[0] == '^' {
+				size++
+				rest = rest[1:]
+			}
+			if len(rest) > 0 && rest[0] == ']' {
+				size++
+			}
+		case c == '(' && len(rest) > 0 && rest[0] == '?':
+			if len(rest) < 2 || rest[1] != ':' {
+				return errors.New("uses flags or named groups, which are not portable")
+			}
+			size += 2
+		case c == '{':
+			count := countPattern.FindString(pattern[i:])
+			if count == "" {
+				return errors.New("uses { outside of a quantifier, which must be escaped")
+			}
+			size = len(count)
+		case c == '}':
+			return errors.New("uses } outside of a quantifier, which must be escaped")
+		}
+		i += size
+	}
+	return nil
+}
+
+// portableEscape reports whether \c means the same in every language.
+func portableEscape(c rune) bool {
+	switch c {
+	case 'n', 't', 'r', 'f', 'v':
+		return true
+	}
+	return c < utf8.RuneSelf && !('0' <= c && c <= '9') && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,914 @@
+package codegen
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/wapc/language-tests/pkg/widl"
+)
+
+// Rust generates the bindings of a Rust guest: the Handlers of the
+// operations of the interface, the Host and role clients calling the host,
+// and the types of the schema. The types derive their codecs with serde, and
+// the bindings encode and decode them with rmp-serde. The code also uses
+// lazy_static, serde_bytes for bytes, and regex for @pattern constraints.
+//
+// Unlike the TinyGo bindings, decoding always fails on a missing required
+// field, and unions are enums, so they always hold exactly one variant.
+func Rust(doc *widl.Document, config Config) ([]byte, error) {
+	if len(config.Types) > 0 {
+		return nil, errors.New("the Rust bindings cannot map aliases to other types")
+	}
+	g, err := newGenerator(doc, config, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.checkKeys("Rust"); err != nil {
+		return nil, err
+	}
+	return spaces(g.rustFile(), 4), nil
+}
+
+// rustPrims maps the built-in types of WIDL to their Rust type.
+var rustPrims = map[string]string{
+	"bool":   "bool",
+	"u8":     "u8",
+	"u16":    "u16",
+	"u32":    "u32",
+	"u64":    "u64",
+	"i8":     "i8",
+	"i16":    "i16",
+	"i32":    "i32",
+	"i64":    "i64",
+	"f32":    "f32",
+	"f64":    "f64",
+	"string": "String",
+	"bytes":  "ByteBuf",
+}
+
+// spaces indents the generated code with `width` spaces per level, as the
+// formatters of the guest languages do.
+func spaces(code []string, width int) []byte {
+	var b strings.Builder
+	for _, line := range code {
+		tabs := len(line) - len(strings.TrimLeft(line, "\t"))
+		b.WriteString(strings.Repeat(" ", tabs*width) + line[tabs:] + "\n")
+	}
+	return []byte(strings.TrimRight(b.String(), "\n") + "\n")
+}
+
+// rustDoc returns the doc comment of a description.
+func rustDoc(description string) []string {
+	if description == "" {
+		return nil
+	}
+	return []string{"/// " + description}
+}
+
+// snakeCase returns the Rust name of a field or an operation of the schema.
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// walkTypes calls f for every type reference of the schema, including the
+// keys and elements of collections, until it returns true.
+func (g *generator) walkTypes(f func(t *widl.TypeRef) bool) bool {
+	var walk func(t *widl.TypeRef) bool
+	walk = func(t *widl.TypeRef) bool {
+		return t != nil && (f(t) || walk(t.Key) || walk(t.Elem))
+	}
+	for _, t := range g.doc.Types {
+		for _, field := range t.Fields {
+			if walk(field.Type) {
+				return true
+			}
+		}
+	}
+	for _, i := range g.doc.Interfaces {
+		for _, op := range i.Operations {
+			if walk(op.Returns) {
+				return true
+			}
+			for _, p := range op.Parameters {
+				if walk(p.Type) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// checkKeys fails on map keys that `language` cannot hash by value.
+func (g *generator) checkKeys(language string) error {
+	var err error
+	g.walkTypes(func(t *widl.TypeRef) bool {
+		if t.Kind != widl.Map {
+			return false
+		}
+		k := t.Key
+		name := k.Name
+		if g.isAlias(k) {
+			name = g.aliases[k.Name]
+		}
+		if k.Kind != widl.Named || k.Optional || name == "f32" || name == "f64" || g.isObject(k) {
+			err = errors.New("map keys of type " + k.String() + " are not supported in " + language)
+			return true
+		}
+		return false
+	})
+	return err
+}
+
+func (g *generator) rustFile() []string {
+	ops := operations(g.doc.Interface())
+	streams := streamTypes(ops)
+	unions := len(g.doc.Unions) > 0
+	times := g.usesTime()
+	bytes := g.walkTypes(func(t *widl.TypeRef) bool { return t.Name == "bytes" })
+	for _, a := range g.doc.Aliases {
+		bytes = bytes || a.Type.Name == "bytes"
+	}
+	recursive := len(g.recursive) > 0
+	// The items set g.uses.orEmpty and g.uses.nestedOrEmpty.
+	items := g.rustItems(ops, streams)
+	out := []string{generatedComment, "", "use std::cell::Cell;"}
+	if g.walkTypes(func(t *widl.TypeRef) bool { return t.Kind == widl.Map }) {
+		out = append(out, "use std::collections::HashMap;")
+	}
+	if len(streams) > 0 {
+		out = append(out, "use std::collections::VecDeque;")
+	}
+	out = append(out, "use std::error::Error;", "use std::fmt;")
+	if len(streams) > 0 {
+		out = append(out, "use std::sync::atomic::{AtomicBool, AtomicUsize, Ordering};")
+	} else {
+		out = append(out, "use std::sync::atomic::{AtomicBool, Ordering};")
+	}
+	out = append(out, "use std::sync::RwLock;", "", "use lazy_static::lazy_static;")
+	if g.uses.regexp {
+		out = append(out, "use regex::Regex;")
+	}
+	switch {
+	case unions:
+		out = append(out, "use serde::de::{self, IgnoredAny, MapAccess, Visitor};", "use serde::ser::SerializeMap;")
+	case times || recursive:
+		out = append(out, "use serde::de;")
+	}
+	switch {
+	case unions || times:
+		out = append(out, "use serde::{Deserialize, Deserializer, Serialize, Serializer};")
+	case recursive || g.uses.orEmpty:
+		out = append(out, "use serde::{Deserialize, Deserializer, Serialize};")
+	default:
+		out = append(out, "use serde::{Deserialize, Serialize};")
+	}
+	if bytes || times {
+		out = append(out, "use serde_bytes::ByteBuf;")
+	}
+	out = append(out, "use wapc_guest::prelude::*;", "")
+	out = append(out, execute("rust_codec", nil))
+	if times {
+		out = append(out, execute("rust_timestamp", nil))
+	}
+	if g.uses.orEmpty {
+		out = append(out, execute("rust_or_empty", nil))
+	}
+	if recursive {
+		out = append(out, execute("rust_depth", g.uses.nestedOrEmpty))
+	}
+	if len(g.constrained) > 0 {
+		out = append(out, execute("rust_validation_error", nil))
+	}
+	if unions {
+		out = append(out, execute("rust_union_error", nil))
+	}
+	return append(out, items...)
+}
+
+// rustItems returns the hosts, the handlers and the types of the file.
+func (g *generator) rustItems(ops []operation, streams []string) []string {
+	var out []string
+	out = append(out, g.rustHost("Host", nil, g.doc.Namespace.Name, ops)...)
+	out = append(out, g.rustOps(ops)...)
+	if len(streams) > 0 {
+		out = append(out, execute("rust_streams", nil))
+		for _, name := range streams {
+			out = append(out, g.rustStruct(frameType(name), []string{
+				"/// " + name + "Frame is a chunk of a stream of " + name + ". Frames are numbered from 0",
+				"/// and the last one has end set.",
+			})...)
+			out = append(out, execute("rust_stream", name))
+		}
+	}
+	for _, role := range g.roles() {
+		host := role.Name + "Host"
+		comment := []string{"/// " + host + " calls the host operations of the " + role.Namespace() + " namespace."}
+		roleOps := operations(role)
+		out = append(out, g.rustHost(host, append(comment, rustDoc(role.Description)...), role.Namespace(), roleOps)...)
+		for _, op := range roleOps {
+			if op.hasArgs() {
+				out = append(out, g.rustStruct(op.argsType(), nil)...)
+			}
+		}
+	}
+	for _, t := range g.doc.Types {
+		out = append(out, g.rustStruct(t, rustDoc(t.Description))...)
+	}
+	for _, u := range g.doc.Unions {
+		out = append(out, g.rustUnion(u)...)
+	}
+	for _, a := range g.doc.Aliases {
+		out = append(out, rustDoc(a.Description)...)
+		out = append(out, "pub type "+a.Name+" = "+rustPrims[a.Type.Name]+";", "")
+	}
+	for _, e := range g.doc.Enums {
+		out = append(out, g.rustEnum(e)...)
+	}
+	return out
+}
+
+// rustDeserializeWith returns the function deserializing the field f, or ""
+// for its own Deserialize: nested for fields holding recursive objects, which
+// count their nesting, and or_empty for required collections and bytes, which
+// decode nil as empty.
+func (g *generator) rustDeserializeWith(f *widl.Field) string {
+	nested := g.recursive[g.heldObject(f.Type)]
+	orEmpty := !f.Type.Optional && g.isNilable(f.Type)
+	switch {
+	case nested && orEmpty:
+		g.uses.nestedOrEmpty = true
+		return "nested_or_empty"
+	case nested:
+		return "nested"
+	case orEmpty:
+		g.uses.orEmpty = true
+		return "or_empty"
+	}
+	return ""
+}
+
+// rustType returns the Rust type of t, in which objects are boxed if `boxed`.
+func (g *generator) rustType(t *widl.TypeRef, boxed bool) string {
+	var name string
+	switch {
+	case t.Kind == widl.List:
+		name = "Vec<" + g.rustType(t.Elem, false) + ">"
+	case t.Kind == widl.Map:
+		name = "HashMap<" + g.rustType(t.Key, false) + ", " + g.rustType(t.Elem, false) + ">"
+	case t.Kind == widl.Stream:
+		name = "&mut " + t.Elem.Name + "StreamReader"
+	case isPrim(t):
+		name = rustPrims[t.Name]
+	case t.Name == "datetime":
+		name = "Timestamp"
+	case boxed:
+		name = "Box<" + t.Name + ">"
+	default:
+		name = t.Name
+	}
+	if t.Optional {
+		return "Option<" + name + ">"
+	}
+	return name
+}
+
+// objectFields returns the objects that an object or a union holds outside
+// of collections, which Rust stores inline.
+func (g *generator) objectFields(name string) []string {
+	var names []string
+	for _, u := range g.doc.Unions {
+		if u.Name == name {
+			for _, m := range u.Members {
+				names = append(names, m.Name)
+			}
+		}
+	}
+	for _, t := range g.doc.Types {
+		if t.Name != name {
+			continue
+		}
+		for _, f := range t.Fields {
+			if g.isObject(f.Type) {
+				names = append(names, f.Type.Name)
+			}
+		}
+	}
+	return names
+}
+
+// isBoxed reports whether a field of type t of `owner` must be boxed, as
+// the object it holds holds `owner` in turn, outside of collections.
+func (g *generator) isBoxed(owner string, t *widl.TypeRef) bool {
+	if !g.isObject(t) {
+		return false
+	}
+	seen := map[string]bool{}
+	var reaches func(name string) bool
+	reaches = func(name string) bool {
+		if name == owner {
+			return true
+		}
+		if seen[name] {
+			return false
+		}
+		seen[name] = true
+		for _, n := range g.objectFields(name) {
+			if reaches(n) {
+				return true
+			}
+		}
+		return false
+	}
+	return reaches(t.Name)
+}
+
+// receiver returns `expr`, a reference, as the receiver of a method call,
+// which dereferences it.
+func receiver(expr string) string {
+	return strings.TrimPrefix(expr, "&")
+}
+
+// deref returns the value that `expr` refers to.
+func deref(expr string) string {
+	if strings.HasPrefix(expr, "&") {
+		return expr[1:]
+	}
+	return "*" + expr
+}
+
+// rustDefault returns the Rust expression of the default value of a field.
+func (g *generator) rustDefault(f *widl.Field) string {
+	name := f.Type.Name
+	if g.isAlias(f.Type) {
+		name = g.aliases[name]
+	}
+	switch {
+	case f.Default.Kind == widl.Identifier && g.isEnum(f.Type):
+		return f.Type.Name + "::" + strings.ToUpper(snakeCase(f.Default.Text))
+	case f.Default.Kind == widl.String:
+		return quote(f.Default.Text) + ".to_string()"
+	case name == "f32" || name == "f64":
+		return floatLiteral(f.Default.Text)
+	}
+	return f.Default.Text
+}
+
+// floatLiteral makes a number of the schema a Rust float literal.
+func floatLiteral(n string) string {
+	if strings.ContainsAny(n, ".eE") {
+		return n
+	}
+	return n + ".0"
+}
+
+// rustStruct returns the struct of an object, deriving its codec, and, if
+// it has constraints, its validate method. `comment` is its doc comment.
+// Fields with a default get it from a function of the struct, which both
+// its Default and its decoding call.
+func (g *generator) rustStruct(t *widl.Type, comment []string) []string {
+	hasDefaults := false
+	for _, f := range t.Fields {
+		hasDefaults = hasDefaults || f.Default != nil
+	}
+	out := append([]string(nil), comment...)
+	if hasDefaults {
+		out = append(out, "#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]")
+	} else {
+		out = append(out, "#[derive(Debug, Clone, PartialEq, Default, Serialize, Deserialize)]")
+	}
+	out = append(out, "pub struct "+t.Name+" {")
+	var methods []string
+	for _, f := range t.Fields {
+		typ := g.rustType(f.Type, g.isBoxed(t.Name, f.Type))
+		attr := "rename = " + strconv.Quote(f.Name)
+		if with := g.rustDeserializeWith(f); with != "" {
+			// With deserialize_with, a missing Option is no longer None.
+			if f.Type.Optional && f.Default == nil {
+				attr += ", default"
+			}
+			attr += ", deserialize_with = " + strconv.Quote(with)
+		}
+		if required(f) {
+			attr += ", default = " + strconv.Quote(t.Name+"::missing_"+snakeCase(f.Name))
+			if len(methods) > 0 {
+				methods = append(methods, "")
+			}
+			methods = append(methods,
+				"fn missing_"+snakeCase(f.Name)+"() -> "+typ+" {",
+				"	missing_field("+strconv.Quote(t.Name)+", "+strconv.Quote(f.Name)+")",
+				"}")
+		}
+		if f.Default != nil {
+			attr += ", default = " + strconv.Quote(t.Name+"::default_"+snakeCase(f.Name))
+			if len(methods) > 0 {
+				methods = append(methods, "")
+			}
+			methods = append(methods,
+				"fn default_"+snakeCase(f.Name)+"() -> "+typ+" {",
+				"\t"+g.rustDefault(f),
+				"}")
+		}
+		out = append(out, indent(rustDoc(f.Description), 1)...)
+		out = append(out, "\t#[serde("+attr+")]", "\tpub "+snakeCase(f.Name)+": "+typ+",")
+	}
+	out = append(out, "}", "")
+	if hasDefaults {
+		out = append(out,
+			"impl Default for "+t.Name+" {",
+			"\tfn default() -> Self {",
+			"\t\t"+t.Name+" {")
+		for _, f := range t.Fields {
+			value := "Default::default()"
+			if f.Default != nil {
+				value = t.Name + "::default_" + snakeCase(f.Name) + "()"
+			}
+			out = append(out, "\t\t\t"+snakeCase(f.Name)+": "+value+",")
+		}
+		out = append(out, "\t\t}", "\t}", "}", "")
+	}
+	out = append(out, g.rustPatterns(t)...)
+	check := g.rustCheckMethods(t)
+	if len(methods) == 0 && len(check) > 0 {
+		check = check[1:]
+	}
+	methods = append(methods, check...)
+	if len(methods) == 0 {
+		return out
+	}
+	out = append(out, "impl "+t.Name+" {")
+	out = append(out, indent(methods, 1)...)
+	return append(out, "}", "")
+}
+
+// rustUnion returns the enum of a union, with a variant for each member, and
+// its codec. It is encoded as a map from the name of its variant to its
+// value, and decoding fails unless the payload holds exactly one variant.
+func (g *generator) rustUnion(u *widl.Union) []string {
+	out := rustDoc(u.Description)
+	out = append(out, "#[derive(Debug, Clone, PartialEq)]", "pub enum "+u.Name+" {")
+	for _, m := range u.Members {
+		out = append(out, "\t"+m.Name+"("+g.rustType(m, g.isBoxed(u.Name, m))+"),")
+	}
+	first := u.Members[0]
+	value := first.Name + "::default()"
+	if g.isBoxed(u.Name, first) {
+		value = "Box::default()"
+	}
+	visitor := u.Name + "Visitor"
+	out = append(out, "}", "",
+		"impl Default for "+u.Name+" {",
+		"\tfn default() -> Self {",
+		"\t\t"+u.Name+"::"+first.Name+"("+value+")",
+		"\t}",
+		"}",
+		"",
+		"impl Serialize for "+u.Name+" {",
+		"\tfn serialize<S: Serializer>(&self, serializer: S) -> Result<S::Ok, S::Error> {",
+		"\t\tlet mut map = serializer.serialize_map(Some(1))?;",
+		"\t\tmatch self {")
+	for _, m := range u.Members {
+		out = append(out, "\t\t\t"+u.Name+"::"+m.Name+"(v) => map.serialize_entry("+strconv.Quote(m.Name)+", v)?,")
+	}
+	out = append(out,
+		"\t\t}",
+		"\t\tmap.end()",
+		"\t}",
+		"}",
+		"",
+		"impl<'de> Deserialize<'de> for "+u.Name+" {",
+		"\tfn deserialize<D: Deserializer<'de>>(deserializer: D) -> Result<Self, D::Error> {",
+		"\t\tdeserializer.deserialize_map("+visitor+")",
+		"\t}",
+		"}",
+		"",
+		"struct "+visitor+";",
+		"",
+		"impl<'de> Visitor<'de> for "+visitor+" {",
+		"\ttype Value = "+u.Name+";",
+		"",
+		"\tfn expecting(&self, f: &mut fmt::Formatter) -> fmt::Result {",
+		"\t\tf.write_str(\"a map holding a variant of "+u.Name+"\")",
+		"\t}",
+		"",
+		"\tfn visit_map<A: MapAccess<'de>>(self, mut map: A) -> Result<"+u.Name+", A::Error> {",
+		"\t\tlet mut variants = Vec::new();",
+		"\t\tlet mut o = None;",
+		"\t\twhile let Some(field) = map.next_key::<String>()? {",
+		"\t\t\tmatch field.as_str() {")
+	for _, m := range u.Members {
+		out = append(out,
+			"\t\t\t\t"+strconv.Quote(m.Name)+" => {",
+			"\t\t\t\t\tif let Some(v) = map.next_value::<"+g.rustType(&widl.TypeRef{Kind: widl.Named, Name: m.Name, Optional: true}, g.isBoxed(u.Name, m))+">()? {",
+			"\t\t\t\t\t\to = Some("+u.Name+"::"+m.Name+"(v));",
+			"\t\t\t\t\t\tvariants.push(field);",
+			"\t\t\t\t\t}",
+			"\t\t\t\t}")
+	}
+	return append(out,
+		"\t\t\t\t_ => {",
+		"\t\t\t\t\tmap.next_value::<IgnoredAny>()?;",
+		"\t\t\t\t\tvariants.push(field);",
+		"\t\t\t\t}",
+		"\t\t\t}",
+		"\t\t}",
+		"\t\tmatch o {",
+		"\t\t\tSome(o) if variants.len() == 1 => Ok(o),",
+		"\t\t\t_ => Err(de::Error::custom(UnionError {",
+		"\t\t\t\ttype_name: "+strconv.Quote(u.Name)+",",
+		"\t\t\t\tvariants,",
+		"\t\t\t})),",
+		"\t\t}",
+		"\t}",
+		"}",
+		"")
+}
+
+// rustEnum returns an enum as a struct of its value, encoded as the value,
+// so that unknown values are preserved, with a constant for each declared
+// value.
+func (g *generator) rustEnum(e *widl.Enum) []string {
+	out := rustDoc(e.Description)
+	out = append(out,
+		"#[derive(Debug, Default, Clone, Copy, PartialEq, Eq, Hash, Serialize, Deserialize)]",
+		"#[serde(transparent)]",
+		"pub struct "+e.Name+"(pub i32);",
+		"",
+		"impl "+e.Name+" {")
+	var names []string
+	for _, v := range e.Values {
+		name := e.Name + "::" + strings.ToUpper(snakeCase(v.Name))
+		names = append(names, name)
+		out = append(out, "\tpub const "+strings.ToUpper(snakeCase(v.Name))+": "+e.Name+" = "+e.Name+"("+strconv.Itoa(v.Index)+");")
+	}
+	out = append(out,
+		"",
+		"\t/// is_valid reports whether the value is one of the declared values.",
+		"\tpub fn is_valid(&self) -> bool {",
+		"\t\tmatches!(*self, "+strings.Join(names, " | ")+")",
+		"\t}",
+		"}",
+		"",
+		"impl fmt::Display for "+e.Name+" {",
+		"\tfn fmt(&self, f: &mut fmt::Formatter) -> fmt::Result {",
+		"\t\tmatch *self {")
+	for i, v := range e.Values {
+		out = append(out, "\t\t\t"+names[i]+" => f.write_str("+strconv.Quote(v.Name)+"),")
+	}
+	return append(out,
+		"\t\t\t_ => write!(f, \""+e.Name+"({})\", self.0),",
+		"\t\t}",
+		"\t}",
+		"}",
+		"")
+}
+
+// rustHandlerSig returns the type of the handler of `op`.
+func (g *generator) rustHandlerSig(op operation) string {
+	var params []string
+	for _, p := range op.Parameters {
+		params = append(params, g.rustType(p.Type, false))
+	}
+	result := "()"
+	switch {
+	case op.StreamResult():
+		params = append(params, "&mut "+op.Returns.Elem.Name+"StreamWriter")
+	case op.Returns != nil:
+		result = g.rustType(op.Returns, false)
+	}
+	return "fn(" + strings.Join(params, ", ") + ") -> HandlerResult<" + result + ">"
+}
+
+// rustHost returns the struct `host` with a method calling each of `ops` on
+// the host.
+func (g *generator) rustHost(host string, comment []string, namespace string, ops []operation) []string {
+	out := append([]string(nil), comment...)
+	out = append(out,
+		"pub struct "+host+" {",
+		"\tbinding: String,",
+		"}",
+		"",
+		"impl "+host+" {",
+		"\tpub fn new(binding: &str) -> Self {",
+		"\t\t"+host+" {",
+		"\t\t\tbinding: binding.to_string(),",
+		"\t\t}",
+		"\t}")
+	for _, op := range ops {
+		if op.StreamParameter() != nil || op.StreamResult() {
+			// Streams are only between the host and the guest exporting them.
+			continue
+		}
+		params := []string{"&self"}
+		for _, p := range op.Parameters {
+			params = append(params, snakeCase(p.Name)+": "+g.rustType(p.Type, false))
+		}
+		result := "()"
+		if op.Returns != nil {
+			result = g.rustType(op.Returns, false)
+		}
+		out = append(out, "", "\tpub fn "+snakeCase(op.Name)+"("+strings.Join(params, ", ")+") -> HandlerResult<"+result+"> {")
+		payload := "&[]"
+		switch {
+		case op.Unary:
+			payload = "&serialize(&" + snakeCase(op.Parameters[0].Name) + ")?"
+		case len(op.Parameters) > 0:
+			out = append(out, "\t\tlet input_args = "+op.argsName()+" {")
+			for _, p := range op.Parameters {
+				out = append(out, "\t\t\t"+snakeCase(p.Name)+",")
+			}
+			out = append(out, "\t\t};")
+			payload = "&serialize(&input_args)?"
+		}
+		call := "host_call(&self.binding, " + strconv.Quote(namespace) + ", " + strconv.Quote(op.Name) + ", " + payload + ")?"
+		if op.Returns == nil {
+			out = append(out, "\t\t"+call+";", "\t\tOk(())", "\t}")
+			continue
+		}
+		out = append(out,
+			"\t\tlet payload = "+call+";",
+			"\t\tdeserialize(&payload)",
+			"\t}")
+	}
+	return append(out, "}", "")
+}
+
+func rustStatic(op operation) string {
+	return strings.ToUpper(snakeCase(op.Name))
+}
+
+func (g *generator) rustOps(ops []operation) []string {
+	out := []string{"pub struct Handlers {}", "", "impl Handlers {"}
+	for i, op := range ops {
+		if i > 0 {
+			out = append(out, "")
+		}
+		out = append(out,
+			"\tpub fn register_"+snakeCase(op.Name)+"(f: "+g.rustHandlerSig(op)+") {",
+			"\t\t*"+rustStatic(op)+".write().unwrap() = Some(f);",
+			"\t\tregister_function("+strconv.Quote(op.Name)+", "+snakeCase(op.Name)+"_wrapper);",
+			"\t}")
+	}
+	out = append(out, "}", "", "lazy_static! {")
+	for _, op := range ops {
+		out = append(out, "\tstatic ref "+rustStatic(op)+": RwLock<Option<"+g.rustHandlerSig(op)+">> = RwLock::new(None);")
+	}
+	out = append(out, "}", "")
+	for _, op := range ops {
+		out = append(out, g.rustWrapper(op)...)
+	}
+	for _, op := range ops {
+		if op.hasArgs() {
+			out = append(out, g.rustStruct(op.argsType(), nil)...)
+		}
+	}
+	return out
+}
+
+// rustWrapper returns the function that decodes the payload of `op`,
+// invokes its handler and encodes the response.
+func (g *generator) rustWrapper(op operation) []string {
+	payload := "input_payload"
+	if !op.Unary && len(op.args()) == 0 {
+		payload = "_input_payload"
+	}
+	out := []string{"fn " + snakeCase(op.Name) + "_wrapper(" + payload + ": &[u8]) -> CallResult {"}
+	var args []string
+	request := ""
+	switch {
+	case op.Unary:
+		p := op.Parameters[0]
+		out = append(out, "\tlet request: "+g.rustType(p.Type, false)+" = deserialize(input_payload)?;")
+		args, request = []string{"request"}, "request"
+	case len(op.args()) > 0:
+		out = append(out, "\tlet input: "+op.argsName()+" = deserialize(input_payload)?;")
+		for _, p := range op.Parameters {
+			if p.Type.Kind == widl.Stream {
+				args = append(args, "&mut "+snakeCase(p.Name))
+			} else {
+				args = append(args, "input."+snakeCase(p.Name))
+			}
+		}
+		request = "input"
+	default:
+		for _, p := range op.Parameters {
+			args = append(args, "&mut "+snakeCase(p.Name))
+		}
+	}
+	if g.checked(op) {
+		out = append(out, "\t"+request+".validate()?;")
+	}
+	out = append(out, "\tlet handler = "+rustStatic(op)+".read().unwrap().unwrap();")
+	if p := op.StreamParameter(); p != nil {
+		out = append(out, "\tlet mut "+snakeCase(p.Name)+" = "+p.Type.Elem.Name+"StreamReader::new(receive_frame, cancel_frames);")
+	}
+	call := "handler(" + strings.Join(args, ", ") + ")"
+	switch r := op.Returns; {
+	case op.StreamResult():
+		call = "handler(" + strings.Join(append(args, "&mut stream"), ", ") + ")"
+		out = append(out,
+			"\tlet mut stream = "+r.Elem.Name+"StreamWriter::new(send_frame);",
+			"\tif let Err(err) = "+call+" {",
+			"\t\t// Send the items queued before the error.",
+			"\t\tlet _ = stream.flush();",
+			"\t\treturn Err(err);",
+			"\t}",
+			"\tstream.close()?;",
+			"\tOk(Vec::new())")
+	default:
+		if p := op.StreamParameter(); p != nil {
+			out = append(out,
+				"\tlet result = "+call+";",
+				"\t// Stop the stream of the host if the handler returns before its end.",
+				"\tlet _ = "+snakeCase(p.Name)+".cancel();")
+			call = "result"
+		}
+		if r == nil {
+			out = append(out, "\t"+call+"?;", "\tOk(Vec::new())")
+		} else {
+			out = append(out, "\tlet response = "+call+"?;", "\tserialize(&response)")
+		}
+	}
+	return append(out, "}", "")
+}
+
+// rustPatterns returns the compiled @pattern constraints of an object.
+func (g *generator) rustPatterns(t *widl.Type) []string {
+	if !g.constrained[t.Name] {
+		return nil
+	}
+	var out []string
+	for _, f := range t.Fields {
+		if c := fieldConstraints(f); c.pattern != nil {
+			out = append(out, "\tstatic ref "+rustPatternVar(t.Name, f.Name)+": Regex = Regex::new("+rustRawString(*c.pattern)+").unwrap();")
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return append(append([]string{"lazy_static! {"}, out...), "}", "")
+}
+
+func rustPatternVar(object, field string) string {
+	return strings.ToUpper(snakeCase(object)+"_"+snakeCase(field)) + "_PATTERN"
+}
+
+// rustRawString returns a Rust raw string literal of s.
+func rustRawString(s string) string {
+	hashes := ""
+	for strings.Contains(s, `"`+hashes) {
+		hashes += "#"
+	}
+	return "r" + hashes + `"` + s + `"` + hashes
+}
+
+// rustPath is the path of a field in a ValidationError, as the format and
+// arguments of format!.
+type rustPath struct {
+	format string
+	args   []string
+}
+
+func (p rustPath) then(format string, args ...string) rustPath {
+	return rustPath{p.format + format, append(append([]string(nil), p.args...), args...)}
+}
+
+func (p rustPath) String() string {
+	return "format!(" + strconv.Quote(p.format) + ", " + strings.Join(p.args, ", ") + ")"
+}
+
+// rustCheckBounds returns the statements adding a violation of `b` by
+// `expr` to errs. Floats are compared with negated conditions so that NaN,
+// which compares false with everything, violates its bounds.
+func rustCheckBounds(path rustPath, expr string, b *bounds, length, float bool) []string {
+	prefix := ""
+	if length {
+		prefix = "length "
+	}
+	literal := func(n string) string {
+		if float {
+			return floatLiteral(n)
+		}
+		return n
+	}
+	var conds, messages []string
+	if b.min != nil {
+		if float {
+			conds = append(conds, "!("+expr+" >= "+literal(*b.min)+")")
+		} else {
+			conds = append(conds, expr+" < "+*b.min)
+		}
+		messages = append(messages, prefix+"must be at least "+*b.min)
+	}
+	if b.max != nil {
+		if float {
+			conds = append(conds, "!("+expr+" <= "+literal(*b.max)+")")
+		} else {
+			conds = append(conds, expr+" > "+*b.max)
+		}
+		messages = append(messages, prefix+"must be at most "+*b.max)
+	}
+	var out []string
+	for i, cond := range conds {
+		els := ""
+		if i > 0 {
+			els = "} else "
+		}
+		out = append(out, els+"if "+cond+" {", "\terrs.add("+path.String()+", \""+messages[i]+"\");")
+	}
+	return append(out, "}")
+}
+
+// rustCheckValue returns the statements adding the constraint violations of
+// `expr`, a reference to a value of type t at `path`, to errs.
+func (g *generator) rustCheckValue(object string, f *widl.Field, t *widl.TypeRef, c constraints, expr string, path rustPath, depth int) []string {
+	var out []string
+	name := t.Name
+	if g.isAlias(t) {
+		name = g.aliases[t.Name]
+	}
+	if c.length != nil {
+		length := receiver(expr) + ".len()"
+		if t.Kind == widl.Named && name == "string" {
+			length = receiver(expr) + ".chars().count()"
+		}
+		out = append(out, "let l = "+length+";")
+		out = append(out, rustCheckBounds(path, "l", c.length, true, false)...)
+	}
+	if c.rang != nil {
+		out = append(out, rustCheckBounds(path, deref(expr), c.rang, false, name == "f32" || name == "f64")...)
+	}
+	if c.pattern != nil {
+		out = append(out,
+			"if !"+rustPatternVar(object, f.Name)+".is_match("+expr+") {",
+			"\terrs.add("+path.String()+", "+quote("must match "+*c.pattern)+");",
+			"}")
+	}
+	if t.Kind == widl.List {
+		if needs, _ := g.needsCheck(t.Elem, constraints{}); needs {
+			s := suffix(depth)
+			i, v := "i"+s, "v"+s
+			code := g.rustCheckValue(object, f, nonOptional(t.Elem), constraints{}, v, path.then("[{}]", i), depth+1)
+			if t.Elem.Optional {
+				code = append(append([]string{"if let Some(" + v + ") = " + v + " {"}, indent(code, 1)...), "}")
+			}
+			out = append(out, "for ("+i+", "+v+") in "+receiver(expr)+".iter().enumerate() {")
+			out = append(out, indent(code, 1)...)
+			out = append(out, "}")
+		}
+	} else if g.isObject(t) && g.constrained[t.Name] {
+		out = append(out, receiver(expr)+".check(&"+path.then(".").String()+", errs);")
+	}
+	return out
+}
+
+func (g *generator) rustCheckField(object string, f *widl.Field) []string {
+	path := rustPath{"{}" + f.Name, []string{"path"}}
+	field := "&self." + snakeCase(f.Name)
+	if !f.Type.Optional {
+		return g.rustCheckValue(object, f, f.Type, fieldConstraints(f), field, path, 0)
+	}
+	code := g.rustCheckValue(object, f, nonOptional(f.Type), fieldConstraints(f), "v", path, 0)
+	if len(code) == 0 {
+		return nil
+	}
+	return append(append([]string{"if let Some(v) = " + field + " {"}, indent(code, 1)...), "}")
+}
+
+// rustCheckMethods returns the validate and check methods of an object with
+// constraints.
+func (g *generator) rustCheckMethods(t *widl.Type) []string {
+	if !g.constrained[t.Name] {
+		return nil
+	}
+	out := []string{
+		"",
+		"/// validate returns a ValidationError listing every field that violates",
+		"/// a constraint of the schema.",
+		"pub fn validate(&self) -> HandlerResult<()> {",
+		"\tlet mut errs = ValidationError::default();",
+		"\tself.check(\"\", &mut errs);",
+		"\tif errs.fields.is_empty() {",
+		"\t\treturn Ok(());",
+		"\t}",
+		"\tErr(Box::new(errs))",
+		"}",
+		"",
+		"fn check(&self, path: &str, errs: &mut ValidationError) {",
+	}
+	for _, f := range t.Fields {
+		out = append(out, indent(g.rustCheckField(t.Name, f), 1)...)
+	}
+	return append(out, "}")
+}
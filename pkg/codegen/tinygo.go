@@ -0,0 +1,684 @@
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/wapc/language-tests/pkg/widl"
+)
+
+// TinyGo generates the bindings of a TinyGo guest: the Handlers of the
+// operations of the interface, the Host and role clients calling the host,
+// and the types of the schema with their msgpack codecs.
+func TinyGo(doc *widl.Document, config Config) ([]byte, error) {
+	g, err := newGenerator(doc, config, false)
+	if err != nil {
+		return nil, err
+	}
+	return source(g.tinygoFile())
+}
+
+const tinygoHeader = `// RequireFields makes decoding fail with a *MissingFieldError when a
+// required field, one that is neither optional nor has a default, is missing
+// from a payload. It only adds that check: handlers return every other
+// decoding error to the host either way.
+var RequireFields bool
+`
+
+const tinygoHost = `
+type Host struct {
+	binding string
+}
+
+func NewHost(binding string) *Host {
+	return &Host{
+		binding: binding,
+	}
+}
+`
+
+func (g *generator) tinygoFile() []string {
+	ops := operations(g.doc.Interface())
+	var std []string
+	if g.uses.regexp {
+		std = append(std, "regexp")
+	}
+	if len(g.doc.Enums) > 0 || g.uses.strconv || len(streamTypes(ops)) > 0 {
+		std = append(std, "strconv")
+	}
+	times := g.usesTime()
+	if times {
+		std = append(std, "time")
+	}
+	if g.uses.utf8 {
+		std = append(std, "unicode/utf8")
+	}
+	out := []string{generatedComment, "", "package " + g.config.Package, "", "import ("}
+	for _, path := range std {
+		out = append(out, "\t"+strconv.Quote(path))
+	}
+	if len(std) > 0 {
+		out = append(out, "")
+	}
+	if times {
+		out = append(out, "\t\"github.com/wapc/language-tests/tinygo/ext\"")
+	}
+	for _, path := range g.mappedImports() {
+		out = append(out, "\t"+strconv.Quote(path))
+	}
+	out = append(out,
+		"\tmsgpack \"github.com/wapc/tinygo-msgpack\"",
+		"\twapc \"github.com/wapc/wapc-guest-tinygo\"",
+		")", "")
+	runtime := tinygoHeader + "\n" + execute("missing_field_error", nil) + "\n" + execute("nil_object_error", nil)
+	if len(g.constrained) > 0 {
+		runtime += "\n" + execute("validation_error", nil)
+	}
+	if len(g.doc.Unions) > 0 {
+		runtime += "\n" + execute("union_error", nil)
+	}
+	if len(g.recursive) > 0 {
+		runtime += "\n" + execute("depth_error", nil)
+	}
+	out = append(out, runtime+tinygoHost)
+	out = append(out, g.tinygoOps(ops, g.doc.Namespace.Name)...)
+	out = append(out, g.tinygoStreams(ops)...)
+	for _, role := range g.roles() {
+		out = append(out, g.tinygoRole(role)...)
+	}
+	for _, t := range g.doc.Types {
+		out = append(out, g.tinygoStruct(t, nil)...)
+	}
+	for _, u := range g.doc.Unions {
+		out = append(out, g.tinygoUnion(u)...)
+	}
+	for _, a := range g.doc.Aliases {
+		out = append(out, g.tinygoAlias(a)...)
+	}
+	for _, e := range g.doc.Enums {
+		out = append(out, g.tinygoEnum(e)...)
+	}
+	return out
+}
+
+// nullableDecoders are the decoding functions of an object.
+const nullableDecoders = `func Decode{{.}}Nullable(decoder *msgpack.Decoder) (*{{.}}, error) {
+	if isNil, err := decoder.IsNextNil(); isNil || err != nil {
+		return nil, err
+	}
+	decoded, err := Decode{{.}}(decoder)
+	return &decoded, err
+}
+
+func Decode{{.}}(decoder *msgpack.Decoder) ({{.}}, error) {
+	var o {{.}}
+	err := o.Decode(decoder)
+	return o, err
+}
+`
+
+const toBuffer = `func (o *{{.}}) ToBuffer() []byte {
+	var sizer msgpack.Sizer
+	o.Encode(&sizer)
+	buffer := make([]byte, sizer.Len())
+	encoder := msgpack.NewEncoder(buffer)
+	o.Encode(&encoder)
+	return buffer
+}
+`
+
+// readFieldCount returns the statements of a Decode method reading the size
+// of the map of `name`, which unlike a collection cannot be nil.
+func readFieldCount(name string) []string {
+	return []string{
+		"\tif isNil, err := decoder.IsNextNil(); err != nil {",
+		"\t\treturn err",
+		"\t} else if isNil {",
+		"\t\treturn &NilObjectError{" + strconv.Quote(name) + "}",
+		"\t}",
+		"\tnumFields, err := decoder.ReadMapSize()",
+		"\tif err != nil {",
+		"\t\treturn err",
+		"\t}",
+	}
+}
+
+// enterDecode returns the first statements of the Decode method of `name`,
+// which count the nesting of a recursive object against MaxDepth.
+func (g *generator) enterDecode(name string) []string {
+	if !g.recursive[name] {
+		return nil
+	}
+	return []string{
+		"\tif decodeDepth >= MaxDepth {",
+		"\t\treturn &DepthError{" + strconv.Quote(name) + "}",
+		"\t}",
+		"\tdecodeDepth++",
+		"\tdefer func() { decodeDepth-- }()",
+	}
+}
+
+func named(code, name string) []string {
+	return strings.Split(strings.ReplaceAll(code, "{{.}}", name), "\n")
+}
+
+// tinygoStruct returns the struct of an object, with its codec and its
+// Validate and Check methods. `comment` is its doc comment.
+func (g *generator) tinygoStruct(t *widl.Type, comment []string) []string {
+	out := append([]string(nil), comment...)
+	out = append(out, "type "+t.Name+" struct {")
+	for _, f := range t.Fields {
+		out = append(out, "\t"+goName(f.Name)+" "+g.goType(f.Type))
+	}
+	out = append(out, "}", "")
+	out = append(out, named(nullableDecoders, t.Name)...)
+	out = append(out, "func (o *"+t.Name+") Decode(decoder *msgpack.Decoder) error {")
+	out = append(out, g.enterDecode(t.Name)...)
+	for _, f := range t.Fields {
+		if f.Default != nil {
+			out = append(out, "\to."+goName(f.Name)+" = "+defaultValue(f))
+		}
+	}
+	out = append(out, readFieldCount(t.Name)...)
+	var requiredFields []string
+	for _, f := range t.Fields {
+		if required(f) {
+			requiredFields = append(requiredFields, strconv.Quote(f.Name))
+		}
+	}
+	if len(requiredFields) > 0 {
+		out = append(out, "\tvar present uint64")
+	}
+	out = append(out, "",
+		"\tfor numFields > 0 {",
+		"\t\tnumFields--",
+		"\t\tfield, err := decoder.ReadString()",
+		"\t\tif err != nil {",
+		"\t\t\treturn err",
+		"\t\t}",
+		"\t\tswitch field {")
+	bit := 0
+	for _, f := range t.Fields {
+		out = append(out, "\t\tcase "+strconv.Quote(f.Name)+":")
+		out = append(out, indent(g.decodeValue(f.Type, "o."+goName(f.Name), 0), 3)...)
+		if required(f) {
+			out = append(out, "\t\t\tpresent |= 1 << "+strconv.Itoa(bit))
+			bit++
+		}
+	}
+	out = append(out,
+		"\t\tdefault:",
+		"\t\t\terr = decoder.Skip()",
+		"\t\t}",
+		"\t\tif err != nil {",
+		"\t\t\treturn err",
+		"\t\t}",
+		"\t}",
+		"")
+	if len(requiredFields) > 0 {
+		out = append(out,
+			"\tif RequireFields {",
+			"\t\tfor i, field := range [...]string{"+strings.Join(requiredFields, ", ")+"} {",
+			"\t\t\tif present&(1<<uint(i)) == 0 {",
+			"\t\t\t\treturn &MissingFieldError{\""+t.Name+"\", field}",
+			"\t\t\t}",
+			"\t\t}",
+			"\t}")
+	}
+	out = append(out, "\treturn nil", "}", "")
+	out = append(out, g.validateMethod(t)...)
+	out = append(out, g.checkMethods(t)...)
+	out = append(out,
+		"func (o *"+t.Name+") Encode(encoder msgpack.Writer) error {",
+		"\tif o == nil {",
+		"\t\tencoder.WriteNil()",
+		"\t\treturn nil",
+		"\t}",
+		"\tencoder.WriteMapSize("+strconv.Itoa(len(t.Fields))+")")
+	for _, f := range t.Fields {
+		out = append(out, "\tencoder.WriteString("+strconv.Quote(f.Name)+")")
+		out = append(out, indent(g.encodeValue(f.Type, "o."+goName(f.Name), 0, false), 1)...)
+	}
+	out = append(out, "", "\treturn nil", "}", "")
+	return append(out, named(toBuffer, t.Name)...)
+}
+
+// tinygoUnion returns the struct of a union, with a pointer for each
+// variant, and its codec.
+func (g *generator) tinygoUnion(u *widl.Union) []string {
+	t := unionType(u)
+	out := doc(u.Description)
+	out = append(out, "type "+u.Name+" struct {")
+	for _, f := range t.Fields {
+		out = append(out, "\t"+f.Name+" *"+f.Name)
+	}
+	out = append(out, "}", "")
+	out = append(out, named(nullableDecoders, u.Name)...)
+	out = append(out,
+		"func (o *"+u.Name+") Decode(decoder *msgpack.Decoder) error {")
+	out = append(out, g.enterDecode(u.Name)...)
+	out = append(out, readFieldCount(u.Name)...)
+	out = append(out,
+		"",
+		"\tvar variants []string",
+		"\tfor numFields > 0 {",
+		"\t\tnumFields--",
+		"\t\tfield, err := decoder.ReadString()",
+		"\t\tif err != nil {",
+		"\t\t\treturn err",
+		"\t\t}",
+		"\t\tswitch field {")
+	for _, f := range t.Fields {
+		out = append(out,
+			"\t\tcase "+strconv.Quote(f.Name)+":",
+			"\t\t\to."+f.Name+", err = Decode"+f.Name+"Nullable(decoder)",
+			"\t\t\tif o."+f.Name+" != nil {",
+			"\t\t\t\tvariants = append(variants, field)",
+			"\t\t\t}")
+	}
+	out = append(out,
+		"\t\tdefault:",
+		"\t\t\terr = decoder.Skip()",
+		"\t\t\tvariants = append(variants, field)",
+		"\t\t}",
+		"\t\tif err != nil {",
+		"\t\t\treturn err",
+		"\t\t}",
+		"\t}",
+		"",
+		"\tif len(variants) != 1 || len(o.variants()) != 1 {",
+		"\t\treturn &UnionError{"+strconv.Quote(u.Name)+", variants}",
+		"\t}",
+		"\treturn nil",
+		"}",
+		"",
+		"// Encode writes an empty map, which no decoder accepts, when the union",
+		"// does not hold exactly one variant.",
+		"func (o *"+u.Name+") Encode(encoder msgpack.Writer) error {",
+		"\tif o == nil {",
+		"\t\tencoder.WriteNil()",
+		"\t\treturn nil",
+		"\t}",
+		"\tif variants := o.variants(); len(variants) != 1 {",
+		"\t\tencoder.WriteMapSize(0)",
+		"\t\treturn &UnionError{"+strconv.Quote(u.Name)+", variants}",
+		"\t}",
+		"\tencoder.WriteMapSize(1)",
+		"\tswitch {")
+	for _, f := range t.Fields {
+		out = append(out,
+			"\tcase o."+f.Name+" != nil:",
+			"\t\tencoder.WriteString("+strconv.Quote(f.Name)+")",
+			"\t\to."+f.Name+".Encode(encoder)")
+	}
+	out = append(out, "\t}", "", "\treturn nil", "}", "")
+	out = append(out, variantsMethod(t)...)
+	out = append(out, g.validateMethod(t)...)
+	return append(out, named(toBuffer, u.Name)...)
+}
+
+func (g *generator) tinygoAlias(a *widl.Alias) []string {
+	if _, ok := g.config.Types[a.Name]; ok {
+		return nil
+	}
+	p := prims[a.Type.Name]
+	out := doc(a.Description)
+	return append(out,
+		"type "+a.Name+" "+p[0],
+		"",
+		"func Decode"+a.Name+"(decoder *msgpack.Decoder) ("+a.Name+", error) {",
+		"\tv, err := decoder.Read"+p[1]+"()",
+		"\treturn "+a.Name+"(v), err",
+		"}",
+		"",
+		"func Encode"+a.Name+"(encoder msgpack.Writer, v "+a.Name+") {",
+		"\tencoder.Write"+p[1]+"("+p[0]+"(v))",
+		"}",
+		"")
+}
+
+// enum returns the declarations of an enum shared by the host and the
+// guests.
+func enum(e *widl.Enum) []string {
+	out := doc(e.Description)
+	out = append(out, "type "+e.Name+" int32", "", "const (")
+	var names []string
+	for _, v := range e.Values {
+		names = append(names, e.Name+goName(v.Name))
+		out = append(out, "\t"+e.Name+goName(v.Name)+" "+e.Name+" = "+strconv.Itoa(v.Index))
+	}
+	out = append(out, ")", "", "func (e "+e.Name+") String() string {", "\tswitch e {")
+	for _, v := range e.Values {
+		out = append(out, "\tcase "+e.Name+goName(v.Name)+":", "\t\treturn "+strconv.Quote(v.Name))
+	}
+	return append(out,
+		"\t}",
+		"\treturn \""+e.Name+"(\" + strconv.FormatInt(int64(e), 10) + \")\"",
+		"}",
+		"",
+		"// IsValid reports whether e is one of the declared values.",
+		"func (e "+e.Name+") IsValid() bool {",
+		"\tswitch e {",
+		"\tcase "+strings.Join(names, ", ")+":",
+		"\t\treturn true",
+		"\t}",
+		"\treturn false",
+		"}",
+		"")
+}
+
+func (g *generator) tinygoEnum(e *widl.Enum) []string {
+	return append(enum(e),
+		"func Decode"+e.Name+"(decoder *msgpack.Decoder) ("+e.Name+", error) {",
+		"\tv, err := decoder.ReadInt32()",
+		"\treturn "+e.Name+"(v), err",
+		"}",
+		"",
+		"func Encode"+e.Name+"(encoder msgpack.Writer, e "+e.Name+") {",
+		"\tencoder.WriteInt32(int32(e))",
+		"}",
+		"")
+}
+
+// zero returns the zero value of the Go type of t.
+func (g *generator) zero(t *widl.TypeRef) string {
+	switch {
+	case isPrim(t):
+		switch goType := g.goType(t); {
+		case t.Optional || strings.HasPrefix(goType, "[]"):
+			return "nil"
+		case goType == "bool":
+			return "false"
+		case goType == "string":
+			return `""`
+		}
+		return "0"
+	case t.Kind != widl.Named || t.Optional:
+		return "nil"
+	case g.isEnum(t):
+		return "0"
+	case g.isAlias(t):
+		if m, ok := g.config.Types[t.Name]; ok {
+			return m.Name + "{}"
+		}
+		return g.zero(&widl.TypeRef{Name: g.aliases[t.Name]})
+	case t.Name == "datetime":
+		return "time.Time{}"
+	}
+	return t.Name + "{}"
+}
+
+// isStruct reports whether t is a non-optional object, whose Go value has
+// the ToBuffer method.
+func (g *generator) isStruct(t *widl.TypeRef) bool {
+	return g.isObject(t) && !t.Optional
+}
+
+// handlerSig returns the signature of the handler of `op`.
+func (g *generator) handlerSig(op operation) string {
+	var params []string
+	for _, p := range op.Parameters {
+		params = append(params, p.Name+" "+g.goType(p.Type))
+	}
+	switch {
+	case op.StreamResult():
+		params = append(params, "stream *"+op.Returns.Elem.Name+"StreamWriter")
+		return "func(" + strings.Join(params, ", ") + ") error"
+	case op.Returns != nil:
+		return "func(" + strings.Join(params, ", ") + ") (" + g.goType(op.Returns) + ", error)"
+	}
+	return "func(" + strings.Join(params, ", ") + ") error"
+}
+
+// tinygoHostMethods returns the methods of `host` calling `ops` on the
+// host.
+func (g *generator) tinygoHostMethods(ops []operation, namespace, host string) []string {
+	var out []string
+	for _, op := range ops {
+		if op.StreamParameter() != nil || op.StreamResult() {
+			// Streams are only between the host and the guest exporting them.
+			continue
+		}
+		var params []string
+		for _, p := range op.Parameters {
+			params = append(params, p.Name+" "+g.goType(p.Type))
+		}
+		results := "error"
+		zero, assign := "", "_, err :="
+		if op.Returns != nil {
+			results = "(" + g.goType(op.Returns) + ", error)"
+			zero, assign = g.zero(op.Returns)+", ", "payload, err :="
+		}
+		out = append(out, "func (h *"+host+") "+op.goName()+"("+strings.Join(params, ", ")+") "+results+" {")
+		call := func(payload string) string {
+			return "\t" + assign + " wapc.HostCall(h.binding, " + strconv.Quote(namespace) + ", " + strconv.Quote(op.Name) + ", " + payload + ")"
+		}
+		switch {
+		case op.Unary:
+			p := op.Parameters[0]
+			if g.isStruct(p.Type) {
+				out = append(out, call(p.Name+".ToBuffer()"))
+			} else {
+				out = append(out, indent(g.sizeAndEncode(p.Type, p.Name, "inputPayload"), 1)...)
+				out = append(out, call("inputPayload"))
+			}
+		case len(op.Parameters) > 0:
+			out = append(out, "\tinputArgs := "+op.argsName()+"{")
+			for _, p := range op.Parameters {
+				out = append(out, "\t\t"+goName(p.Name)+": "+p.Name+",")
+			}
+			out = append(out, "\t}",
+				"\t"+assign+" wapc.HostCall(",
+				"\t\th.binding,",
+				"\t\t"+strconv.Quote(namespace)+",",
+				"\t\t"+strconv.Quote(op.Name)+",",
+				"\t\tinputArgs.ToBuffer(),",
+				"\t)")
+		default:
+			out = append(out, call("[]byte{}"))
+		}
+		r := op.Returns
+		switch {
+		case r == nil:
+			out = append(out, "\treturn err")
+		default:
+			out = append(out,
+				"\tif err != nil {",
+				"\t\treturn "+zero+"err",
+				"\t}",
+				"\tdecoder := msgpack.NewDecoder(payload)")
+			switch {
+			case g.isStruct(r):
+				out = append(out, "\treturn Decode"+r.Name+"(&decoder)")
+			case isPrim(r) && !r.Optional:
+				out = append(out, "\tret, err := decoder.Read"+prims[r.Name][1]+"()", "\treturn ret, err")
+			default:
+				out = append(out, "\tvar ret "+g.goType(r))
+				out = append(out, indent(returning(g.decodeLocal(r, "ret"), g.zero(r)), 1)...)
+				out = append(out, "\treturn ret, err")
+			}
+		}
+		out = append(out, "}", "")
+	}
+	return out
+}
+
+func (g *generator) tinygoRole(role *widl.Interface) []string {
+	host := role.Name + "Host"
+	out := []string{"// " + host + " calls the host operations of the " + role.Namespace() + " namespace."}
+	out = append(out, doc(role.Description)...)
+	out = append(out,
+		"type "+host+" struct {",
+		"\tbinding string",
+		"}",
+		"",
+		"func New"+host+"(binding string) *"+host+" {",
+		"\treturn &"+host+"{",
+		"\t\tbinding: binding,",
+		"\t}",
+		"}",
+		"")
+	ops := operations(role)
+	out = append(out, g.tinygoHostMethods(ops, role.Namespace(), host)...)
+	for _, op := range ops {
+		if op.hasArgs() {
+			out = append(out, g.tinygoStruct(op.argsType(), nil)...)
+		}
+	}
+	return out
+}
+
+func (g *generator) tinygoOps(ops []operation, namespace string) []string {
+	out := g.tinygoHostMethods(ops, namespace, "Host")
+	out = append(out, "type Handlers struct {")
+	for _, op := range ops {
+		out = append(out, "\t"+op.goName()+" "+g.handlerSig(op))
+	}
+	out = append(out, "}", "",
+		"// Functions returns the wrappers of the handlers that are set, by operation",
+		"// name. Register registers them; tests running the bindings natively call",
+		"// them directly.",
+		"func (h Handlers) Functions() wapc.Functions {",
+		"\tfunctions := wapc.Functions{}")
+	for _, op := range ops {
+		out = append(out,
+			"\tif h."+op.goName()+" != nil {",
+			"\t\t"+op.Name+"Handler = h."+op.goName(),
+			"\t\tfunctions["+strconv.Quote(op.Name)+"] = "+op.Name+"Wrapper",
+			"\t}")
+	}
+	out = append(out, "\treturn functions", "}", "",
+		"func (h Handlers) Register() {",
+		"\twapc.RegisterFunctions(h.Functions())",
+		"}", "", "var (")
+	for _, op := range ops {
+		out = append(out, "\t"+op.Name+"Handler "+g.handlerSig(op))
+	}
+	out = append(out, ")", "")
+	for _, op := range ops {
+		out = append(out, g.tinygoWrapper(op)...)
+	}
+	for _, op := range ops {
+		if op.hasArgs() {
+			out = append(out, g.tinygoStruct(op.argsType(), nil)...)
+		}
+	}
+	return out
+}
+
+// tinygoWrapper returns the function that decodes the payload of `op`,
+// invokes its handler and encodes the response.
+func (g *generator) tinygoWrapper(op operation) []string {
+	out := []string{"func " + op.Name + "Wrapper(payload []byte) ([]byte, error) {"}
+	var args []string
+	declared := false
+	switch {
+	case op.Unary:
+		p := op.Parameters[0]
+		out = append(out, "\tdecoder := msgpack.NewDecoder(payload)", "\tvar request "+g.goType(p.Type))
+		if g.isStruct(p.Type) {
+			out = append(out,
+				"\tif err := request.Decode(&decoder); err != nil {",
+				"\t\treturn nil, err",
+				"\t}")
+		} else {
+			out = append(out, "\tvar err error")
+			out = append(out, indent(returning(g.decodeLocal(p.Type, "request"), "nil"), 1)...)
+			out = append(out, "\tif err != nil {", "\t\treturn nil, err", "\t}")
+			declared = true
+		}
+		args = []string{"request"}
+	case len(op.args()) > 0:
+		out = append(out,
+			"\tdecoder := msgpack.NewDecoder(payload)",
+			"\tvar inputArgs "+op.argsName(),
+			"\tif err := inputArgs.Decode(&decoder); err != nil {",
+			"\t\treturn nil, err",
+			"\t}")
+		for _, p := range op.Parameters {
+			if p.Type.Kind == widl.Stream {
+				args = append(args, p.Name)
+			} else {
+				args = append(args, "inputArgs."+goName(p.Name))
+			}
+		}
+	default:
+		for _, p := range op.Parameters {
+			args = append(args, p.Name)
+		}
+	}
+	if g.checked(op) {
+		request := "inputArgs"
+		if op.Unary {
+			request = "request"
+		}
+		out = append(out,
+			"\tif err := "+request+".checkConstraints(); err != nil {",
+			"\t\treturn nil, err",
+			"\t}")
+	}
+	if p := op.StreamParameter(); p != nil {
+		out = append(out,
+			"\t"+p.Name+" := New"+p.Type.Elem.Name+"StreamReader(receiveFrame, cancelFrames)",
+			"\t// Stop the stream of the host if the handler returns before its end.",
+			"\tdefer "+p.Name+".Cancel()")
+	}
+	handler := op.Name + "Handler"
+	switch r := op.Returns; {
+	case op.StreamResult():
+		out = append(out,
+			"\tstream := New"+r.Elem.Name+"StreamWriter(sendFrame)",
+			"\tif err := "+handler+"("+strings.Join(append(args, "stream"), ", ")+"); err != nil {",
+			"\t\t// Send the items queued before the error.",
+			"\t\tstream.Flush()",
+			"\t\treturn nil, err",
+			"\t}",
+			"\treturn []byte{}, stream.Close()")
+	case r != nil:
+		out = append(out,
+			"\tresponse, err := "+handler+"("+strings.Join(args, ", ")+")",
+			"\tif err != nil {",
+			"\t\treturn nil, err",
+			"\t}")
+		if g.isStruct(r) {
+			out = append(out, "\treturn response.ToBuffer(), nil")
+		} else {
+			out = append(out, indent(g.sizeAndEncode(r, "response", "ua"), 1)...)
+			out = append(out, "", "\treturn ua, nil")
+		}
+	default:
+		assign := ":="
+		if declared {
+			assign = "="
+		}
+		out = append(out,
+			"\terr "+assign+" "+handler+"("+strings.Join(args, ", ")+")",
+			"\treturn []byte{}, err")
+	}
+	return append(out, "}", "")
+}
+
+// frameType returns the type of the frames of a stream of `name`.
+func frameType(name string) *widl.Type {
+	return &widl.Type{Name: name + "Frame", Fields: []*widl.Field{
+		{Name: "seq", Type: &widl.TypeRef{Name: "u32"}},
+		{Name: "items", Type: &widl.TypeRef{Kind: widl.List, Elem: &widl.TypeRef{Name: name}}},
+		{Name: "end", Type: &widl.TypeRef{Name: "bool"}},
+	}}
+}
+
+func (g *generator) tinygoStreams(ops []operation) []string {
+	names := streamTypes(ops)
+	if len(names) == 0 {
+		return nil
+	}
+	out := []string{execute("tinygo_streams", nil)}
+	for _, name := range names {
+		out = append(out, g.tinygoStruct(frameType(name), []string{
+			"// " + name + "Frame is a chunk of a stream of " + name + ". Frames are numbered from 0 and",
+			"// the last one has End set.",
+		})...)
+		out = append(out, execute("tinygo_stream", name))
+	}
+	return out
+}
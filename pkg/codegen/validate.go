@@ -0,0 +1,381 @@
+package codegen
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/wapc/language-tests/pkg/widl"
+)
+
+// constraints are the @length, @range and @pattern annotations of a field.
+// Bounds are Go expressions, nil for constraints without them.
+type constraints struct {
+	length, rang *bounds
+	pattern      *string
+}
+
+type bounds struct {
+	min, max *string
+}
+
+func fieldConstraints(f *widl.Field) constraints {
+	var c constraints
+	if a := widl.Find(f.Annotations, "pattern"); a != nil && len(a.Arguments) == 1 {
+		c.pattern = &a.Arguments[0].Value.Text
+	}
+	for _, name := range []string{"length", "range"} {
+		a := widl.Find(f.Annotations, name)
+		if a == nil {
+			continue
+		}
+		b := &bounds{}
+		if v := a.Argument("min"); v != nil {
+			b.min = &v.Text
+		}
+		if v := a.Argument("max"); v != nil {
+			b.max = &v.Text
+		}
+		if name == "length" {
+			c.length = b
+		} else {
+			c.rang = b
+		}
+	}
+	return c
+}
+
+func (c constraints) empty() bool {
+	return c.length == nil && c.rang == nil && c.pattern == nil
+}
+
+// objects returns the types of the schema and the structs of the arguments
+// of the operations of the interface.
+func (g *generator) objects() []*widl.Type {
+	types := append([]*widl.Type(nil), g.doc.Types...)
+	for _, op := range operations(g.doc.Interface()) {
+		if op.hasArgs() {
+			types = append(types, op.argsType())
+		}
+	}
+	return types
+}
+
+// findConstrained sets g.constrained and the packages the checks use.
+func (g *generator) findConstrained() error {
+	g.constrained = map[string]bool{}
+	for changed := true; changed; {
+		changed = false
+		for _, t := range g.objects() {
+			if g.constrained[t.Name] {
+				continue
+			}
+			for _, f := range t.Fields {
+				needs, err := g.needsCheck(f.Type, fieldConstraints(f))
+				if err != nil {
+					return err
+				}
+				if needs {
+					g.constrained[t.Name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	for _, t := range g.objects() {
+		for _, f := range t.Fields {
+			c := fieldConstraints(f)
+			if c.pattern != nil {
+				if err := checkPattern(*c.pattern); err != nil {
+					return errors.New("the pattern of " + t.Name + "." + f.Name + " " + err.Error())
+				}
+				g.uses.regexp = true
+			}
+			if c.length != nil && f.Type.Name == "string" {
+				g.uses.utf8 = true
+			}
+			if f.Type.Kind == widl.List {
+				if needs, _ := g.needsCheck(f.Type.Elem, constraints{}); needs {
+					g.uses.strconv = true
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// needsCheck reports whether values of type t with the constraints c can
+// violate constraints.
+func (g *generator) needsCheck(t *widl.TypeRef, c constraints) (bool, error) {
+	if !c.empty() {
+		return true, nil
+	}
+	switch t.Kind {
+	case widl.List:
+		return g.needsCheck(t.Elem, constraints{})
+	case widl.Map:
+		needs, err := g.needsCheck(t.Elem, constraints{})
+		if needs {
+			return false, errors.New("constraints in map values are not supported")
+		}
+		return false, err
+	}
+	return g.isObject(t) && g.constrained[t.Name], nil
+}
+
+// referencesObject reports whether t is an object or a collection of them.
+func (g *generator) referencesObject(t *widl.TypeRef) bool {
+	if t.Kind == widl.List || t.Kind == widl.Map {
+		return g.referencesObject(t.Elem)
+	}
+	return g.isObject(t)
+}
+
+// validateValue returns the statements checking the structure of the
+// objects held by `expr`.
+func (g *generator) validateValue(t *widl.TypeRef, expr string, depth int) []string {
+	if !g.referencesObject(t) {
+		return nil
+	}
+	if t.Kind == widl.Named {
+		if t.Optional {
+			return []string{
+				"if " + expr + " != nil {",
+				"\tif err := " + expr + ".checkStructure(); err != nil {",
+				"\t\treturn err",
+				"\t}",
+				"}",
+			}
+		}
+		return []string{"if err := " + expr + ".checkStructure(); err != nil {", "\treturn err", "}"}
+	}
+	v := "v" + suffix(depth)
+	out := []string{"for _, " + v + " := range " + expr + " {"}
+	out = append(out, indent(g.validateValue(t.Elem, v, depth+1), 1)...)
+	return append(out, "}")
+}
+
+// validateMethod returns the Validate method of an object, the single entry
+// point of its validation, and the checkStructure method that Validate
+// starts with.
+func (g *generator) validateMethod(t *widl.Type) []string {
+	out := []string{
+		"// Validate returns a *UnionError for the first union without exactly one",
+		"// variant.",
+	}
+	if g.constrained[t.Name] {
+		out = append(out,
+			"// Otherwise it returns a *ValidationError listing every field that violates",
+			"// a constraint of the schema.")
+	}
+	out = append(out, "// Nil collections and bytes are valid: every guest decodes them as empty.")
+	if g.constrained[t.Name] {
+		out = append(out,
+			"func (o *"+t.Name+") Validate() error {",
+			"\tif err := o.checkStructure(); err != nil {",
+			"\t\treturn err",
+			"\t}",
+			"\treturn o.checkConstraints()",
+			"}",
+			"")
+	} else {
+		out = append(out,
+			"func (o *"+t.Name+") Validate() error {",
+			"\treturn o.checkStructure()",
+			"}",
+			"")
+	}
+	out = append(out, "func (o *"+t.Name+") checkStructure() error {")
+	if g.isUnion(t.Name) {
+		out = append(out,
+			"\tif variants := o.variants(); len(variants) != 1 {",
+			"\t\treturn &UnionError{"+strconv.Quote(t.Name)+", variants}",
+			"\t}")
+	}
+	for _, f := range t.Fields {
+		out = append(out, indent(g.validateValue(f.Type, "o."+goName(f.Name), 0), 1)...)
+	}
+	return append(out, "\treturn nil", "}", "")
+}
+
+// variantsMethod returns the method listing the variants that are set in a
+// union, `t` being its unionType.
+func variantsMethod(t *widl.Type) []string {
+	out := []string{
+		"func (o *" + t.Name + ") variants() []string {",
+		"\tvar variants []string",
+	}
+	for _, f := range t.Fields {
+		out = append(out,
+			"\tif o."+f.Name+" != nil {",
+			"\t\tvariants = append(variants, "+strconv.Quote(f.Name)+")",
+			"\t}")
+	}
+	return append(out, "\treturn variants", "}", "")
+}
+
+func patternVar(object, field string) string {
+	return unexport(object) + goName(field) + "Pattern"
+}
+
+// checkBounds returns the statements adding a violation of `b` by `expr`
+// to errs. Numbers are compared with negated conditions so that NaN, which
+// compares false with everything, violates its bounds.
+func checkBounds(path, expr string, b *bounds, length bool) []string {
+	prefix := ""
+	if length {
+		prefix = "length "
+	}
+	var conds, messages []string
+	if b.min != nil {
+		if length {
+			conds = append(conds, expr+" < "+*b.min)
+		} else {
+			conds = append(conds, "!("+expr+" >= "+*b.min+")")
+		}
+		messages = append(messages, prefix+"must be at least "+*b.min)
+	}
+	if b.max != nil {
+		if length {
+			conds = append(conds, expr+" > "+*b.max)
+		} else {
+			conds = append(conds, "!("+expr+" <= "+*b.max+")")
+		}
+		messages = append(messages, prefix+"must be at most "+*b.max)
+	}
+	var out []string
+	for i, cond := range conds {
+		els := ""
+		if i > 0 {
+			els = "} else "
+		}
+		out = append(out, els+"if "+cond+" {", "\terrs.add("+path+", \""+messages[i]+"\")")
+	}
+	return append(out, "}")
+}
+
+// checkValue returns the statements adding the constraint violations of
+// `expr`, a value of type t at `path`, to errs.
+func (g *generator) checkValue(object string, f *widl.Field, t *widl.TypeRef, c constraints, expr, path string, depth int) []string {
+	var out []string
+	if c.length != nil {
+		length := "len(" + expr + ")"
+		if t.Kind == widl.Named && t.Name == "string" {
+			length = "utf8.RuneCountInString(" + expr + ")"
+		}
+		code := checkBounds(path, "l", c.length, true)
+		code[0] = strings.Replace(code[0], "if ", "if l := "+length+"; ", 1)
+		out = append(out, code...)
+	}
+	if c.rang != nil {
+		out = append(out, checkBounds(path, expr, c.rang, false)...)
+	}
+	if c.pattern != nil {
+		message := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(*c.pattern)
+		out = append(out,
+			"if !"+patternVar(object, f.Name)+".MatchString("+expr+") {",
+			"\terrs.add("+path+", \"must match "+message+"\")",
+			"}")
+	}
+	if t.Kind == widl.List {
+		if needs, _ := g.needsCheck(t.Elem, constraints{}); needs {
+			i := "i" + suffix(depth)
+			item := expr + "[" + i + "]"
+			code := g.checkValue(object, f, t.Elem, constraints{}, item,
+				concat(concat(path, "[")+"+strconv.Itoa("+i+")", "]"), depth+1)
+			if t.Elem.Optional {
+				code = append(append([]string{"if " + item + " != nil {"}, indent(code, 1)...), "}")
+			}
+			out = append(out, "for "+i+" := range "+expr+" {")
+			out = append(out, indent(code, 1)...)
+			out = append(out, "}")
+		}
+	} else if g.isObject(t) && g.constrained[t.Name] {
+		out = append(out, expr+".check("+concat(path, ".")+", errs)")
+	}
+	return out
+}
+
+// concat appends a string literal to a Go string expression.
+func concat(expr, literal string) string {
+	if strings.HasSuffix(expr, `"`) {
+		return expr[:len(expr)-1] + literal + `"`
+	}
+	return expr + `+"` + literal + `"`
+}
+
+func (g *generator) checkField(object string, f *widl.Field) []string {
+	path := `path+"` + f.Name + `"`
+	c := fieldConstraints(f)
+	t := f.Type
+	field := "o." + goName(f.Name)
+	var code []string
+	switch {
+	case t.Optional && t.Kind == widl.Named && !g.isNilable(t):
+		expr := "*" + field
+		if g.isObject(t) {
+			expr = field
+		}
+		code = g.checkValue(object, f, nonOptional(t), c, expr, path, 0)
+	default:
+		code = g.checkValue(object, f, t, c, field, path, 0)
+		if !t.Optional {
+			return code
+		}
+	}
+	if len(code) == 0 {
+		return nil
+	}
+	return append(append([]string{"if " + field + " != nil {"}, indent(code, 1)...), "}")
+}
+
+// checkMethods returns the checkConstraints method of an object with
+// constraints.
+func (g *generator) checkMethods(t *widl.Type) []string {
+	if !g.constrained[t.Name] {
+		return nil
+	}
+	var out []string
+	for _, f := range t.Fields {
+		if c := fieldConstraints(f); c.pattern != nil {
+			out = append(out, "var "+patternVar(t.Name, f.Name)+" = regexp.MustCompile("+rawString(*c.pattern)+")", "")
+		}
+	}
+	out = append(out,
+		"// checkConstraints returns a *ValidationError listing every field that",
+		"// violates a constraint of the schema.",
+		"func (o *"+t.Name+") checkConstraints() error {",
+		"\tvar errs ValidationError",
+		"\to.check(\"\", &errs)",
+		"\tif len(errs.Fields) > 0 {",
+		"\t\treturn &errs",
+		"\t}",
+		"\treturn nil",
+		"}",
+		"",
+		"func (o *"+t.Name+") check(path string, errs *ValidationError) {")
+	for _, f := range t.Fields {
+		out = append(out, indent(g.checkField(t.Name, f), 1)...)
+	}
+	return append(out, "}", "")
+}
+
+// rawString returns a Go raw string literal of s, or an interpreted one if
+// s holds a backquote.
+func rawString(s string) string {
+	if strings.Contains(s, "`") {
+		return quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// checked reports whether the request of `op` has constraints to check
+// before its handler is invoked.
+func (g *generator) checked(op operation) bool {
+	if op.Unary {
+		t := op.Parameters[0].Type
+		return g.isObject(t) && !t.Optional && g.constrained[t.Name]
+	}
+	return op.hasArgs() && g.constrained[op.argsName()]
+}
@@ -0,0 +1,494 @@
+// Package drift compares generated bindings with the schema they were
+// generated from, so that a stale generated file fails the tests instead of
+// silently passing them.
+package drift
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wapc/language-tests/pkg/widl"
+)
+
+// Problem is a difference between the schema and a binding.
+type Problem struct {
+	Pos     widl.Pos
+	Binding string
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Pos.String() + ": " + p.Binding + ": " + p.Message
+}
+
+var primitives = map[string]reflect.Type{
+	"bool":     reflect.TypeOf(false),
+	"i8":       reflect.TypeOf(int8(0)),
+	"i16":      reflect.TypeOf(int16(0)),
+	"i32":      reflect.TypeOf(int32(0)),
+	"i64":      reflect.TypeOf(int64(0)),
+	"u8":       reflect.TypeOf(uint8(0)),
+	"u16":      reflect.TypeOf(uint16(0)),
+	"u32":      reflect.TypeOf(uint32(0)),
+	"u64":      reflect.TypeOf(uint64(0)),
+	"f32":      reflect.TypeOf(float32(0)),
+	"f64":      reflect.TypeOf(float64(0)),
+	"string":   reflect.TypeOf(""),
+	"bytes":    reflect.TypeOf([]byte(nil)),
+	"datetime": reflect.TypeOf(time.Time{}),
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Host describes the bindings in pkg/module.
+type Host struct {
+	// Module is a *Module, whose methods are the operations of the interface.
+	Module interface{}
+	// Router is a Router, whose fields are the roles. It may be nil for
+	// schemas without roles.
+	Router interface{}
+}
+
+// Check returns the differences between the host bindings and `doc`.
+func (h Host) Check(doc *widl.Document) []Problem {
+	module := reflect.TypeOf(h.Module)
+	c := newChecker(doc, "host", module, true)
+	if i := doc.Interface(); i != nil {
+		c.methods(i, module, "Module", true)
+	}
+	var router reflect.Type
+	if h.Router != nil {
+		router = reflect.TypeOf(h.Router)
+	}
+	c.router(router)
+	return c.done()
+}
+
+// TinyGo describes the bindings in tinygo/module.
+type TinyGo struct {
+	// Handlers is a Handlers, whose fields are the operations of the
+	// interface.
+	Handlers interface{}
+	// Host is a *Host, the client for the operations of the interface.
+	Host interface{}
+	// Roles holds the clients of the roles, such as a *StorageHost, by role
+	// name.
+	Roles map[string]interface{}
+}
+
+// Check returns the differences between the TinyGo bindings and `doc`.
+func (g TinyGo) Check(doc *widl.Document) []Problem {
+	handlers := reflect.TypeOf(g.Handlers)
+	c := newChecker(doc, "tinygo", handlers, false)
+	if i := doc.Interface(); i != nil {
+		c.handlers(i, handlers)
+		// Streams are only between the host and the guest exporting them.
+		c.methods(i, reflect.TypeOf(g.Host), "Host", false)
+	}
+	for _, role := range doc.Interfaces {
+		if !role.Role {
+			continue
+		}
+		if client, ok := g.Roles[role.Name]; ok {
+			c.methods(role, reflect.TypeOf(client), role.Name+"Host", false)
+		} else {
+			c.report(role.Pos, "no client for role %s", role.Name)
+		}
+	}
+	return c.done()
+}
+
+type checker struct {
+	doc     *widl.Document
+	binding string
+	// pkgPath is the package of the generated types. Named types from other
+	// packages are user types that aliases are mapped to.
+	pkgPath  string
+	host     bool
+	checked  map[reflect.Type]bool
+	problems []Problem
+}
+
+func newChecker(doc *widl.Document, binding string, root reflect.Type, host bool) *checker {
+	for root.Kind() == reflect.Ptr {
+		root = root.Elem()
+	}
+	return &checker{
+		doc:     doc,
+		binding: binding,
+		pkgPath: root.PkgPath(),
+		host:    host,
+		checked: map[reflect.Type]bool{},
+	}
+}
+
+func (c *checker) report(pos widl.Pos, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{pos, c.binding, fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) done() []Problem {
+	for _, t := range c.doc.Types {
+		if !c.named(t.Name) {
+			c.report(t.Pos, "type %s is not used by any operation", t.Name)
+		}
+	}
+	sort.SliceStable(c.problems, func(i, j int) bool {
+		a, b := c.problems[i].Pos, c.problems[j].Pos
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return c.problems
+}
+
+// named reports whether the type `name` was reached from an operation.
+func (c *checker) named(name string) bool {
+	for typ := range c.checked {
+		if typ.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// router checks that the Router has a field per role, besides Fallback, and
+// that each role has a method per operation.
+func (c *checker) router(router reflect.Type) {
+	declared := map[string]bool{"Fallback": true}
+	for _, role := range c.doc.Interfaces {
+		if !role.Role {
+			continue
+		}
+		declared[role.Name] = true
+		if router == nil {
+			c.report(role.Pos, "no Router for role %s", role.Name)
+			continue
+		}
+		field, ok := router.FieldByName(role.Name)
+		if !ok {
+			c.report(role.Pos, "Router has no field for role %s", role.Name)
+			continue
+		}
+		c.methods(role, field.Type, role.Name, false)
+	}
+	if router == nil {
+		return
+	}
+	for i := 0; i < router.NumField(); i++ {
+		if name := router.Field(i).Name; !declared[name] {
+			c.report(widl.Pos{}, "Router has field %s for an undeclared role", name)
+		}
+	}
+}
+
+func goName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// methods checks that `typ` has a method per operation of `i`, and no other
+// methods. Streaming operations have no method unless `streams` is set, in
+// which case the other operations may also have Async and Batch methods.
+func (c *checker) methods(i *widl.Interface, typ reflect.Type, name string, streams bool) {
+	declared := map[string]bool{}
+	for _, op := range i.Operations {
+		stream := op.StreamResult() || op.StreamParameter() != nil
+		if !streams && stream {
+			continue
+		}
+		declared[goName(op.Name)] = true
+		if streams && !stream {
+			declared[goName(op.Name)+"Async"] = true
+			declared[goName(op.Name)+"Batch"] = true
+		}
+		method, ok := typ.MethodByName(goName(op.Name))
+		if !ok {
+			c.report(op.Pos, "%s has no method for operation %s", name, op.Name)
+			continue
+		}
+		in := 0
+		if typ.Kind() != reflect.Interface {
+			in = 1 // the receiver
+		}
+		c.signature(op, name+"."+method.Name, method.Type, in)
+	}
+	for j := 0; j < typ.NumMethod(); j++ {
+		if m := typ.Method(j).Name; !declared[m] {
+			c.report(i.Pos, "%s has method %s for an undeclared operation", name, m)
+		}
+	}
+}
+
+// handlers checks that the Handlers struct has a field per operation.
+func (c *checker) handlers(i *widl.Interface, typ reflect.Type) {
+	declared := map[string]bool{}
+	for _, op := range i.Operations {
+		declared[goName(op.Name)] = true
+		field, ok := typ.FieldByName(goName(op.Name))
+		if !ok {
+			c.report(op.Pos, "Handlers has no field for operation %s", op.Name)
+			continue
+		}
+		if field.Type.Kind() != reflect.Func {
+			c.report(op.Pos, "Handlers.%s is %s, not a function", field.Name, field.Type)
+			continue
+		}
+		c.signature(op, "Handlers."+field.Name, field.Type, 0)
+	}
+	for j := 0; j < typ.NumField(); j++ {
+		if f := typ.Field(j).Name; !declared[f] {
+			c.report(i.Pos, "Handlers has field %s for an undeclared operation", f)
+		}
+	}
+}
+
+// signature checks the parameters and results of the function `fn` for
+// `op`. Its first `in` parameters are skipped, and host functions take a
+// context. Host functions return a stream instead of a result and an error,
+// and TinyGo handlers take a stream writer after the parameters instead.
+func (c *checker) signature(op *widl.Operation, name string, fn reflect.Type, in int) {
+	if c.host {
+		if fn.NumIn() <= in || fn.In(in) != contextType {
+			c.report(op.Pos, "%s does not take a context", name)
+			return
+		}
+		in++
+	}
+	params := len(op.Parameters)
+	if op.StreamResult() && !c.host {
+		params++
+	}
+	if fn.NumIn()-in != params {
+		c.report(op.Pos, "%s takes %d parameters, the schema declares %d", name, fn.NumIn()-in, params)
+		return
+	}
+	for j, p := range op.Parameters {
+		c.typeRef(p.Type, fn.In(in+j), name+" parameter "+p.Name)
+	}
+	if op.StreamResult() {
+		c.streamResult(op, name, fn, in+len(op.Parameters))
+		return
+	}
+	results := 1
+	if op.Returns != nil {
+		results = 2
+	}
+	if fn.NumOut() != results || fn.Out(results-1) != errorType {
+		c.report(op.Pos, "%s returns %d results, want %d ending with an error", name, fn.NumOut(), results)
+		return
+	}
+	if op.Returns != nil {
+		c.typeRef(op.Returns, fn.Out(0), name+" result")
+	}
+}
+
+// streamResult checks the stream returned by the host function `fn`, or the
+// writer that the TinyGo handler `fn` takes as its parameter `writer`.
+func (c *checker) streamResult(op *widl.Operation, name string, fn reflect.Type, writer int) {
+	if c.host {
+		if fn.NumOut() != 1 {
+			c.report(op.Pos, "%s returns %d results, want a stream", name, fn.NumOut())
+			return
+		}
+		c.typeRef(op.Returns, fn.Out(0), name+" result")
+		return
+	}
+	c.stream(op.Returns, fn.In(writer), name+" stream", "Send")
+	if fn.NumOut() != 1 || fn.Out(0) != errorType {
+		c.report(op.Pos, "%s returns %d results, want an error", name, fn.NumOut())
+	}
+}
+
+// stream checks that `typ`, the type of `what`, streams the items of `ref`:
+// readers have an Item method returning an item and writers a Send method
+// taking one, named by `method`.
+func (c *checker) stream(ref *widl.TypeRef, typ reflect.Type, what, method string) {
+	m, ok := typ.MethodByName(method)
+	if !ok {
+		c.report(ref.Pos, "%s is %s, the schema declares %s", what, typ, ref)
+		return
+	}
+	in := 0
+	if typ.Kind() != reflect.Interface {
+		in = 1 // the receiver
+	}
+	switch {
+	case method == "Item" && m.Type.NumOut() == 1:
+		c.typeRef(ref.Elem, m.Type.Out(0), what+" item")
+	case method == "Send" && m.Type.NumIn() == in+1:
+		c.typeRef(ref.Elem, m.Type.In(in), what+" item")
+	default:
+		c.report(ref.Pos, "%s.%s is %s", what, method, m.Type)
+	}
+}
+
+func nilable(t reflect.Type) bool {
+	k := t.Kind()
+	return k == reflect.Slice || k == reflect.Map || k == reflect.Ptr
+}
+
+// typeRef checks that `typ`, the type of `what`, is the Go type generated
+// for `ref`.
+func (c *checker) typeRef(ref *widl.TypeRef, typ reflect.Type, what string) {
+	mistyped := func() {
+		c.report(ref.Pos, "%s is %s, the schema declares %s", what, typ, ref)
+	}
+	if ref.Kind == widl.Stream {
+		c.stream(ref, typ, what, "Item")
+		return
+	}
+	if ref.Optional && !nilable(typ) {
+		mistyped()
+		return
+	}
+	if ref.Optional && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch ref.Kind {
+	case widl.List:
+		if typ.Kind() != reflect.Slice {
+			mistyped()
+			return
+		}
+		c.typeRef(ref.Elem, typ.Elem(), what+" element")
+		return
+	case widl.Map:
+		if typ.Kind() != reflect.Map {
+			mistyped()
+			return
+		}
+		c.typeRef(ref.Key, typ.Key(), what+" key")
+		c.typeRef(ref.Elem, typ.Elem(), what+" value")
+		return
+	}
+	if primitive, ok := primitives[ref.Name]; ok {
+		if typ != primitive {
+			mistyped()
+		}
+		return
+	}
+	if typ.Name() != ref.Name {
+		mistyped()
+		return
+	}
+	c.declaration(ref, typ)
+}
+
+// declaration checks the declaration of the named type `typ` once.
+func (c *checker) declaration(ref *widl.TypeRef, typ reflect.Type) {
+	if c.checked[typ] {
+		return
+	}
+	c.checked[typ] = true
+	name := typ.Name()
+	if t := c.doc.Type(name); t != nil {
+		c.fields(t.Pos, t.Name, t.Fields, typ, "")
+		if !c.host && typ.Kind() == reflect.Struct {
+			c.wire(t.Pos, t.Name, t.Fields, typ, false)
+		}
+		return
+	}
+	for _, u := range c.doc.Unions {
+		if u.Name == name {
+			var fields []*widl.Field
+			for _, m := range u.Members {
+				optional := *m
+				optional.Optional = true
+				fields = append(fields, &widl.Field{Pos: m.Pos, Name: m.Name, Type: &optional})
+			}
+			c.fields(u.Pos, u.Name, fields, typ, ",omitempty")
+			if !c.host && typ.Kind() == reflect.Struct {
+				c.wire(u.Pos, u.Name, fields, typ, true)
+			}
+			return
+		}
+	}
+	for _, e := range c.doc.Enums {
+		if e.Name == name {
+			c.enum(e, typ)
+			return
+		}
+	}
+	for _, a := range c.doc.Aliases {
+		if a.Name == name {
+			// Aliases mapped to a user type have their own representation.
+			if typ.PkgPath() != c.pkgPath {
+				return
+			}
+			if primitive, ok := primitives[a.Type.Name]; !ok || typ.Kind() != primitive.Kind() || !typ.ConvertibleTo(primitive) {
+				c.report(a.Pos, "alias %s is %s, the schema declares %s", name, typ.Kind(), a.Type)
+			}
+			return
+		}
+	}
+	c.report(ref.Pos, "type %s is not declared", name)
+}
+
+// fields checks that the struct `typ` has a field per declared field, in the
+// same order. Host fields are matched by their MsgPack key, with `options`,
+// and TinyGo fields by name.
+func (c *checker) fields(pos widl.Pos, name string, fields []*widl.Field, typ reflect.Type, options string) {
+	if typ.Kind() != reflect.Struct {
+		c.report(pos, "%s is %s, not a struct", name, typ.Kind())
+		return
+	}
+	index := map[string]int{}
+	for i := 0; i < typ.NumField(); i++ {
+		index[c.key(typ.Field(i))] = i
+	}
+	declared := map[string]bool{}
+	last := -1
+	for _, f := range fields {
+		key := f.Name + options
+		if !c.host {
+			key = goName(f.Name)
+		}
+		declared[key] = true
+		i, ok := index[key]
+		if !ok {
+			c.report(f.Pos, "%s has no field %s", name, f.Name)
+			continue
+		}
+		if i < last {
+			c.report(f.Pos, "%s.%s is out of order", name, f.Name)
+		}
+		last = i
+		c.typeRef(f.Type, typ.Field(i).Type, name+"."+f.Name)
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if key := c.key(typ.Field(i)); !declared[key] {
+			c.report(pos, "%s has field %s, which the schema does not declare", name, typ.Field(i).Name)
+		}
+	}
+}
+
+// key returns the MsgPack key of a host field and the name of a TinyGo field.
+func (c *checker) key(field reflect.StructField) string {
+	if c.host {
+		return field.Tag.Get("msgpack")
+	}
+	return field.Name
+}
+
+// enum checks that each value of `e` has its name.
+func (c *checker) enum(e *widl.Enum, typ reflect.Type) {
+	if typ.Kind() != reflect.Int32 {
+		c.report(e.Pos, "enum %s is %s, not int32", e.Name, typ.Kind())
+		return
+	}
+	if !typ.Implements(reflect.TypeOf((*fmt.Stringer)(nil)).Elem()) {
+		c.report(e.Pos, "enum %s has no String method", e.Name)
+		return
+	}
+	for _, v := range e.Values {
+		value := reflect.New(typ).Elem()
+		value.SetInt(int64(v.Index))
+		if s := value.Interface().(fmt.Stringer).String(); s != v.Name {
+			c.report(v.Pos, "%s(%d) is named %s, the schema declares %s", e.Name, v.Index, s, v.Name)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package drift_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	msgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/drift"
+	"github.com/wapc/language-tests/pkg/widl"
+)
+
+const schema = `namespace "drift"
+
+interface {
+  echo{thing: Thing}: Thing
+  count(values: [string]): u32
+  added(): void
+}
+
+type Thing {
+  name: string
+  size: u32
+  tags: [string]
+  kind: Kind
+  parent: Thing?
+}
+
+enum Kind {
+  small = 0
+  large = 1
+}
+`
+
+// The stale bindings of the schema.
+
+type Thing struct {
+	Size   uint64 `msgpack:"size"`
+	Name   string `msgpack:"name"`
+	Kind   Kind   `msgpack:"kind"`
+	Extra  string `msgpack:"extra"`
+	Parent *Thing `msgpack:"parent"`
+}
+
+type Kind int32
+
+func (k Kind) String() string {
+	if k == 0 {
+		return "small"
+	}
+	return "big"
+}
+
+type staleModule struct{}
+
+func (m *staleModule) Echo(ctx context.Context, thing Thing) (Thing, error) {
+	return thing, nil
+}
+
+func (m *staleModule) Count(ctx context.Context, values string) (uint32, error) {
+	return 0, nil
+}
+
+func (m *staleModule) Removed(ctx context.Context) error {
+	return nil
+}
+
+type staleHandlers struct {
+	Echo    func(ctx context.Context, thing Thing) (Thing, error)
+	Count   func(values []string) uint32
+	Removed func() error
+}
+
+type staleHost struct{}
+
+func (h *staleHost) Echo(thing Thing) (Thing, error) {
+	return thing, nil
+}
+
+func TestDrift(t *testing.T) {
+	doc, err := widl.Parse([]byte(schema))
+	require.NoError(t, err)
+
+	var problems []string
+	for _, p := range (drift.Host{Module: &staleModule{}}).Check(doc) {
+		problems = append(problems, p.String())
+	}
+	assert.Equal(t, []string{
+		"3:1: host: Module has method Removed for an undeclared operation",
+		"5:17: host: Module.Count parameter values is string, the schema declares [string]",
+		"6:3: host: Module has no method for operation added",
+		"9:1: host: Thing has field Extra, which the schema does not declare",
+		"11:3: host: Thing.size is out of order",
+		"11:9: host: Thing.size is uint64, the schema declares u32",
+		"12:3: host: Thing has no field tags",
+		"19:3: host: Kind(1) is named big, the schema declares large",
+	}, problems)
+
+	problems = nil
+	for _, p := range (drift.TinyGo{Handlers: staleHandlers{}, Host: &staleHost{}}).Check(doc) {
+		problems = append(problems, p.String())
+	}
+	assert.Equal(t, []string{
+		"3:1: tinygo: Handlers has field Removed for an undeclared operation",
+		"4:3: tinygo: Handlers.Echo takes 2 parameters, the schema declares 1",
+		"5:3: tinygo: Handlers.Count returns 1 results, want 2 ending with an error",
+		"5:3: tinygo: Host has no method for operation count",
+		"6:3: tinygo: Handlers has no field for operation added",
+		"6:3: tinygo: Host has no method for operation added",
+		"9:1: tinygo: Thing has field Extra, which the schema does not declare",
+		"9:1: tinygo: Thing has no ToBuffer method",
+		"11:3: tinygo: Thing.size is out of order",
+		"11:9: tinygo: Thing.size is uint64, the schema declares u32",
+		"12:3: tinygo: Thing has no field tags",
+		"19:3: tinygo: Kind(1) is named big, the schema declares large",
+	}, problems)
+}
+
+const streamSchema = `namespace "drift"
+
+interface {
+  watch(prefix: string): stream Point
+  upload(points: stream Point): u32
+}
+
+type Point {
+  x: f64
+}
+`
+
+type Point struct {
+	X float64 `msgpack:"x"`
+}
+
+// ToBuffer encodes the point under the Go name of its field instead of the
+// name declared in the schema.
+func (p *Point) ToBuffer() []byte {
+	var sizer msgpack.Sizer
+	p.encode(&sizer)
+	buffer := make([]byte, sizer.Len())
+	encoder := msgpack.NewEncoder(buffer)
+	p.encode(&encoder)
+	return buffer
+}
+
+func (p *Point) encode(encoder msgpack.Writer) {
+	encoder.WriteMapSize(1)
+	encoder.WriteString("X")
+	encoder.WriteFloat64(p.X)
+}
+
+func (p *Point) Decode(decoder *msgpack.Decoder) error {
+	n, err := decoder.ReadMapSize()
+	for ; err == nil && n > 0; n-- {
+		if _, err = decoder.ReadString(); err == nil {
+			p.X, err = decoder.ReadFloat64()
+		}
+	}
+	return err
+}
+
+type pointReader struct{}
+
+func (r *pointReader) Item() Point {
+	return Point{}
+}
+
+type pointWriter struct{}
+
+func (w *pointWriter) Send(p Point) error {
+	return nil
+}
+
+type stringReader struct{}
+
+func (r *stringReader) Item() string {
+	return ""
+}
+
+type staleStreamModule struct{}
+
+func (m *staleStreamModule) Watch(ctx context.Context, prefix string) (*pointReader, error) {
+	return nil, nil
+}
+
+func (m *staleStreamModule) Upload(ctx context.Context, points []Point) (uint32, error) {
+	return 0, nil
+}
+
+type staleStreamHandlers struct {
+	Watch  func(prefix string, stream *pointWriter) error
+	Upload func(points *stringReader) (uint32, error)
+}
+
+func TestDriftStreams(t *testing.T) {
+	doc, err := widl.Parse([]byte(streamSchema))
+	require.NoError(t, err)
+
+	var problems []string
+	for _, p := range (drift.Host{Module: &staleStreamModule{}}).Check(doc) {
+		problems = append(problems, p.String())
+	}
+	assert.Equal(t, []string{
+		"4:3: host: Module.Watch returns 2 results, want a stream",
+		"5:18: host: Module.Upload parameter points is []drift_test.Point, the schema declares stream Point",
+		"8:1: host: type Point is not used by any operation",
+	}, problems)
+
+	problems = nil
+	for _, p := range (drift.TinyGo{Handlers: staleStreamHandlers{}, Host: &struct{}{}}).Check(doc) {
+		problems = append(problems, p.String())
+	}
+	assert.Equal(t, []string{
+		"5:25: tinygo: Handlers.Upload parameter points item is string, the schema declares Point",
+		"8:1: tinygo: Point encodes key \"X\", which the schema does not declare",
+		"9:3: tinygo: Point does not encode key \"x\"",
+	}, problems)
+}
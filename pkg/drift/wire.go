@@ -0,0 +1,177 @@
+package drift
+
+import (
+	"bytes"
+	"reflect"
+	"time"
+
+	vmsgpack "github.com/vmihailenco/msgpack/v4"
+	msgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/widl"
+)
+
+var (
+	decoderType = reflect.TypeOf(&msgpack.Decoder{})
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// wire encodes a populated value of the TinyGo struct `typ`, checks that its
+// MsgPack keys are the declared `fields` and that decoding it back gives the
+// same encoding. Unions only encode the variant that is set.
+func (c *checker) wire(pos widl.Pos, name string, fields []*widl.Field, typ reflect.Type, union bool) {
+	ptr := reflect.PtrTo(typ)
+	toBuffer, ok := ptr.MethodByName("ToBuffer")
+	if !ok || toBuffer.Type.NumIn() != 1 || toBuffer.Type.NumOut() != 1 || toBuffer.Type.Out(0) != reflect.TypeOf([]byte(nil)) {
+		c.report(pos, "%s has no ToBuffer method", name)
+		return
+	}
+	decode, ok := ptr.MethodByName("Decode")
+	if !ok || decode.Type.NumIn() != 2 || decode.Type.In(1) != decoderType || decode.Type.NumOut() != 1 || decode.Type.Out(0) != errorType {
+		c.report(pos, "%s has no Decode method", name)
+		return
+	}
+
+	value := reflect.New(typ)
+	populate(value.Elem(), map[reflect.Type]bool{}, c.isUnion)
+	encoded := toBuffer.Func.Call([]reflect.Value{value})[0].Bytes()
+	keys, err := mapKeys(encoded)
+	if err != nil {
+		c.report(pos, "%s does not encode a map: %v", name, err)
+		return
+	}
+
+	declared := map[string]bool{}
+	for _, f := range fields {
+		declared[f.Name] = true
+	}
+	encodedKeys := map[string]bool{}
+	for _, key := range keys {
+		encodedKeys[key] = true
+		if !declared[key] {
+			c.report(pos, "%s encodes key %q, which the schema does not declare", name, key)
+		}
+	}
+	if union {
+		if len(keys) != 1 {
+			c.report(pos, "%s encodes %d variants, want 1", name, len(keys))
+		}
+	} else {
+		for _, f := range fields {
+			if !encodedKeys[f.Name] {
+				c.report(f.Pos, "%s does not encode key %q", name, f.Name)
+			}
+		}
+	}
+
+	decoded := reflect.New(typ)
+	decoder := msgpack.NewDecoder(encoded)
+	if err, _ := decode.Func.Call([]reflect.Value{decoded, reflect.ValueOf(&decoder)})[0].Interface().(error); err != nil {
+		c.report(pos, "%s does not decode its own encoding: %v", name, err)
+		return
+	}
+	if again := toBuffer.Func.Call([]reflect.Value{decoded})[0].Bytes(); !bytes.Equal(again, encoded) {
+		c.report(pos, "%s does not decode what it encodes", name)
+	}
+}
+
+func (c *checker) isUnion(typ reflect.Type) bool {
+	if typ.PkgPath() != c.pkgPath {
+		return false
+	}
+	for _, u := range c.doc.Unions {
+		if u.Name == typ.Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// mapKeys returns the keys of the MsgPack map `data`, in order.
+func mapKeys(data []byte) ([]string, error) {
+	decoder := vmsgpack.NewDecoder(bytes.NewReader(data))
+	n, err := decoder.DecodeMapLen()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		key, err := decoder.DecodeString()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		if err := decoder.Skip(); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// populate sets every field of `v` to a value other than its zero value,
+// and only the first field of unions. Pointers, slices and maps of a struct
+// type being populated are left empty, to end recursive types.
+func populate(v reflect.Value, active map[reflect.Type]bool, isUnion func(reflect.Type) bool) {
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.String:
+		v.SetString("a")
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			populate(v.Index(i), active, isUnion)
+		}
+	case reflect.Ptr:
+		if recursive(v.Type().Elem(), active) {
+			return
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		populate(v.Elem(), active, isUnion)
+	case reflect.Slice:
+		if recursive(v.Type().Elem(), active) {
+			return
+		}
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		populate(v.Index(0), active, isUnion)
+	case reflect.Map:
+		if recursive(v.Type().Elem(), active) {
+			return
+		}
+		key := reflect.New(v.Type().Key()).Elem()
+		elem := reflect.New(v.Type().Elem()).Elem()
+		populate(key, active, isUnion)
+		populate(elem, active, isUnion)
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(key, elem)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			v.Set(reflect.ValueOf(time.Unix(1, 500).UTC()))
+			return
+		}
+		active[v.Type()] = true
+		defer delete(active, v.Type())
+		fields := v.NumField()
+		if isUnion(v.Type()) {
+			fields = 1
+		}
+		for i := 0; i < fields; i++ {
+			if v.Field(i).CanSet() {
+				populate(v.Field(i), active, isUnion)
+			}
+		}
+	}
+}
+
+// recursive reports whether `typ` leads to a struct that is being populated.
+func recursive(typ reflect.Type, active map[reflect.Type]bool) bool {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+		typ = typ.Elem()
+	}
+	return active[typ]
+}
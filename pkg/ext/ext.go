@@ -0,0 +1,36 @@
+// Package ext registers Go types as MsgPack extension types on the host. The
+// guest side is github.com/wapc/language-tests/tinygo/ext.
+package ext
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+// Extension is a value encoded as a MsgPack extension. MarshalMsgpack
+// returns the extension data without the header and UnmarshalMsgpack is
+// given the data only.
+type Extension interface {
+	msgpack.Marshaler
+	msgpack.Unmarshaler
+}
+
+// Register makes values of the type of `value` encode and decode as
+// extension type `typ`. Negative types are reserved by the MsgPack
+// specification; the timestamp type, -1, is registered for time.Time by the
+// msgpack package.
+func Register(typ int8, value Extension) (err error) {
+	if typ < 0 {
+		return errors.New("ext: extension types below 0 are reserved")
+	}
+	// RegisterExt panics when the type is already registered.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("ext: %v", r)
+		}
+	}()
+	msgpack.RegisterExt(typ, value)
+	return nil
+}
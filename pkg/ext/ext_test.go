@@ -0,0 +1,152 @@
+package ext_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	tinygomsgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/ext"
+	guest "github.com/wapc/language-tests/tinygo/ext"
+)
+
+const pointType = 7
+
+// point is encoded as extension 7 holding two big endian int16s.
+type point struct {
+	X, Y int16
+}
+
+func (p *point) MarshalMsgpack() ([]byte, error) {
+	return p.marshal(), nil
+}
+
+func (p *point) UnmarshalMsgpack(data []byte) error {
+	return p.unmarshal(data)
+}
+
+func (p *point) marshal() []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint16(b, uint16(p.X))
+	binary.BigEndian.PutUint16(b[2:], uint16(p.Y))
+	return b
+}
+
+func (p *point) unmarshal(data []byte) error {
+	if len(data) != 4 {
+		return assert.AnError
+	}
+	p.X = int16(binary.BigEndian.Uint16(data))
+	p.Y = int16(binary.BigEndian.Uint16(data[2:]))
+	return nil
+}
+
+// guestPoint is point for the TinyGo extension registry.
+type guestPoint struct {
+	point
+}
+
+func (p *guestPoint) ExtensionType() int8 {
+	return pointType
+}
+
+func (p *guestPoint) MarshalExtension() []byte {
+	return p.marshal()
+}
+
+func (p *guestPoint) UnmarshalExtension(data []byte) error {
+	return p.unmarshal(data)
+}
+
+func TestRegister(t *testing.T) {
+	require.NoError(t, ext.Register(pointType, &point{}))
+	require.NoError(t, guest.Register(pointType, func() guest.Extension { return &guestPoint{} }))
+
+	assert.Error(t, ext.Register(pointType, &point{}), "duplicate type was registered")
+	assert.Error(t, ext.Register(-2, &point{}), "reserved type was registered")
+	assert.Error(t, guest.Register(pointType, func() guest.Extension { return &guestPoint{} }), "duplicate type was registered")
+	assert.Error(t, guest.Register(-2, func() guest.Extension { return &guestPoint{} }), "reserved type was registered")
+
+	expected := point{X: -1, Y: 300}
+	encoded, err := msgpack.Marshal(&expected)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xd6, pointType, 0xff, 0xff, 0x01, 0x2c}, encoded)
+
+	decoder := tinygomsgpack.NewDecoder(encoded)
+	decoded, err := guest.Decode(&decoder)
+	require.NoError(t, err)
+	require.IsType(t, &guestPoint{}, decoded)
+	assert.Equal(t, expected, decoded.(*guestPoint).point)
+
+	var sizer tinygomsgpack.Sizer
+	guest.Write(&sizer, decoded)
+	buffer := make([]byte, sizer.Len())
+	encoder := tinygomsgpack.NewEncoder(buffer)
+	guest.Write(&encoder, decoded)
+	assert.Equal(t, encoded, buffer)
+
+	var actual point
+	require.NoError(t, msgpack.Unmarshal(buffer, &actual))
+	assert.Equal(t, expected, actual)
+}
+
+// extFormatSizes are data sizes covering every extension format.
+var extFormatSizes = []int{1, 2, 3, 4, 8, 16, 17, 255, 256, 65535, 65536}
+
+func extData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	return data
+}
+
+func TestReadExtFormats(t *testing.T) {
+	for _, size := range extFormatSizes {
+		data := extData(size)
+
+		var sizer tinygomsgpack.Sizer
+		sizer.WriteExt(3, data)
+		buffer := make([]byte, sizer.Len())
+		encoder := tinygomsgpack.NewEncoder(buffer)
+		encoder.WriteExt(3, data)
+
+		decoder := tinygomsgpack.NewDecoder(buffer)
+		typ, actual, err := decoder.ReadExt()
+		require.NoErrorf(t, err, "could not read %d bytes", size)
+		assert.Equal(t, int8(3), typ)
+		assert.Equalf(t, data, actual, "%d bytes changed", size)
+
+		hostDecoder := msgpack.NewDecoder(bytes.NewReader(buffer))
+		hostType, length, err := hostDecoder.DecodeExtHeader()
+		require.NoErrorf(t, err, "host could not read header of %d bytes", size)
+		assert.Equal(t, int8(3), hostType)
+		assert.Equal(t, size, length)
+	}
+
+	decoder := tinygomsgpack.NewDecoder([]byte{0xc0})
+	_, _, err := decoder.ReadExt()
+	assert.Error(t, err, "nil was read as an extension")
+}
+
+// TestSkipExtFormats checks that TinyGo skips an extension of every format,
+// as it does for unknown fields, and lands on the value that follows it.
+func TestSkipExtFormats(t *testing.T) {
+	for _, size := range extFormatSizes {
+		var buffer bytes.Buffer
+		encoder := msgpack.NewEncoder(&buffer)
+		require.NoError(t, encoder.EncodeExtHeader(3, size))
+		buffer.Write(extData(size))
+		require.NoError(t, encoder.EncodeString("next"))
+
+		decoder := tinygomsgpack.NewDecoder(buffer.Bytes())
+		require.NoErrorf(t, decoder.Skip(), "could not skip %d bytes", size)
+		next, err := decoder.ReadString()
+		require.NoErrorf(t, err, "skipping %d bytes left the decoder elsewhere", size)
+		assert.Equal(t, "next", next)
+	}
+}
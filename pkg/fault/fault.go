@@ -0,0 +1,113 @@
+// Package fault injects failures and malformed responses into the host call
+// path so tests can verify how guests handle a misbehaving host.
+package fault
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/wapc/wapc-go"
+)
+
+var errUnimplemented = errors.New("fault: no host call handler")
+
+// Fault rewrites the result of a host call before it is returned to the guest.
+type Fault func(response []byte, err error) ([]byte, error)
+
+// Error makes the host call fail with `err`.
+func Error(err error) Fault {
+	return func([]byte, error) ([]byte, error) {
+		return nil, err
+	}
+}
+
+// Truncate drops the second half of the response so the guest receives
+// incomplete MsgPack.
+func Truncate() Fault {
+	return func(response []byte, err error) ([]byte, error) {
+		if err != nil {
+			return response, err
+		}
+		return response[:len(response)/2], nil
+	}
+}
+
+// WrongType replaces the response with a MsgPack string, which is not the
+// type any operation returning an object expects.
+func WrongType() Fault {
+	return func([]byte, error) ([]byte, error) {
+		return []byte{0xa5, 'w', 'r', 'o', 'n', 'g'}, nil
+	}
+}
+
+// Oversize replaces the response with a MsgPack bin of `size` bytes.
+func Oversize(size int) Fault {
+	return func([]byte, error) ([]byte, error) {
+		response := make([]byte, 5+size)
+		response[0] = 0xc6 // bin 32
+		response[1] = byte(size >> 24)
+		response[2] = byte(size >> 16)
+		response[3] = byte(size >> 8)
+		response[4] = byte(size)
+		return response, nil
+	}
+}
+
+// Empty makes the host call succeed with a zero length response.
+func Empty() Fault {
+	return func([]byte, error) ([]byte, error) {
+		return []byte{}, nil
+	}
+}
+
+// Injector wraps a host call handler and applies faults per operation.
+type Injector struct {
+	mu     sync.RWMutex
+	next   wapc.HostCallHandler
+	faults map[string]Fault
+}
+
+// New creates an `Injector` that passes calls through to `next` until a
+// fault is injected. A nil `next` fails every call that has no fault.
+func New(next wapc.HostCallHandler) *Injector {
+	return &Injector{
+		next:   next,
+		faults: make(map[string]Fault),
+	}
+}
+
+// Inject applies `fault` to every subsequent call of `operation`.
+func (i *Injector) Inject(operation string, fault Fault) {
+	i.mu.Lock()
+	i.faults[operation] = fault
+	i.mu.Unlock()
+}
+
+// Clear removes the fault for `operation`.
+func (i *Injector) Clear(operation string) {
+	i.mu.Lock()
+	delete(i.faults, operation)
+	i.mu.Unlock()
+}
+
+// HostCall handles a host call from a guest. Its signature matches
+// `wapc.HostCallHandler` so it can be passed directly to `wapc.New`.
+func (i *Injector) HostCall(ctx context.Context, binding, namespace, operation string, payload []byte) ([]byte, error) {
+	i.mu.RLock()
+	fault := i.faults[operation]
+	i.mu.RUnlock()
+
+	var response []byte
+	var err error
+	if i.next != nil {
+		response, err = i.next(ctx, binding, namespace, operation, payload)
+	} else {
+		err = errUnimplemented
+	}
+	if fault != nil {
+		response, err = fault(response, err)
+	}
+
+	return response, err
+}
@@ -0,0 +1,76 @@
+package fault_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+
+	"github.com/wapc/language-tests/pkg/fault"
+)
+
+func echo(ctx context.Context, binding, namespace, operation string, payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+func TestPassThrough(t *testing.T) {
+	injector := fault.New(echo)
+	response, err := injector.HostCall(context.Background(), "", "tests", "testUnary", []byte{0x80})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x80}, response)
+
+	_, err = fault.New(nil).HostCall(context.Background(), "", "tests", "testUnary", nil)
+	assert.Error(t, err, "calls without a handler should fail")
+}
+
+func TestFaults(t *testing.T) {
+	ctx := context.Background()
+	payload, err := msgpack.Marshal(map[string]string{"value": "test"})
+	require.NoError(t, err)
+	hostErr := errors.New("host failure")
+
+	injector := fault.New(echo)
+
+	injector.Inject("testUnary", fault.Error(hostErr))
+	_, err = injector.HostCall(ctx, "", "tests", "testUnary", payload)
+	assert.Equal(t, hostErr, err)
+
+	injector.Inject("testUnary", fault.Truncate())
+	response, err := injector.HostCall(ctx, "", "tests", "testUnary", payload)
+	require.NoError(t, err)
+	assert.Equal(t, payload[:len(payload)/2], response)
+	var decoded map[string]string
+	assert.Error(t, msgpack.Unmarshal(response, &decoded), "truncated response should not decode")
+
+	injector.Inject("testUnary", fault.WrongType())
+	response, err = injector.HostCall(ctx, "", "tests", "testUnary", payload)
+	require.NoError(t, err)
+	var s string
+	require.NoError(t, msgpack.Unmarshal(response, &s))
+	assert.Equal(t, "wrong", s)
+
+	injector.Inject("testUnary", fault.Oversize(1<<20))
+	response, err = injector.HostCall(ctx, "", "tests", "testUnary", payload)
+	require.NoError(t, err)
+	var b []byte
+	require.NoError(t, msgpack.Unmarshal(response, &b))
+	assert.Len(t, b, 1<<20)
+
+	injector.Inject("testUnary", fault.Empty())
+	response, err = injector.HostCall(ctx, "", "tests", "testUnary", payload)
+	require.NoError(t, err)
+	assert.Empty(t, response)
+
+	// Faults only apply to the operation they were injected for.
+	response, err = injector.HostCall(ctx, "", "tests", "testDecode", payload)
+	require.NoError(t, err)
+	assert.Equal(t, payload, response)
+
+	injector.Clear("testUnary")
+	response, err = injector.HostCall(ctx, "", "tests", "testUnary", payload)
+	require.NoError(t, err)
+	assert.Equal(t, payload, response)
+}
@@ -0,0 +1,154 @@
+// Package manifest records how a guest was built, in a manifest beside its
+// Wasm file, so tests can tell when a committed guest is older than the
+// schema or the sources it was built from.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// File is a file and the SHA-256 of its contents. Paths are relative to the
+// root of the repository and use forward slashes.
+type File struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest is the provenance of a built guest. It holds no build time, so that
+// rebuilding a guest from the same files does not change its manifest.
+type Manifest struct {
+	Wasm    File   `json:"wasm"`
+	Schema  File   `json:"schema"`
+	Sources []File `json:"sources"`
+	// Toolchain maps tool names, such as "tinygo", to their versions.
+	Toolchain map[string]string `json:"toolchain,omitempty"`
+	// SDK maps the waPC guest library, such as "wapc-guest", to the version
+	// the guest was built with.
+	SDK map[string]string `json:"sdk,omitempty"`
+}
+
+// Path returns the path of the manifest of `wasmFile`, `tinygo.manifest.json`
+// for `tinygo.wasm`.
+func Path(wasmFile string) string {
+	return strings.TrimSuffix(wasmFile, ".wasm") + ".manifest.json"
+}
+
+// Hash returns the hex SHA-256 of the contents of `file`.
+func Hash(file string) (string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func hashFile(root, path string) (File, error) {
+	sum, err := Hash(filepath.Join(root, filepath.FromSlash(path)))
+	return File{filepath.ToSlash(path), sum}, err
+}
+
+// New hashes the guest `wasm`, the schema it was generated from and the
+// sources it was built from. Paths are relative to `root`.
+func New(root, wasm, schema string, sources []string, toolchain, sdk map[string]string) (*Manifest, error) {
+	m := &Manifest{Toolchain: toolchain, SDK: sdk}
+	var err error
+	if m.Wasm, err = hashFile(root, wasm); err != nil {
+		return nil, err
+	}
+	if m.Schema, err = hashFile(root, schema); err != nil {
+		return nil, err
+	}
+	for _, source := range sources {
+		f, err := hashFile(root, source)
+		if err != nil {
+			return nil, err
+		}
+		m.Sources = append(m.Sources, f)
+	}
+	sort.Slice(m.Sources, func(i, j int) bool { return m.Sources[i].Path < m.Sources[j].Path })
+	return m, nil
+}
+
+// Read reads the manifest of `wasmFile`. The error satisfies os.IsNotExist if
+// the guest has no manifest.
+func Read(wasmFile string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(Path(wasmFile))
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses a manifest written by Write.
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Write writes the manifest beside the guest, in `root`.
+func (m *Manifest) Write(root string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(Path(filepath.Join(root, filepath.FromSlash(m.Wasm.Path))), append(data, '\n'), 0644)
+}
+
+// StaleError is returned by Check for a guest whose files changed after it was
+// built.
+type StaleError struct {
+	Wasm string
+	// Changed lists the files whose hash differs from the manifest. The guest
+	// itself is listed if it was rebuilt without updating its manifest.
+	Changed []string
+}
+
+func (e *StaleError) Error() string {
+	return e.Wasm + " is stale: " + strings.Join(e.Changed, ", ") + " changed since it was built"
+}
+
+// ErrNoToolchain is returned by Check for a manifest that records no
+// toolchain. build.sh always records one, so such a manifest was not written
+// by a build.
+var ErrNoToolchain = errors.New("manifest records no toolchain")
+
+// Check reads the manifest of the guest `wasm`, relative to `root`, and
+// returns a *StaleError if the guest, its schema or its sources no longer
+// match it. Deleted files count as changed. It returns an error wrapping
+// ErrNoToolchain for a manifest that build.sh did not write.
+func Check(root, wasm string) error {
+	m, err := Read(filepath.Join(root, filepath.FromSlash(wasm)))
+	if err != nil {
+		return err
+	}
+	if len(m.Toolchain) == 0 {
+		return fmt.Errorf("%s: %w", filepath.ToSlash(wasm), ErrNoToolchain)
+	}
+	stale := &StaleError{Wasm: filepath.ToSlash(wasm)}
+	for _, f := range append([]File{m.Wasm, m.Schema}, m.Sources...) {
+		current, err := hashFile(root, f.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if current != f {
+			stale.Changed = append(stale.Changed, f.Path)
+		}
+	}
+	if len(stale.Changed) > 0 {
+		return stale
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package manifest_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wapc/language-tests/pkg/manifest"
+)
+
+func write(t *testing.T, root, path, contents string) {
+	file := filepath.Join(root, filepath.FromSlash(path))
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	require.NoError(t, ioutil.WriteFile(file, []byte(contents), 0644))
+}
+
+func TestManifest(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, "schema.widl", `namespace "tests"`)
+	write(t, root, "guest/main.go", "package main")
+	write(t, root, "guest/module/module.go", "package module")
+	write(t, root, "build/guest.wasm", "\x00asm")
+
+	_, err := manifest.Read(filepath.Join(root, "build", "guest.wasm"))
+	assert.True(t, os.IsNotExist(err), "%v", err)
+	assert.True(t, os.IsNotExist(manifest.Check(root, "build/guest.wasm")))
+
+	m, err := manifest.New(root, "build/guest.wasm", "schema.widl",
+		[]string{"guest/module/module.go", "guest/main.go"}, map[string]string{"tinygo": "0.17.0"},
+		map[string]string{"github.com/wapc/wapc-guest-tinygo": "v0.3.1"})
+	require.NoError(t, err)
+	require.NoError(t, m.Write(root))
+	written, err := ioutil.ReadFile(filepath.Join(root, "build", "guest.manifest.json"))
+	require.NoError(t, err)
+
+	// Rebuilding from the same files writes the same manifest.
+	again, err := manifest.New(root, "build/guest.wasm", "schema.widl",
+		[]string{"guest/main.go", "guest/module/module.go"}, map[string]string{"tinygo": "0.17.0"},
+		map[string]string{"github.com/wapc/wapc-guest-tinygo": "v0.3.1"})
+	require.NoError(t, err)
+	require.NoError(t, again.Write(root))
+	rewritten, err := ioutil.ReadFile(filepath.Join(root, "build", "guest.manifest.json"))
+	require.NoError(t, err)
+	assert.Equal(t, string(written), string(rewritten))
+
+	read, err := manifest.Read(filepath.Join(root, "build", "guest.wasm"))
+	require.NoError(t, err)
+	assert.Equal(t, m.Schema, read.Schema)
+	assert.Equal(t, []string{"guest/main.go", "guest/module/module.go"},
+		[]string{read.Sources[0].Path, read.Sources[1].Path})
+	assert.Equal(t, "0.17.0", read.Toolchain["tinygo"])
+	assert.Equal(t, "v0.3.1", read.SDK["github.com/wapc/wapc-guest-tinygo"])
+	sum, err := manifest.Hash(filepath.Join(root, "schema.widl"))
+	require.NoError(t, err)
+	assert.Equal(t, sum, read.Schema.SHA256)
+	assert.Len(t, sum, 64)
+	assert.NoError(t, manifest.Check(root, "build/guest.wasm"))
+
+	// A manifest written without a build records no toolchain.
+	handwritten, err := manifest.New(root, "build/guest.wasm", "schema.widl",
+		[]string{"guest/main.go", "guest/module/module.go"}, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, handwritten.Write(root))
+	err = manifest.Check(root, "build/guest.wasm")
+	assert.True(t, errors.Is(err, manifest.ErrNoToolchain), "%v", err)
+	assert.EqualError(t, err, "build/guest.wasm: manifest records no toolchain")
+	require.NoError(t, m.Write(root))
+
+	write(t, root, "schema.widl", `namespace "tests.v2"`)
+	require.NoError(t, os.Remove(filepath.Join(root, "guest", "main.go")))
+	err = manifest.Check(root, "build/guest.wasm")
+	var stale *manifest.StaleError
+	require.IsType(t, stale, err)
+	stale = err.(*manifest.StaleError)
+	assert.Equal(t, []string{"schema.widl", "guest/main.go"}, stale.Changed)
+	assert.EqualError(t, err, "build/guest.wasm is stale: schema.widl, guest/main.go changed since it was built")
+}
@@ -0,0 +1,223 @@
+// Package mock provides a scriptable waPC host for testing how guests make
+// and handle host calls.
+package mock
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Call is a single host call made by a guest.
+type Call struct {
+	Binding   string
+	Namespace string
+	Operation string
+	Payload   []byte
+}
+
+func (c Call) String() string {
+	return fmt.Sprintf("%s/%s/%s (%d bytes)", c.Binding, c.Namespace, c.Operation, len(c.Payload))
+}
+
+// Expectation is a host call the guest is expected to make along with the
+// canned response returned when it does. Its methods are safe to call while
+// the guest makes host calls.
+type Expectation struct {
+	// host guards the fields below with its lock.
+	host       *Host
+	call       Call
+	anyPayload bool
+	response   []byte
+	err        error
+	delay      time.Duration
+	times      int
+	calls      int
+}
+
+// Return sets the response payload returned to the guest.
+func (e *Expectation) Return(response []byte) *Expectation {
+	e.host.mu.Lock()
+	defer e.host.mu.Unlock()
+	e.response = response
+	e.err = nil
+	return e
+}
+
+// ReturnError makes the host call fail with `err`.
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.host.mu.Lock()
+	defer e.host.mu.Unlock()
+	e.response = nil
+	e.err = err
+	return e
+}
+
+// After delays the response by `delay`, or until the invocation context is done.
+func (e *Expectation) After(delay time.Duration) *Expectation {
+	e.host.mu.Lock()
+	defer e.host.mu.Unlock()
+	e.delay = delay
+	return e
+}
+
+// Times sets how many calls the expectation accepts. The default is one.
+func (e *Expectation) Times(times int) *Expectation {
+	e.host.mu.Lock()
+	defer e.host.mu.Unlock()
+	e.times = times
+	return e
+}
+
+// AnyPayload matches the expectation regardless of the payload sent.
+func (e *Expectation) AnyPayload() *Expectation {
+	e.host.mu.Lock()
+	defer e.host.mu.Unlock()
+	e.anyPayload = true
+	return e
+}
+
+// EmptyPayload matches the expectation only when the payload sent is empty.
+func (e *Expectation) EmptyPayload() *Expectation {
+	e.host.mu.Lock()
+	defer e.host.mu.Unlock()
+	e.call.Payload = []byte{}
+	e.anyPayload = false
+	return e
+}
+
+// matches is called with the lock of the host held.
+func (e *Expectation) matches(c Call) bool {
+	return e.calls < e.times &&
+		e.call.Binding == c.Binding &&
+		e.call.Namespace == c.Namespace &&
+		e.call.Operation == c.Operation &&
+		(e.anyPayload || bytes.Equal(e.call.Payload, c.Payload))
+}
+
+// Host is a scriptable host call handler. Expected calls are matched in the
+// order they were declared and any call without a matching expectation fails.
+type Host struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+	unexpected   []Call
+}
+
+// NewHost creates a `Host` with no expectations.
+func NewHost() *Host {
+	return &Host{}
+}
+
+// Expect declares a host call the guest is expected to make. A nil `payload`
+// is equivalent to calling `AnyPayload` on the returned expectation; call
+// `EmptyPayload` to expect a call without a payload.
+func (h *Host) Expect(binding, namespace, operation string, payload []byte) *Expectation {
+	e := &Expectation{
+		host: h,
+		call: Call{
+			Binding:   binding,
+			Namespace: namespace,
+			Operation: operation,
+			Payload:   payload,
+		},
+		anyPayload: payload == nil,
+		times:      1,
+	}
+	h.mu.Lock()
+	h.expectations = append(h.expectations, e)
+	h.mu.Unlock()
+	return e
+}
+
+// HostCall handles a host call from a guest. Its signature matches
+// `wapc.HostCallHandler` so it can be passed directly to `wapc.New`.
+func (h *Host) HostCall(ctx context.Context, binding, namespace, operation string, payload []byte) ([]byte, error) {
+	call := Call{
+		Binding:   binding,
+		Namespace: namespace,
+		Operation: operation,
+		Payload:   payload,
+	}
+
+	h.mu.Lock()
+	h.calls = append(h.calls, call)
+	var matched *Expectation
+	for _, e := range h.expectations {
+		if e.matches(call) {
+			matched = e
+			break
+		}
+	}
+	if matched == nil {
+		h.unexpected = append(h.unexpected, call)
+		h.mu.Unlock()
+		return nil, fmt.Errorf("mock: unexpected host call %s", call)
+	}
+	matched.calls++
+	response, err, delay := matched.response, matched.err, matched.delay
+	h.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return response, err
+}
+
+// Calls returns every host call received so far.
+func (h *Host) Calls() []Call {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	calls := make([]Call, len(h.calls))
+	copy(calls, h.calls)
+	return calls
+}
+
+// Verify returns an error describing any unexpected calls and any
+// expectations that were not fully met.
+func (h *Host) Verify() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var problems []string
+	for _, c := range h.unexpected {
+		problems = append(problems, "unexpected call "+c.String())
+	}
+	for _, e := range h.expectations {
+		if e.calls < e.times {
+			problems = append(problems, fmt.Sprintf("expected call %s/%s/%s %d time(s), got %d",
+				e.call.Binding, e.call.Namespace, e.call.Operation, e.times, e.calls))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("mock: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// TestingT is the subset of `testing.T` used by `AssertExpectations`.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertExpectations fails the test if `Verify` returns an error.
+func (h *Host) AssertExpectations(t TestingT) bool {
+	t.Helper()
+	if err := h.Verify(); err != nil {
+		t.Errorf("%v", err)
+		return false
+	}
+	return true
+}
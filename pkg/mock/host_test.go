@@ -0,0 +1,125 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wapc/language-tests/pkg/mock"
+)
+
+func TestExpectedCalls(t *testing.T) {
+	ctx := context.Background()
+	host := mock.NewHost()
+	host.Expect("", "tests", "testUnary", []byte{0x80}).Return([]byte("first"))
+	host.Expect("", "tests", "testUnary", nil).Return([]byte("second")).Times(2)
+
+	response, err := host.HostCall(ctx, "", "tests", "testUnary", []byte{0x80})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), response)
+
+	for i := 0; i < 2; i++ {
+		response, err = host.HostCall(ctx, "", "tests", "testUnary", []byte{0x80})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("second"), response)
+	}
+
+	assert.NoError(t, host.Verify())
+	assert.Len(t, host.Calls(), 3)
+}
+
+func TestUnexpectedCall(t *testing.T) {
+	ctx := context.Background()
+	host := mock.NewHost()
+	host.Expect("", "tests", "testUnary", nil).Return([]byte{0x80})
+
+	_, err := host.HostCall(ctx, "", "tests", "testDecode", nil)
+	assert.Error(t, err, "unexpected call should fail")
+	_, err = host.HostCall(ctx, "", "tests", "testUnary", nil)
+	assert.NoError(t, err)
+	_, err = host.HostCall(ctx, "", "tests", "testUnary", nil)
+	assert.Error(t, err, "call beyond expected times should fail")
+
+	err = host.Verify()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected call /tests/testDecode")
+}
+
+func TestUnmetExpectation(t *testing.T) {
+	host := mock.NewHost()
+	host.Expect("binding", "tests", "testFunction", nil)
+
+	err := host.Verify()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected call binding/tests/testFunction 1 time(s), got 0")
+}
+
+func TestReturnError(t *testing.T) {
+	hostErr := errors.New("host failure")
+	host := mock.NewHost()
+	host.Expect("", "tests", "testUnary", nil).ReturnError(hostErr)
+
+	response, err := host.HostCall(context.Background(), "", "tests", "testUnary", nil)
+	assert.Nil(t, response)
+	assert.Equal(t, hostErr, err)
+}
+
+func TestEmptyPayload(t *testing.T) {
+	ctx := context.Background()
+	host := mock.NewHost()
+	host.Expect("", "tests", "testNoArgs", nil).EmptyPayload().Times(2)
+
+	_, err := host.HostCall(ctx, "", "tests", "testNoArgs", []byte{0x80})
+	assert.Error(t, err, "call with a payload should fail")
+	_, err = host.HostCall(ctx, "", "tests", "testNoArgs", nil)
+	assert.NoError(t, err)
+	_, err = host.HostCall(ctx, "", "tests", "testNoArgs", []byte{})
+	assert.NoError(t, err)
+
+	err = host.Verify()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected call /tests/testNoArgs (1 bytes)")
+}
+
+func TestLatency(t *testing.T) {
+	host := mock.NewHost()
+	host.Expect("", "tests", "testUnary", nil).Return([]byte{0x80}).After(20 * time.Millisecond)
+	host.Expect("", "tests", "testDecode", nil).Return([]byte{0xa0}).After(time.Minute)
+
+	start := time.Now()
+	_, err := host.HostCall(context.Background(), "", "tests", "testUnary", nil)
+	require.NoError(t, err)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond, "response was not delayed")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = host.HostCall(ctx, "", "tests", "testDecode", nil)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+// TestConcurrentExpectations changes an expectation while a guest calls the
+// host, for go test -race.
+func TestConcurrentExpectations(t *testing.T) {
+	host := mock.NewHost()
+	e := host.Expect("", "tests", "testUnary", nil).Return([]byte{0x80}).Times(100)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_, err := host.HostCall(context.Background(), "", "tests", "testUnary", nil)
+			assert.NoError(t, err)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		e.Return([]byte{0x81}).After(0).Times(100).AnyPayload()
+	}
+	wg.Wait()
+	assert.NoError(t, host.Verify())
+}
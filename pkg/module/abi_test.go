@@ -0,0 +1,33 @@
+package module_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wapc/language-tests/pkg/abi"
+)
+
+// TestABI checks the imports and exports of every guest against the waPC ABI
+// and logs them, so `go test -v -run TestABI` reports the ABI of each
+// language. The checks are skipped for stale guests.
+func TestABI(t *testing.T) {
+	for _, lang := range append(languages, languagesV2...) {
+		lang := lang
+		t.Run(lang.guest, func(t *testing.T) {
+			report, err := abi.NewReport(guestCode(t, lang.guest))
+			require.NoError(t, err)
+			for _, i := range report.Imports {
+				t.Logf("import %s", i)
+			}
+			for _, e := range report.Exports {
+				if e.Kind == abi.Func {
+					t.Logf("export %s", e)
+				}
+			}
+			requireFresh(t, lang.guest)
+			assert.Empty(t, report.Problems)
+		})
+	}
+}
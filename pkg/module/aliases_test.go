@@ -0,0 +1,140 @@
+package module_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	tinygomsgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/module"
+	"github.com/wapc/language-tests/pkg/scalar"
+	guest "github.com/wapc/language-tests/tinygo/module"
+	guestscalar "github.com/wapc/language-tests/tinygo/scalar"
+)
+
+// plainAliases is Aliases with the underlying types of the aliases.
+type plainAliases struct {
+	Id               string            `msgpack:"id"`
+	IdOptional       *string           `msgpack:"idOptional"`
+	Email            string            `msgpack:"email"`
+	EmailOptional    *string           `msgpack:"emailOptional"`
+	Checksum         []byte            `msgpack:"checksum"`
+	ChecksumOptional []byte            `msgpack:"checksumOptional"`
+	Ids              []string          `msgpack:"ids"`
+	EmailsById       map[string]string `msgpack:"emailsById"`
+}
+
+const (
+	testUUID  = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	nilUUID   = "00000000-0000-0000-0000-000000000000"
+	testEmail = "test@example.com"
+)
+
+// newAliases returns the same values as Aliases and as plainAliases.
+func newAliases(t *testing.T) (module.Aliases, plainAliases) {
+	id, err := scalar.ParseUUID(testUUID)
+	require.NoError(t, err)
+	email := module.Email(testEmail)
+	sum := sha256.Sum256([]byte("test"))
+
+	aliases := module.Aliases{
+		Id:               id,
+		IdOptional:       &id,
+		Email:            email,
+		EmailOptional:    &email,
+		Checksum:         sum[:],
+		ChecksumOptional: sum[:],
+		Ids:              []scalar.UUID{id, {}},
+		EmailsById:       map[scalar.UUID]module.Email{id: email},
+	}
+	plain := plainAliases{
+		Id:               testUUID,
+		IdOptional:       pointer.ToString(testUUID),
+		Email:            testEmail,
+		EmailOptional:    pointer.ToString(testEmail),
+		Checksum:         sum[:],
+		ChecksumOptional: sum[:],
+		Ids:              []string{testUUID, nilUUID},
+		EmailsById:       map[string]string{testUUID: testEmail},
+	}
+	return aliases, plain
+}
+
+func TestUUID(t *testing.T) {
+	id, err := scalar.ParseUUID(testUUID)
+	require.NoError(t, err)
+	assert.Equal(t, testUUID, id.String())
+	assert.Equal(t, nilUUID, scalar.UUID{}.String())
+
+	guestID, err := guestscalar.ParseUUID(testUUID)
+	require.NoError(t, err)
+	assert.Equal(t, testUUID, guestID.String())
+
+	for _, invalid := range []string{
+		"", "test", testUUID[:35], testUUID + "0",
+		"6ba7b810x9dad-11d1-80b4-00c04fd430c8",
+		"6ba7b810-9dad-11d1-80b4-00c04fd430cg",
+		"6ba7b81-09dad-11d1-80b4-00c04fd430c8",
+		"6BA7B810-9DAD-11D1-80B4-00C04FD430C8",
+		"6ba7b810-9dad-11d1-80b4-00c04fd430cA",
+		"{6ba7b810-9dad-11d1-80b4-00c04fd430c8}",
+	} {
+		_, err := scalar.ParseUUID(invalid)
+		assert.Errorf(t, err, "host parsed %q", invalid)
+		_, err = guestscalar.ParseUUID(invalid)
+		assert.Errorf(t, err, "TinyGo parsed %q", invalid)
+	}
+}
+
+// TestAliasEncoding checks that aliases and user mapped types do not change
+// the wire format and that the TinyGo bindings round trip them.
+func TestAliasEncoding(t *testing.T) {
+	aliases, plain := newAliases(t)
+	encoded, err := msgpack.Marshal(&aliases)
+	require.NoError(t, err)
+	plainEncoded, err := msgpack.Marshal(&plain)
+	require.NoError(t, err)
+	assert.Equal(t, plainEncoded, encoded, "aliases changed the wire format")
+
+	var actual module.Aliases
+	require.NoError(t, msgpack.Unmarshal(encoded, &actual))
+	assert.Equal(t, aliases, actual)
+
+	decoder := tinygomsgpack.NewDecoder(encoded)
+	decoded, err := guest.DecodeAliases(&decoder)
+	require.NoError(t, err)
+	assert.Equal(t, testUUID, decoded.Id.String())
+	assert.Equal(t, guest.Email(testEmail), decoded.Email)
+	assert.Equal(t, encoded, decoded.ToBuffer(), "TinyGo changed the aliases")
+
+	// A string that is not a UUID cannot be decoded as the mapped type.
+	plain.Id = "test"
+	invalid, err := msgpack.Marshal(&plain)
+	require.NoError(t, err)
+	assert.Error(t, msgpack.Unmarshal(invalid, &actual), "host decoded an invalid UUID")
+	decoder = tinygomsgpack.NewDecoder(invalid)
+	_, err = guest.DecodeAliases(&decoder)
+	assert.Error(t, err, "TinyGo decoded an invalid UUID")
+}
+
+func TestAliases(t *testing.T) {
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			wapcInstance := instantiate(t, lang.guest)
+			defer wapcInstance.Close()
+			m := module.New(wapcInstance)
+
+			aliases, _ := newAliases(t)
+			actual, err := m.TestAliases(context.Background(), aliases)
+			require.NoError(t, err, "could not invoke testAliases")
+			assert.Equal(t, aliases, actual, "aliases were not preserved")
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package module_test
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/wapc/language-tests/build"
+	"github.com/wapc/language-tests/pkg/manifest"
+)
+
+var strictArtifacts = flag.Bool("strict-artifacts", false,
+	"fail instead of warning when a guest is stale")
+
+// staleGuests holds the guests whose schema, sources or Wasm file changed
+// since they were built, with the reason.
+var staleGuests = map[string]error{}
+
+// TestMain checks the manifests of the guests before running the tests, so
+// that failures caused by a stale guest are not mistaken for bugs in the
+// generated code. A built guest without a manifest is an error. Afterwards it
+// closes the modules shared by the tests.
+func TestMain(m *testing.M) {
+	flag.Parse()
+	level := "warning"
+	if *strictArtifacts {
+		level = "error"
+	}
+	failed := false
+	for _, lang := range append(languages, languagesV2...) {
+		wasm := "build/" + lang.guest + ".wasm"
+		if _, err := build.Get(lang.guest); errors.Is(err, build.ErrNotBuilt) {
+			continue
+		}
+		err := manifest.Check("../..", wasm)
+		if os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "error: %s has no manifest; build it with build.sh\n", wasm)
+			failed = true
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v; rebuild it with build.sh\n", level, err)
+			staleGuests[lang.guest] = err
+			failed = failed || *strictArtifacts
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+	code := m.Run()
+	if testing.Verbose() {
+		fmt.Printf("echo modules: %s\n", echoModules.Stats())
+	}
+	echoModules.Close()
+	os.Exit(code)
+}
+
+// requireFresh skips the test if `guest` is stale, for tests of behavior that
+// the guest may predate.
+func requireFresh(t *testing.T, guest string) {
+	t.Helper()
+	if err := staleGuests[guest]; err != nil {
+		t.Skipf("%v; rebuild it with build.sh", err)
+	}
+}
@@ -0,0 +1,239 @@
+package module_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+
+	"github.com/wapc/language-tests/pkg/module"
+)
+
+// doubler is an Invoker returning twice its u64 request. It fails for
+// requests of 13 and when called while already running a call.
+type doubler struct {
+	busy    int32
+	running *int32
+	max     *int32
+}
+
+func (d *doubler) Invoke(ctx context.Context, operation string, payload []byte) ([]byte, error) {
+	if !atomic.CompareAndSwapInt32(&d.busy, 0, 1) {
+		return nil, errors.New("instance used concurrently")
+	}
+	defer atomic.StoreInt32(&d.busy, 0)
+	running := atomic.AddInt32(d.running, 1)
+	defer atomic.AddInt32(d.running, -1)
+	for {
+		max := atomic.LoadInt32(d.max)
+		if running <= max || atomic.CompareAndSwapInt32(d.max, max, running) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+
+	var n uint64
+	if err := msgpack.Unmarshal(payload, &n); err != nil {
+		return nil, err
+	}
+	if n == 13 {
+		return nil, errors.New("unlucky " + strconv.FormatUint(n, 10))
+	}
+	return msgpack.Marshal(2 * n)
+}
+
+// doublers returns a pool of `size` doublers and the maximum number of calls
+// they ran at once.
+func doublers(size int) (*module.Pool, *int32) {
+	var running, max int32
+	instances := make([]module.Invoker, size)
+	for i := range instances {
+		instances[i] = &doubler{running: &running, max: &max}
+	}
+	return module.NewPool(instances...), &max
+}
+
+func numbers(n int) []uint64 {
+	reqs := make([]uint64, n)
+	for i := range reqs {
+		reqs[i] = uint64(i)
+	}
+	return reqs
+}
+
+func TestRunBatch(t *testing.T) {
+	pool, max := doublers(4)
+	assert.Equal(t, 4, pool.Size())
+	results := module.RunBatch[uint64, uint64](context.Background(), pool, "double", numbers(100),
+		module.BatchSettings{Workers: 4, MaxPending: 16})
+	require.Len(t, results, 100)
+	for i, r := range results {
+		if i == 13 {
+			assert.EqualError(t, r.Err, "unlucky 13")
+			continue
+		}
+		assert.NoError(t, r.Err)
+		assert.Equal(t, uint64(2*i), r.Resp)
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(max), int32(4))
+	assert.Greater(t, atomic.LoadInt32(max), int32(1))
+}
+
+func TestRunBatchCancel(t *testing.T) {
+	pool, _ := doublers(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := module.RunBatch[uint64, uint64](ctx, pool, "double", numbers(10), module.BatchSettings{})
+	require.Len(t, results, 10)
+	for _, r := range results {
+		assert.Equal(t, context.Canceled, r.Err)
+	}
+}
+
+func TestBatchBackpressure(t *testing.T) {
+	pool, _ := doublers(2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := module.NewBatch[uint64, uint64](ctx, pool, "double", module.BatchSettings{Workers: 2, MaxPending: 3})
+	for i := uint64(0); i < 3; i++ {
+		require.NoError(t, b.Submit(i))
+	}
+
+	// The fourth request waits for a result to be received.
+	submitted := make(chan error)
+	go func() { submitted <- b.Submit(3) }()
+	select {
+	case <-submitted:
+		t.Fatal("Submit did not block with MaxPending results pending")
+	case <-time.After(50 * time.Millisecond):
+	}
+	r := <-b.Results()
+	assert.Equal(t, uint64(0), r.Resp)
+	require.NoError(t, <-submitted)
+	b.Close()
+
+	var resps []uint64
+	for r := range b.Results() {
+		require.NoError(t, r.Err)
+		resps = append(resps, r.Resp)
+	}
+	assert.Equal(t, []uint64{2, 4, 6}, resps)
+
+	b = module.NewBatch[uint64, uint64](ctx, pool, "double", module.BatchSettings{MaxPending: 1})
+	require.NoError(t, b.Submit(1))
+	cancel()
+	assert.Equal(t, context.Canceled, b.Submit(2))
+	b.Close()
+	for r := range b.Results() {
+		assert.Condition(t, func() bool { return r.Err == context.Canceled || r.Resp == 2 })
+	}
+}
+
+func TestInvokeAsync(t *testing.T) {
+	pool, _ := doublers(2)
+	futures := make([]*module.Future[uint64], 10)
+	for i := range futures {
+		futures[i] = module.InvokeAsync[uint64, uint64](context.Background(), pool, "double", uint64(i))
+	}
+	for i, f := range futures {
+		resp, err := f.Result()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2*i), resp)
+		select {
+		case <-f.Done():
+		default:
+			t.Fatal("Done is open after Result returned")
+		}
+	}
+}
+
+// echoer is an Invoker returning its request.
+type echoer struct{}
+
+func (echoer) Invoke(ctx context.Context, operation string, payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+// TestModuleBatch checks that the Async and Batch methods of a Module apply
+// its options, here validating every request.
+func TestModuleBatch(t *testing.T) {
+	m := module.New(echoer{}, module.RequireFields())
+	valid := module.Unions{
+		Shape:  module.Shape{Circle: &module.Circle{Radius: 1}},
+		Shapes: []module.Shape{},
+	}
+	invalid := module.Unions{Shapes: []module.Shape{}}
+	empty := &module.UnionError{Type: "Shape"}
+
+	results := m.TestUnionsBatch(context.Background(), []module.Unions{valid, invalid, valid},
+		module.BatchSettings{})
+	require.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, valid, results[0].Resp)
+	assert.Equal(t, empty, results[1].Err)
+	assert.NoError(t, results[2].Err)
+
+	_, err := m.TestUnionsAsync(context.Background(), invalid).Result()
+	assert.Equal(t, empty, err)
+	resp, err := m.TestUnionsAsync(context.Background(), valid).Result()
+	require.NoError(t, err)
+	assert.Equal(t, valid, resp)
+}
+
+func TestBatchGuest(t *testing.T) {
+	wapcModule := echoModule(t, "tinygo")
+	instances := make([]module.Invoker, 4)
+	for i := range instances {
+		wapcInstance, err := wapcModule.Instantiate()
+		require.NoError(t, err, "could instantiate module")
+		defer wapcInstance.Close()
+		instances[i] = wapcInstance
+	}
+	pool := module.NewPool(instances...)
+
+	reqs := make([]module.Tests, 20)
+	for i := range reqs {
+		reqs[i] = newTests()
+		reqs[i].Required.StringValue = strconv.Itoa(i)
+	}
+	m := module.New(pool)
+	results := m.TestUnaryBatch(context.Background(), reqs, module.BatchSettings{Workers: pool.Size()})
+	for i, r := range results {
+		require.NoError(t, r.Err)
+		assert.Equal(t, reqs[i], r.Resp)
+	}
+
+	futures := make([]*module.Future[module.Tests], len(reqs))
+	for i, req := range reqs {
+		futures[i] = m.TestUnaryAsync(context.Background(), req)
+	}
+	for i, f := range futures {
+		resp, err := f.Result()
+		require.NoError(t, err)
+		assert.Equal(t, reqs[i], resp)
+	}
+
+	// A Module on a pool is safe for concurrent use.
+	results = make([]module.Result[module.Tests], len(reqs))
+	done := make(chan struct{})
+	for i := range reqs {
+		i := i
+		go func() {
+			results[i].Resp, results[i].Err = m.TestUnary(context.Background(), reqs[i])
+			done <- struct{}{}
+		}()
+	}
+	for range reqs {
+		<-done
+	}
+	for i, r := range results {
+		require.NoError(t, r.Err)
+		assert.Equal(t, reqs[i], r.Resp)
+	}
+}
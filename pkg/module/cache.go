@@ -0,0 +1,174 @@
+package module
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wapc/wapc-go"
+)
+
+// Cache compiles every distinct guest once, keyed by the SHA-256 of its code,
+// so that hosts loading the same guest many times only pay for
+// instantiation. All modules share the host call handler of the cache.
+//
+// The compiled modules live in memory only: wapc-go does not expose the
+// engine's compiled module, so they cannot be serialized to disk.
+type Cache struct {
+	hostCallHandler wapc.HostCallHandler
+
+	// mu guards the fields below and the module and error of the entries.
+	mu      sync.Mutex
+	logger  wapc.Logger
+	writer  wapc.Logger
+	modules map[[sha256.Size]byte]*cached
+	stats   CacheStats
+}
+
+type cached struct {
+	once   sync.Once
+	module *wapc.Module
+	err    error
+}
+
+// ErrCacheClosed is returned by Cache.Module for a module that the cache
+// closed before it could be returned.
+var ErrCacheClosed = errors.New("module cache closed during compilation")
+
+// CacheStats counts the work done by a Cache.
+type CacheStats struct {
+	// Hits counts the calls that found a compiled module and Misses those
+	// that compiled one.
+	Hits   int
+	Misses int
+	// Compile is the total time spent compiling.
+	Compile time.Duration
+	// Instances counts the instances created by Instantiate and Instantiate
+	// the total time spent creating them.
+	Instances   int
+	Instantiate time.Duration
+}
+
+func (s CacheStats) String() string {
+	return fmt.Sprintf("%d compiled in %s, %d hits, %d instances in %s",
+		s.Misses, s.Compile, s.Hits, s.Instances, s.Instantiate)
+}
+
+// NewCache creates an empty cache of modules using `hostCallHandler`.
+func NewCache(hostCallHandler wapc.HostCallHandler) *Cache {
+	return &Cache{
+		hostCallHandler: hostCallHandler,
+		modules:         map[[sha256.Size]byte]*cached{},
+	}
+}
+
+// SetLogger sets the waPC logger of the modules compiled afterwards.
+func (c *Cache) SetLogger(logger wapc.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = logger
+}
+
+// SetWriter sets the WASI writer of the modules compiled afterwards.
+func (c *Cache) SetWriter(writer wapc.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writer = writer
+}
+
+// Module returns the module compiled from `code`, compiling it on the first
+// call. The module is owned by the cache: close the cache instead of the
+// module. A failed compilation is not cached: the next call compiles again.
+func (c *Cache) Module(code []byte) (*wapc.Module, error) {
+	key := sha256.Sum256(code)
+	c.mu.Lock()
+	entry, ok := c.modules[key]
+	if ok {
+		c.stats.Hits++
+	} else {
+		entry = &cached{}
+		c.modules[key] = entry
+		c.stats.Misses++
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		c.mu.Lock()
+		logger, writer := c.logger, c.writer
+		c.mu.Unlock()
+
+		start := time.Now()
+		module, err := wapc.New(code, c.hostCallHandler)
+		elapsed := time.Since(start)
+		if err == nil {
+			if logger != nil {
+				module.SetLogger(logger)
+			}
+			if writer != nil {
+				module.SetWriter(writer)
+			}
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.stats.Compile += elapsed
+		if c.modules[key] != entry {
+			// Close removed the entry while it compiled.
+			if err == nil {
+				module.Close()
+			}
+			module, err = nil, ErrCacheClosed
+		} else if err != nil {
+			delete(c.modules, key)
+		}
+		entry.module, entry.err = module, err
+	})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry.err == nil && c.modules[key] != entry {
+		// Close closed the module after it compiled.
+		return nil, ErrCacheClosed
+	}
+	return entry.module, entry.err
+}
+
+// Instantiate creates an instance of the module compiled from `code`. The
+// caller closes the instance.
+func (c *Cache) Instantiate(code []byte) (*wapc.Instance, error) {
+	m, err := c.Module(code)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	instance, err := m.Instantiate()
+	elapsed := time.Since(start)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Instantiate += elapsed
+	if err == nil {
+		c.stats.Instances++
+	}
+	return instance, err
+}
+
+// Stats returns the work done by the cache so far.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Close closes the compiled modules and empties the cache. Close the
+// instances first.
+func (c *Cache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.modules {
+		if entry.module != nil {
+			entry.module.Close()
+		}
+		delete(c.modules, key)
+	}
+}
@@ -0,0 +1,117 @@
+package module_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wapc/wapc-go"
+
+	"github.com/wapc/language-tests/pkg/module"
+)
+
+func TestCache(t *testing.T) {
+	requireFresh(t, "tinygo")
+	code := guestCode(t, "tinygo")
+	cache := module.NewCache(echoHost)
+	defer cache.Close()
+
+	first, err := cache.Module(code)
+	require.NoError(t, err)
+	// The cache is keyed by contents, not by slice.
+	second, err := cache.Module(append([]byte(nil), code...))
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	for i := 0; i < 3; i++ {
+		wapcInstance, err := cache.Instantiate(code)
+		require.NoError(t, err)
+		result, err := module.New(wapcInstance).TestUnary(context.Background(), newTests())
+		wapcInstance.Close()
+		require.NoError(t, err)
+		assert.Equal(t, newTests(), result)
+	}
+
+	// A failed compilation is not cached, so both calls compile.
+	_, err = cache.Module([]byte("not wasm"))
+	assert.Error(t, err)
+	_, err = cache.Instantiate([]byte("not wasm"))
+	assert.Error(t, err)
+
+	stats := cache.Stats()
+	assert.Equal(t, 3, stats.Misses)
+	assert.Equal(t, 4, stats.Hits)
+	assert.Equal(t, 3, stats.Instances)
+	assert.Greater(t, int64(stats.Compile), int64(0))
+	assert.Greater(t, int64(stats.Instantiate), int64(0))
+	t.Log(stats)
+}
+
+// TestCacheConcurrency uses a cache from several goroutines, first alone and
+// then while closing it, for go test -race.
+func TestCacheConcurrency(t *testing.T) {
+	requireFresh(t, "tinygo")
+	code := guestCode(t, "tinygo")
+	cache := module.NewCache(echoHost)
+	defer cache.Close()
+
+	const goroutines = 8
+	modules := make([]*wapc.Module, goroutines)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cache.SetLogger(func(string) {})
+			cache.SetWriter(func(string) {})
+			m, err := cache.Module(code)
+			assert.NoError(t, err)
+			modules[i] = m
+		}(i)
+	}
+	wg.Wait()
+	// The goroutines share a single compilation.
+	require.NotNil(t, modules[0])
+	for _, m := range modules[1:] {
+		assert.Same(t, modules[0], m)
+	}
+	stats := cache.Stats()
+	assert.Equal(t, 1, stats.Misses)
+	assert.Equal(t, goroutines-1, stats.Hits)
+
+	// Racing Close, every call returns either a module or ErrCacheClosed.
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, err := cache.Module(code)
+			if err == module.ErrCacheClosed {
+				assert.Nil(t, m)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, m)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cache.Close()
+	}()
+	wg.Wait()
+	stats = cache.Stats()
+	assert.Equal(t, 2*goroutines, stats.Misses+stats.Hits)
+
+	// The cache compiles again after Close, and the new module works.
+	m, err := cache.Module(code)
+	require.NoError(t, err)
+	assert.NotSame(t, modules[0], m)
+	wapcInstance, err := cache.Instantiate(code)
+	require.NoError(t, err)
+	defer wapcInstance.Close()
+	result, err := module.New(wapcInstance).TestUnary(context.Background(), newTests())
+	require.NoError(t, err)
+	assert.Equal(t, newTests(), result)
+}
@@ -0,0 +1,125 @@
+package module_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+
+	"github.com/wapc/language-tests/pkg/module"
+)
+
+// recorder is an Invoker that records the calls and returns a canned
+// response.
+type recorder struct {
+	operations []string
+	payloads   [][]byte
+	response   []byte
+}
+
+func (r *recorder) Invoke(ctx context.Context, operation string, payload []byte) ([]byte, error) {
+	r.operations = append(r.operations, operation)
+	r.payloads = append(r.payloads, payload)
+	return r.response, nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// greeting is the request and response of an operation missing from the
+// schema.
+type greeting struct {
+	Name string `msgpack:"name" json:"name"`
+}
+
+func TestCall(t *testing.T) {
+	ctx := context.Background()
+	response, err := msgpack.Marshal(&greeting{"hello"})
+	require.NoError(t, err)
+	r := &recorder{response: response}
+
+	result, err := module.Call[greeting, greeting](ctx, r, "greet", greeting{"world"})
+	require.NoError(t, err)
+	assert.Equal(t, greeting{"hello"}, result)
+	var request greeting
+	require.NoError(t, msgpack.Unmarshal(r.payloads[0], &request))
+	assert.Equal(t, greeting{"world"}, request)
+
+	// Void requests are empty and void responses are not decoded.
+	r = &recorder{response: []byte("not msgpack")}
+	_, err = module.Call[module.Void, module.Void](ctx, r, "ping", module.Void{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{}, r.payloads[0])
+
+	r = &recorder{response: []byte(`{"name":"hello"}`)}
+	result, err = module.Call[greeting, greeting](ctx, r, "greet", greeting{"world"}, module.WithCodec(jsonCodec{}))
+	require.NoError(t, err)
+	assert.Equal(t, greeting{"hello"}, result)
+	assert.JSONEq(t, `{"name":"world"}`, string(r.payloads[0]))
+}
+
+func TestCallChecks(t *testing.T) {
+	ctx := context.Background()
+	r := &recorder{}
+
+	// Constraints are always checked, before invoking the operation.
+	_, err := module.Call[module.Validated, module.Validated](ctx, r, "testValidation", module.Validated{})
+	assert.IsType(t, &module.ValidationError{}, err)
+	assert.Empty(t, r.operations)
+
+	response, err := msgpack.Marshal(map[string]interface{}{"optional": "test"})
+	require.NoError(t, err)
+	r = &recorder{response: response}
+	_, err = module.Call[module.Defaults, module.Defaults](ctx, r, "testDefaults", module.Defaults{Required: "test"})
+	assert.NoError(t, err)
+	_, err = module.Call[module.Defaults, module.Defaults](ctx, r, "testDefaults", module.Defaults{Required: "test"},
+		module.WithRequiredFields("Defaults"))
+	assert.Equal(t, &module.MissingFieldError{Type: "Defaults", Field: "required"}, err)
+}
+
+func TestModuleCallOptions(t *testing.T) {
+	r := &recorder{response: []byte(`"done"`)}
+	m := module.New(r, module.CallOptions(module.WithCodec(jsonCodec{})))
+	result, err := m.TestUnaryString(context.Background(), "value")
+	require.NoError(t, err)
+	assert.Equal(t, "done", result)
+	assert.Equal(t, []string{"testUnaryString"}, r.operations)
+	assert.Equal(t, `"value"`, string(r.payloads[0]))
+}
+
+// counter is an Invoker wrapping an instance, as tracing would.
+type counter struct {
+	module.Invoker
+	calls map[string]int
+}
+
+func (c *counter) Invoke(ctx context.Context, operation string, payload []byte) ([]byte, error) {
+	c.calls[operation]++
+	return c.Invoker.Invoke(ctx, operation, payload)
+}
+
+func TestInvoker(t *testing.T) {
+	wapcInstance := instantiate(t, "tinygo")
+	defer wapcInstance.Close()
+	c := &counter{wapcInstance, map[string]int{}}
+	m := module.New(c)
+
+	tests := newTests()
+	result, err := m.TestUnary(context.Background(), tests)
+	require.NoError(t, err)
+	assert.Equal(t, tests, result)
+	result, err = module.Call[module.Tests, module.Tests](context.Background(), c, "testUnary", tests)
+	require.NoError(t, err)
+	assert.Equal(t, tests, result)
+	assert.Equal(t, map[string]int{"testUnary": 2}, c.calls)
+}
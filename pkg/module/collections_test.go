@@ -0,0 +1,97 @@
+package module_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	tinygomsgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/module"
+	guest "github.com/wapc/language-tests/tinygo/module"
+)
+
+func newCollections() module.Collections {
+	return module.Collections{
+		MapStringKeys: map[string]string{"a": "test", "": "empty key"},
+		MapI64Keys:    map[int64]string{math.MinInt64: "min", -1: "negative", 0: "zero", math.MaxInt64: "max"},
+		MapBoolKeys:   map[bool]string{true: "yes", false: "no"},
+		MapObjects:    map[string]module.Thing{"thing": {Value: "test"}},
+		MapOptionalValues: map[string]*string{
+			"set":   pointer.ToString("test"),
+			"unset": nil,
+		},
+		MapLists: map[string][]uint64{
+			"values": {1, math.MaxUint64},
+			"empty":  {},
+		},
+		ListLists: [][]string{{"a", "b"}, {}, {"c"}},
+		ListMaps: []map[string]uint64{
+			{"a": 1},
+			{},
+			{"b": 2, "c": math.MaxUint64},
+		},
+		ListOptional: []string{"test"},
+		MapOptional:  map[string]string{"a": "test"},
+	}
+}
+
+// collectionCases adds the three states of the optional collections to the
+// fixture: set, empty and absent.
+var collectionCases = map[string]func(*module.Collections){
+	"set": func(*module.Collections) {},
+	"empty": func(c *module.Collections) {
+		c.ListOptional = []string{}
+		c.MapOptional = map[string]string{}
+	},
+	"absent": func(c *module.Collections) {
+		c.ListOptional = nil
+		c.MapOptional = nil
+	},
+}
+
+func TestCollectionsEncoding(t *testing.T) {
+	for name, modify := range collectionCases {
+		collections := newCollections()
+		modify(&collections)
+		hostEncoded, err := msgpack.Marshal(&collections)
+		require.NoError(t, err)
+
+		var actual module.Collections
+		require.NoError(t, msgpack.Unmarshal(hostEncoded, &actual))
+		assert.Equalf(t, collections, actual, "host changed %s collections", name)
+
+		decoder := tinygomsgpack.NewDecoder(hostEncoded)
+		decoded, err := guest.DecodeCollections(&decoder)
+		require.NoErrorf(t, err, "TinyGo could not decode %s collections", name)
+		actual = module.Collections{}
+		require.NoError(t, msgpack.Unmarshal(decoded.ToBuffer(), &actual))
+		assert.Equalf(t, collections, actual, "TinyGo changed %s collections", name)
+	}
+}
+
+func TestCollections(t *testing.T) {
+	ctx := context.Background()
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			wapcInstance := instantiate(t, lang.guest)
+			defer wapcInstance.Close()
+			m := module.New(wapcInstance)
+
+			for name, modify := range collectionCases {
+				collections := newCollections()
+				modify(&collections)
+				actual, err := m.TestCollections(ctx, collections)
+				if assert.NoErrorf(t, err, "could not invoke testCollections with %s collections", name) {
+					assert.Equalf(t, collections, actual, "%s collections were not preserved", name)
+				}
+			}
+		})
+	}
+}
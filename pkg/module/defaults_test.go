@@ -0,0 +1,209 @@
+package module_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	tinygomsgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/module"
+	guest "github.com/wapc/language-tests/tinygo/module"
+)
+
+var withDefaults = module.Defaults{
+	U64Value:    42,
+	S32Value:    -7,
+	F64Value:    1.5,
+	BoolValue:   true,
+	StringValue: "default",
+	Color:       module.ColorBlue,
+	Required:    "test",
+}
+
+// decodeDefaults decodes `payload` with the host and the TinyGo bindings and
+// checks that both produce the same value.
+func decodeDefaults(t *testing.T, payload []byte) module.Defaults {
+	var hostDecoded module.Defaults
+	require.NoError(t, msgpack.Unmarshal(payload, &hostDecoded))
+
+	decoder := tinygomsgpack.NewDecoder(payload)
+	decoded, err := guest.DecodeDefaults(&decoder)
+	require.NoError(t, err)
+	var tinygoDecoded module.Defaults
+	require.NoError(t, msgpack.Unmarshal(decoded.ToBuffer(), &tinygoDecoded))
+	assert.Equal(t, hostDecoded, tinygoDecoded, "host and TinyGo applied different defaults")
+	return hostDecoded
+}
+
+func TestDefaultsEncoding(t *testing.T) {
+	payload, err := msgpack.Marshal(map[string]string{"required": "test"})
+	require.NoError(t, err)
+	assert.Equal(t, withDefaults, decodeDefaults(t, payload), "defaults were not applied")
+
+	// Values that are present, including zero values, replace the defaults.
+	zero := module.Defaults{Optional: pointer.ToString("")}
+	payload, err = msgpack.Marshal(&zero)
+	require.NoError(t, err)
+	assert.Equal(t, zero, decodeDefaults(t, payload), "zero values were replaced by defaults")
+
+	// Decoding into a value that is already set applies the defaults too.
+	decoded := module.Defaults{U64Value: 1, Required: "previous"}
+	payload, err = msgpack.Marshal(map[string]string{"required": "test"})
+	require.NoError(t, err)
+	require.NoError(t, msgpack.Unmarshal(payload, &decoded))
+	assert.Equal(t, withDefaults, decoded)
+}
+
+// missingFieldCases are payloads each missing one required field, possibly in
+// a nested object.
+var missingFieldCases = []struct {
+	name     string
+	typ      string
+	value    interface{}
+	decode   func(*tinygomsgpack.Decoder) error
+	expected module.MissingFieldError
+}{
+	{
+		"top level",
+		"Defaults",
+		map[string]interface{}{"optional": "test"},
+		func(d *tinygomsgpack.Decoder) error { _, err := guest.DecodeDefaults(d); return err },
+		module.MissingFieldError{Type: "Defaults", Field: "required"},
+	},
+	{
+		"nested",
+		"Trees",
+		map[string]interface{}{
+			"node": map[string]interface{}{
+				"value": "root",
+				"children": []interface{}{
+					map[string]interface{}{"value": "child", "children": []interface{}{}},
+					map[string]interface{}{"value": "missing children"},
+				},
+			},
+			"branch": map[string]interface{}{"leaves": []interface{}{}},
+		},
+		func(d *tinygomsgpack.Decoder) error { _, err := guest.DecodeTrees(d); return err },
+		module.MissingFieldError{Type: "Node", Field: "children"},
+	},
+	{
+		"mutually recursive",
+		"Trees",
+		map[string]interface{}{
+			"node": map[string]interface{}{"value": "root", "children": []interface{}{}},
+			"branch": map[string]interface{}{
+				"leaves": []interface{}{
+					map[string]interface{}{"value": "leaf", "branch": map[string]interface{}{}},
+				},
+			},
+		},
+		func(d *tinygomsgpack.Decoder) error { _, err := guest.DecodeTrees(d); return err },
+		module.MissingFieldError{Type: "Branch", Field: "leaves"},
+	},
+	{
+		"absent object",
+		"Trees",
+		map[string]interface{}{
+			"node": map[string]interface{}{"value": "root", "children": []interface{}{}},
+		},
+		func(d *tinygomsgpack.Decoder) error { _, err := guest.DecodeTrees(d); return err },
+		module.MissingFieldError{Type: "Trees", Field: "branch"},
+	},
+}
+
+// TestRequireFields checks that the host and the TinyGo bindings report the
+// same missing field in strict mode and ignore it otherwise.
+func TestRequireFields(t *testing.T) {
+	for _, c := range missingFieldCases {
+		payload, err := msgpack.Marshal(c.value)
+		require.NoError(t, err)
+
+		err = module.RequireFieldsIn(payload, c.typ)
+		assert.Equalf(t, &c.expected, err, "host reported the wrong field for %s", c.name)
+
+		decoder := tinygomsgpack.NewDecoder(payload)
+		assert.NoErrorf(t, c.decode(&decoder), "TinyGo required fields for %s by default", c.name)
+	}
+
+	guest.RequireFields = true
+	defer func() { guest.RequireFields = false }()
+	for _, c := range missingFieldCases {
+		payload, err := msgpack.Marshal(c.value)
+		require.NoError(t, err)
+
+		decoder := tinygomsgpack.NewDecoder(payload)
+		assert.Equalf(t, &guest.MissingFieldError{Type: c.expected.Type, Field: c.expected.Field}, c.decode(&decoder),
+			"TinyGo reported the wrong field for %s", c.name)
+	}
+
+	payload, err := msgpack.Marshal(map[string]interface{}{
+		"node":   map[string]interface{}{"value": "root", "children": []interface{}{}},
+		"branch": map[string]interface{}{"leaves": []interface{}{}},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, module.RequireFieldsIn(payload, "Trees"))
+}
+
+// TestValidate checks that nil collections and bytes are valid, and that the
+// TinyGo bindings decode them as empty.
+func TestValidate(t *testing.T) {
+	var trees module.Trees
+	assert.NoError(t, trees.Validate())
+	var guestTrees guest.Trees
+	assert.NoError(t, guestTrees.Validate())
+	trees = module.Trees{
+		Branch: module.Branch{
+			Leaves: []module.Leaf{{Branch: &module.Branch{}}},
+		},
+	}
+	assert.NoError(t, trees.Validate())
+
+	tests := newTests()
+	tests.Required.BytesValue = nil
+	tests.Lists.ListStrings = nil
+	assert.NoError(t, tests.Validate())
+	payload, err := msgpack.Marshal(&tests)
+	require.NoError(t, err)
+	decoder := tinygomsgpack.NewDecoder(payload)
+	decoded, err := guest.DecodeTests(&decoder)
+	require.NoError(t, err)
+	assert.Empty(t, decoded.Required.BytesValue)
+	assert.Empty(t, decoded.Lists.ListStrings)
+	assert.NoError(t, decoded.Validate())
+
+	// A strict module validates arguments before invoking the guest.
+	m := module.New(echoer{}, module.RequireFields())
+	_, err = m.TestRecursion(context.Background(), module.Trees{})
+	assert.NoError(t, err)
+}
+
+func TestDefaults(t *testing.T) {
+	ctx := context.Background()
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			wapcInstance := instantiate(t, lang.guest)
+			defer wapcInstance.Close()
+			m := module.New(wapcInstance, module.RequireFields())
+
+			defaults := withDefaults
+			defaults.Optional = pointer.ToString("test")
+			actual, err := m.TestDefaults(ctx, defaults)
+			require.NoError(t, err, "could not invoke testDefaults")
+			assert.Equal(t, defaults, actual, "defaults were not preserved")
+
+			payload, err := msgpack.Marshal(map[string]string{"required": "test"})
+			require.NoError(t, err)
+			response, err := wapcInstance.Invoke(ctx, "testDefaults", payload)
+			require.NoError(t, err)
+			var decoded module.Defaults
+			require.NoError(t, msgpack.Unmarshal(response, &decoded))
+			assert.Equal(t, withDefaults, decoded, "guest did not apply the defaults")
+		})
+	}
+}
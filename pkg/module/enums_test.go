@@ -0,0 +1,104 @@
+package module_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	tinygomsgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/module"
+	guest "github.com/wapc/language-tests/tinygo/module"
+)
+
+// enumValues covers the declared colors and unknown values in every MsgPack
+// integer format.
+var enumValues = []module.Color{
+	module.ColorRed,
+	module.ColorGreen,
+	module.ColorBlue,
+	3,
+	127,
+	128,
+	-1,
+	-33,
+	1000,
+	math.MaxInt32,
+	math.MinInt32,
+}
+
+func newEnums(color module.Color) module.Enums {
+	return module.Enums{
+		Color:         color,
+		ColorOptional: &color,
+		Colors:        []module.Color{module.ColorBlue, color},
+		ColorMap:      map[string]module.Color{"color": color},
+	}
+}
+
+func TestEnumString(t *testing.T) {
+	assert.Equal(t, "red", module.ColorRed.String())
+	assert.Equal(t, "green", module.ColorGreen.String())
+	assert.Equal(t, "blue", module.ColorBlue.String())
+	assert.Equal(t, "Color(3)", module.Color(3).String())
+	assert.Equal(t, "Color(-1)", module.Color(-1).String())
+	assert.True(t, module.ColorBlue.IsValid())
+	assert.False(t, module.Color(3).IsValid())
+
+	assert.Equal(t, "blue", guest.ColorBlue.String())
+	assert.Equal(t, "Color(3)", guest.Color(3).String())
+	assert.False(t, guest.Color(3).IsValid())
+}
+
+// TestEnumEncoding checks that the host and the TinyGo bindings produce the
+// same bytes for every value and preserve unknown values when decoding.
+func TestEnumEncoding(t *testing.T) {
+	for _, color := range enumValues {
+		enums := newEnums(color)
+		hostEncoded, err := msgpack.Marshal(&enums)
+		require.NoError(t, err)
+
+		decoder := tinygomsgpack.NewDecoder(hostEncoded)
+		decoded, err := guest.DecodeEnums(&decoder)
+		require.NoErrorf(t, err, "TinyGo could not decode %s", color)
+		assert.Equalf(t, guest.Color(color), decoded.Color, "TinyGo changed %s", color)
+		assert.Equalf(t, hostEncoded, decoded.ToBuffer(), "TinyGo encoded %s differently", color)
+
+		var actual module.Enums
+		require.NoError(t, msgpack.Unmarshal(hostEncoded, &actual))
+		assert.Equalf(t, enums, actual, "host changed %s", color)
+	}
+}
+
+// TestEnums checks that each guest echoes enum values, known or not, with
+// the same encoding as the host.
+func TestEnums(t *testing.T) {
+	ctx := context.Background()
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			wapcInstance := instantiate(t, lang.guest)
+			defer wapcInstance.Close()
+			m := module.New(wapcInstance)
+
+			for _, color := range enumValues {
+				enums := newEnums(color)
+				actual, err := m.TestEnums(ctx, enums)
+				if assert.NoErrorf(t, err, "could not invoke testEnums with %s", color) {
+					assert.Equalf(t, enums, actual, "%s was not preserved", color)
+				}
+
+				payload, err := msgpack.Marshal(&enums)
+				require.NoError(t, err)
+				response, err := wapcInstance.Invoke(ctx, "testEnums", payload)
+				if assert.NoError(t, err) {
+					assert.Equalf(t, payload, response, "%s was encoded differently", color)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package module_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	tinygomsgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/module"
+	"github.com/wapc/language-tests/pkg/modulev2"
+	guest "github.com/wapc/language-tests/tinygo/module"
+	guestv2 "github.com/wapc/language-tests/tinygo/v2/module"
+)
+
+// languagesV2 are the guests built from schema.v2.widl.
+var languagesV2 = []language{
+	{"TinyGo", "tinygo-v2"},
+	{"AssemblyScript", "assemblyscript-v2"},
+	{"Rust", "rust-v2"},
+}
+
+func newTestsV2() modulev2.Tests {
+	return modulev2.Tests{
+		Required: modulev2.Required{
+			AddedValue:  "added",
+			BoolValue:   true,
+			U8Value:     math.MaxUint8,
+			U16Value:    math.MaxUint16,
+			U32Value:    math.MaxUint32,
+			U64Value:    math.MaxUint64,
+			S8Value:     math.MinInt8,
+			S16Value:    math.MinInt16,
+			S32Value:    math.MinInt32,
+			S64Value:    math.MinInt64,
+			F32Value:    math.MaxFloat32,
+			F64Value:    math.MaxFloat64,
+			StringValue: "test",
+			ObjectValue: modulev2.Thing{
+				Value: "test",
+				Label: "added",
+			},
+		},
+		Optional: modulev2.Optional{
+			U8Value:     pointer.ToUint8(math.MaxUint8),
+			U16Value:    pointer.ToUint16(math.MaxUint16),
+			U32Value:    pointer.ToUint32(math.MaxUint32),
+			U64Value:    pointer.ToUint64(math.MaxUint64),
+			S8Value:     pointer.ToInt8(math.MinInt8),
+			S16Value:    pointer.ToInt16(math.MinInt16),
+			S32Value:    pointer.ToInt32(math.MinInt32),
+			S64Value:    pointer.ToInt64(math.MinInt64),
+			F32Value:    pointer.ToFloat32(math.MaxFloat32),
+			F64Value:    pointer.ToFloat64(math.MaxFloat64),
+			StringValue: pointer.ToString("test"),
+			BytesValue:  []byte("test"),
+			ObjectValue: &modulev2.Thing{
+				Value: "test",
+				Label: "added",
+			},
+		},
+		Maps: modulev2.Maps{
+			MapStringPrimative: map[uint32]string{
+				1234: "test",
+			},
+			MapU64Primative: map[uint32]uint64{
+				5678: 01234,
+			},
+		},
+		Lists: modulev2.Lists{
+			ListStrings:         []string{"test"},
+			ListObjects:         []modulev2.Thing{{Value: "test", Label: "added"}},
+			ListObjectsOptional: []*modulev2.Thing{{Value: "test", Label: "added"}},
+			ListAdded:           []string{"added"},
+		},
+		Added: &modulev2.Thing{
+			Value: "added",
+		},
+	}
+}
+
+// expectedFromV1 is what a version 2 host sees after a version 1 guest has
+// echoed `tests`: fields added in version 2 are skipped by the guest and
+// come back as zero values.
+func expectedFromV1(tests modulev2.Tests) modulev2.Tests {
+	tests.Added = nil
+	tests.Required.AddedValue = ""
+	tests.Required.ObjectValue.Label = ""
+	tests.Optional.ObjectValue = &modulev2.Thing{Value: tests.Optional.ObjectValue.Value}
+	tests.Lists.ListAdded = nil
+	tests.Lists.ListObjects = []modulev2.Thing{{Value: tests.Lists.ListObjects[0].Value}}
+	tests.Lists.ListObjectsOptional = []*modulev2.Thing{{Value: tests.Lists.ListObjectsOptional[0].Value}}
+	return tests
+}
+
+// expectedFromV2 is what a version 1 host sees after a version 2 guest has
+// echoed `tests`: fields removed in version 2 come back as zero values.
+func expectedFromV2(tests module.Tests) module.Tests {
+	tests.Required.BytesValue = nil
+	tests.Lists.ListU64s = nil
+	return tests
+}
+
+func TestSchemaEvolution(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("v2 host to v1 guest", func(t *testing.T) {
+		for _, lang := range languages {
+			lang := lang
+			t.Run(lang.name, func(t *testing.T) {
+				requireFresh(t, lang.guest)
+				wapcInstance := instantiate(t, lang.guest)
+				defer wapcInstance.Close()
+				m := modulev2.New(wapcInstance)
+
+				tests := newTestsV2()
+				expected := expectedFromV1(tests)
+				actual, err := m.TestUnary(ctx, tests)
+				require.NoError(t, err, "could not invoke testUnary")
+				assertTestsV2(t, expected, actual)
+
+				actual, err = m.TestFunction(ctx, tests.Required, tests.Optional, tests.Maps, tests.Lists)
+				require.NoError(t, err, "could not invoke testFunction")
+				assertTestsV2(t, expected, actual)
+			})
+		}
+	})
+
+	t.Run("v1 host to v2 guest", func(t *testing.T) {
+		for _, lang := range languagesV2 {
+			lang := lang
+			t.Run(lang.name, func(t *testing.T) {
+				requireFresh(t, lang.guest)
+				wapcInstance := instantiate(t, lang.guest)
+				defer wapcInstance.Close()
+				m := module.New(wapcInstance)
+
+				tests := newTests()
+				expected := expectedFromV2(tests)
+				actual, err := m.TestUnary(ctx, tests)
+				require.NoError(t, err, "could not invoke testUnary")
+				assertTests(t, expected, actual)
+
+				actual, err = m.TestFunction(ctx, tests.Required, tests.Optional, tests.Maps, tests.Lists)
+				require.NoError(t, err, "could not invoke testFunction")
+				assertTests(t, expected, actual)
+			})
+		}
+	})
+
+	// The generated TinyGo code also compiles with Go, so its decoders can
+	// be checked without building a guest.
+	t.Run("v2 host to TinyGo v1 decoder", func(t *testing.T) {
+		tests := newTestsV2()
+		payload, err := msgpack.Marshal(&tests)
+		require.NoError(t, err)
+		decoder := tinygomsgpack.NewDecoder(payload)
+		decoded, err := guest.DecodeTests(&decoder)
+		require.NoError(t, err)
+
+		var actual modulev2.Tests
+		require.NoError(t, msgpack.Unmarshal(decoded.ToBuffer(), &actual))
+		assertTestsV2(t, expectedFromV1(tests), actual)
+	})
+
+	t.Run("v1 host to TinyGo v2 decoder", func(t *testing.T) {
+		tests := newTests()
+		payload, err := msgpack.Marshal(&tests)
+		require.NoError(t, err)
+		decoder := tinygomsgpack.NewDecoder(payload)
+		decoded, err := guestv2.DecodeTests(&decoder)
+		require.NoError(t, err)
+
+		var actual module.Tests
+		require.NoError(t, msgpack.Unmarshal(decoded.ToBuffer(), &actual))
+		assertTests(t, expectedFromV2(tests), actual)
+	})
+}
+
+func assertTests(t *testing.T, expected, actual module.Tests) {
+	t.Helper()
+	normalizeTests(&expected)
+	normalizeTests(&actual)
+	assert.Equal(t, expected.Required, actual.Required, "mismatch with required fields")
+	assert.Equal(t, expected.Optional, actual.Optional, "mismatch with optional fields")
+	assert.Equal(t, expected.Maps, actual.Maps, "mismatch with map fields")
+	assert.Equal(t, expected.Lists, actual.Lists, "mismatch with list fields")
+}
+
+func assertTestsV2(t *testing.T, expected, actual modulev2.Tests) {
+	t.Helper()
+	if len(expected.Lists.ListAdded) == 0 && len(actual.Lists.ListAdded) == 0 {
+		expected.Lists.ListAdded, actual.Lists.ListAdded = nil, nil
+	}
+	assert.Equal(t, expected, actual)
+}
+
+// normalizeTests treats empty and nil bytes and lists as equal since
+// languages differ in which one they produce for a missing field.
+func normalizeTests(tests *module.Tests) {
+	if len(tests.Required.BytesValue) == 0 {
+		tests.Required.BytesValue = nil
+	}
+	if len(tests.Lists.ListU64s) == 0 {
+		tests.Lists.ListU64s = nil
+	}
+}
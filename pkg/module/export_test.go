@@ -0,0 +1,4 @@
+package module
+
+// RequireFieldsIn exposes requireFields to the tests.
+var RequireFieldsIn = requireFields
@@ -0,0 +1,76 @@
+package module_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wapc/language-tests/pkg/fault"
+	"github.com/wapc/language-tests/pkg/module"
+)
+
+// echoHost implements the host side of the tests namespace by echoing the
+// payload of testUnary.
+func echoHost(ctx context.Context, binding, namespace, operation string, payload []byte) ([]byte, error) {
+	if namespace == "tests" && operation == "testUnary" {
+		return payload, nil
+	}
+	return nil, errors.New("unimplemented")
+}
+
+func TestHostCallFaults(t *testing.T) {
+	faults := []struct {
+		name  string
+		fault fault.Fault
+	}{
+		{"error", fault.Error(errors.New("injected host failure"))},
+		{"truncated", fault.Truncate()},
+		{"wrong type", fault.WrongType()},
+		{"oversized", fault.Oversize(4 << 20)},
+		{"empty", fault.Empty()},
+	}
+
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			injector := fault.New(echoHost)
+			wapcModule, err := getModule(lang.guest, injector.HostCall)
+			require.NoError(t, err, "could load Wasm module")
+			defer wapcModule.Close()
+			wapcInstance, err := wapcModule.Instantiate()
+			require.NoError(t, err, "could instantiate module")
+			defer wapcInstance.Close()
+			m := module.New(wapcInstance)
+			ctx := context.Background()
+			tests := module.Tests{
+				Required: module.Required{
+					StringValue: "test",
+					ObjectValue: module.Thing{Value: "test"},
+				},
+			}
+
+			actual, err := m.TestHostCall(ctx, tests)
+			require.NoError(t, err, "could not invoke testHostCall")
+			assert.Equal(t, tests.Required, actual.Required, "host response was not returned")
+
+			for _, f := range faults {
+				injector.Inject("testUnary", f.fault)
+				actual, err = m.TestHostCall(ctx, tests)
+				// wapc-go v0.2.1 drops the error text of a guest that returns
+				// an error, so only the failure can be checked.
+				assert.Errorf(t, err, "%s host response should fail, got %+v", f.name, actual)
+				injector.Clear("testUnary")
+
+				// The instance must still be usable after the failure.
+				actual, err = m.TestHostCall(ctx, tests)
+				if assert.NoErrorf(t, err, "instance unusable after %s host response", f.name) {
+					assert.Equal(t, tests.Required, actual.Required)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package module_test
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+)
+
+// raw builds MsgPack byte by byte so tests can produce encodings that the
+// host encoder never would.
+type raw []byte
+
+func (r raw) mapSize(n uint32) raw {
+	switch {
+	case n < 16:
+		return append(r, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return append(r, 0xde, byte(n>>8), byte(n))
+	default:
+		return append(r, 0xdf, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+}
+
+func (r raw) arraySize(n uint32) raw {
+	switch {
+	case n < 16:
+		return append(r, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return append(r, 0xdc, byte(n>>8), byte(n))
+	default:
+		return append(r, 0xdd, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+}
+
+func (r raw) str(s string) raw {
+	if len(s) < 32 {
+		r = append(r, 0xa0|byte(len(s)))
+	} else {
+		r = append(r, 0xdb, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(r[len(r)-4:], uint32(len(s)))
+	}
+	return append(r, s...)
+}
+
+// code appends a type code followed by a big endian value `size` bytes wide.
+func (r raw) code(c byte, value uint64, size int) raw {
+	r = append(r, c)
+	for i := size - 1; i >= 0; i-- {
+		r = append(r, byte(value>>(uint(i)*8)))
+	}
+	return r
+}
+
+func (r raw) bytes(b ...byte) raw {
+	return append(r, b...)
+}
+
+func (r raw) value(t *testing.T, v interface{}) raw {
+	t.Helper()
+	encoded, err := msgpack.Marshal(v)
+	require.NoError(t, err)
+	return append(r, encoded...)
+}
+
+// testsWith encodes the `newTests` fixture with `field` of the `section`
+// object replaced by the already encoded `value`.
+func testsWith(t *testing.T, section, field string, value raw) raw {
+	t.Helper()
+	tests := newTests()
+	encoded, err := msgpack.Marshal(&tests)
+	require.NoError(t, err)
+	var sections map[string]map[string]interface{}
+	require.NoError(t, msgpack.Unmarshal(encoded, &sections))
+
+	r := raw{}.mapSize(uint32(len(sections)))
+	for _, name := range sortedKeys(sections) {
+		fields := sections[name]
+		if name == section {
+			if _, ok := fields[field]; !ok {
+				fields[field] = nil
+			}
+		}
+		r = r.str(name).mapSize(uint32(len(fields)))
+		for _, f := range sortedKeys(fields) {
+			r = r.str(f)
+			if name == section && f == field {
+				r = r.bytes(value...)
+			} else {
+				r = r.value(t, fields[f])
+			}
+		}
+	}
+	return r
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	sorted := make([]string, len(keys))
+	for i, k := range keys {
+		sorted[i] = k.String()
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func requiredField(t *testing.T, field string, value raw) raw {
+	t.Helper()
+	return testsWith(t, "required", field, value)
+}
+
+type outcome string
+
+const (
+	accepted outcome = "accepted"
+	rejected outcome = "error"
+	// trapped is a guest that panicked or hit a Wasm trap instead of
+	// returning an error.
+	trapped outcome = "trap"
+)
+
+// classify returns the outcome of an Invoke. wapc-go v0.2.1 drops the text a
+// guest passes to __guest_error when it returns an error, and reports `call
+// to "<operation>" was unsuccessful` instead. It wraps a trap as "error
+// invoking guest", unless the guest called __guest_error before trapping, as
+// the abort of AssemblyScript does, in which case it reports that text.
+func classify(err error) outcome {
+	switch {
+	case err == nil:
+		return accepted
+	case strings.HasPrefix(err.Error(), "error invoking guest"):
+		return trapped
+	default:
+		return rejected
+	}
+}
+
+type malformedCase struct {
+	name     string
+	payload  raw
+	expected outcome
+	// operation receives the payload, or every operation taking Tests
+	// arguments if it is empty.
+	operation string
+}
+
+// malformedCases is the catalogue of hostile payloads and the policy of
+// every guest: accept the valid payloads and return an error for the others.
+// Payloads come from untrusted clients, so a guest may not zero-fill what it
+// cannot decode, and may not trap.
+func malformedCases(t *testing.T) []malformedCase {
+	tests := newTests()
+	valid, err := msgpack.Marshal(&tests)
+	require.NoError(t, err)
+
+	cases := []malformedCase{
+		{"valid payload", raw(valid), accepted, ""},
+		{"valid payload re-encoded", requiredField(t, "stringValue", raw{}.str("test")), accepted, ""},
+		{"empty payload", raw{}, rejected, ""},
+		{"nil payload", raw{0xc0}, rejected, ""},
+		{"not a map", raw{}.str("tests"), rejected, ""},
+		{"truncated map", raw(valid[:len(valid)/2]), rejected, ""},
+		{"reserved type code", raw{0xc1}, rejected, ""},
+		{"map size claims billions", raw{}.mapSize(math.MaxUint32), rejected, ""},
+		{"list size claims billions", testsWith(t, "lists", "listStrings", raw{}.arraySize(math.MaxUint32)), rejected, ""},
+		{"string length beyond payload", requiredField(t, "stringValue", raw{}.code(0xdb, math.MaxUint32, 4)), rejected, ""},
+		{"bytes length beyond payload", requiredField(t, "bytesValue", raw{}.code(0xc6, math.MaxUint32, 4)), rejected, ""},
+		{"invalid UTF-8 string", requiredField(t, "stringValue", raw{}.bytes(0xa2, 0xff, 0xfe)), rejected, ""},
+		{"negative u64Value", requiredField(t, "u64Value", raw{}.bytes(0xff)), rejected, ""},
+		{"overflowing u8Value", requiredField(t, "u8Value", raw{}.code(0xcd, 256, 2)), rejected, ""},
+		{"overflowing s8Value", requiredField(t, "s8Value", raw{}.code(0xd1, math.MaxInt16, 2)), rejected, ""},
+	}
+
+	// Deeply nested arrays in an unknown field, beyond any sane depth limit.
+	var nested raw
+	for i := 0; i < 100000; i++ {
+		nested = nested.arraySize(1)
+	}
+	nested = nested.bytes(0xc0)
+	cases = append(cases, malformedCase{
+		name:     "deeply nested arrays",
+		payload:  testsWith(t, "required", "unknown", nested),
+		expected: rejected,
+	})
+
+	// Trees nesting nodes through Node.next beyond the MaxDepth of the
+	// guests, which would otherwise overflow the stack.
+	var node raw
+	for i := 0; i < 100000; i++ {
+		node = node.mapSize(1).str("next")
+	}
+	node = node.mapSize(0)
+	cases = append(cases, malformedCase{
+		name:      "deeply nested nodes",
+		payload:   raw{}.mapSize(1).str("node").bytes(node...),
+		expected:  rejected,
+		operation: "testRecursion",
+	})
+
+	// A value of the wrong type for each required field.
+	wrongTypes := []struct {
+		field string
+		value raw
+	}{
+		{"boolValue", raw{}.str("true")},
+		{"u8Value", raw{}.str("1")},
+		{"u16Value", raw{}.str("1")},
+		{"u32Value", raw{}.str("1")},
+		{"u64Value", raw{}.str("1")},
+		{"s8Value", raw{}.str("1")},
+		{"s16Value", raw{}.str("1")},
+		{"s32Value", raw{}.str("1")},
+		{"s64Value", raw{}.str("1")},
+		{"f32Value", raw{}.str("1")},
+		{"f64Value", raw{}.str("1")},
+		{"stringValue", raw{}.bytes(0x01)},
+		{"bytesValue", raw{}.bytes(0xc3)},
+		{"objectValue", raw{}.str("thing")},
+	}
+	for _, wt := range wrongTypes {
+		cases = append(cases, malformedCase{
+			name:     "wrong type for " + wt.field,
+			payload:  requiredField(t, wt.field, wt.value),
+			expected: rejected,
+		})
+	}
+
+	return cases
+}
+
+// malformedDeviations records, by language and case name, the outcomes that
+// differ from the policy because of the MsgPack library of the guest.
+var malformedDeviations = map[string]map[string]outcome{
+	// The upstream tinygo-msgpack decoder trusts declared sizes, wraps around
+	// in its bounds checks, formats overflows in base 64, which panics,
+	// skips values recursively without a limit and does not check UTF-8.
+	"TinyGo": {
+		"list size claims billions":    trapped,
+		"string length beyond payload": trapped,
+		"bytes length beyond payload":  trapped,
+		"invalid UTF-8 string":         accepted,
+		"overflowing u8Value":          trapped,
+		"deeply nested arrays":         trapped,
+	},
+}
+
+func TestMalformedInput(t *testing.T) {
+	operations := []string{"testFunction", "testUnary", "testDecode"}
+	cases := malformedCases(t)
+
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			wapcModule := echoModule(t, lang.guest)
+			for _, c := range cases {
+				caseOperations := operations
+				if c.operation != "" {
+					caseOperations = []string{c.operation}
+				}
+				for _, operation := range caseOperations {
+					// Guests may trap, so each payload gets a new instance.
+					wapcInstance, err := wapcModule.Instantiate()
+					require.NoError(t, err, "could instantiate module")
+					_, err = wapcInstance.Invoke(context.Background(), operation, c.payload)
+					wapcInstance.Close()
+					actual := classify(err)
+					expected := c.expected
+					if deviation, ok := malformedDeviations[lang.name][c.name]; ok {
+						expected = deviation
+					}
+					if actual == trapped && expected != trapped {
+						t.Errorf("%s with %s trapped: %v", operation, c.name, err)
+						continue
+					}
+					assert.Equalf(t, expected, actual, "%s with %s (error: %v)", operation, c.name, err)
+				}
+			}
+		})
+	}
+}
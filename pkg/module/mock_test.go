@@ -0,0 +1,76 @@
+package module_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wapc/language-tests/pkg/mock"
+	"github.com/wapc/language-tests/pkg/module"
+	guest "github.com/wapc/language-tests/tinygo/module"
+)
+
+// mockGuest instantiates `guest` with `host` handling its host calls.
+func mockGuest(t *testing.T, guest string, host *mock.Host) *module.Module {
+	t.Helper()
+	wapcModule, err := getModule(guest, host.HostCall)
+	require.NoError(t, err, "could load Wasm module")
+	t.Cleanup(func() { wapcModule.Close() })
+	wapcInstance, err := wapcModule.Instantiate()
+	require.NoError(t, err, "could instantiate module")
+	t.Cleanup(func() { wapcInstance.Close() })
+	return module.New(wapcInstance)
+}
+
+// TestMockHost checks the host calls of the guests against scripted
+// responses, errors and delays.
+func TestMockHost(t *testing.T) {
+	setArgs := guest.StorageSetArgs{Key: "key", Value: "value"}
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			ctx := context.Background()
+
+			// The calls are made in order, with the expected payloads.
+			host := mock.NewHost()
+			host.Expect("", "tests.storage", "set", setArgs.ToBuffer()).After(20 * time.Millisecond)
+			host.Expect("", "tests.log", "write", marshal(t, "key=value")).Return(marshal(t, uint32(7)))
+			host.Expect("", "tests.storage", "get", marshal(t, "key")).Return(marshal(t, "stored"))
+			host.Expect("", "tests.log", "get", marshal(t, uint32(7))).Return(marshal(t, nil))
+			m := mockGuest(t, lang.guest, host)
+			start := time.Now()
+			results, err := m.TestNamespaces(ctx, "key", "value")
+			require.NoError(t, err, "could not invoke testNamespaces")
+			assert.True(t, time.Since(start) >= 20*time.Millisecond, "the response was not delayed")
+			assert.Equal(t, module.NamespaceResults{Stored: pointer.ToString("stored"), Index: 7}, results)
+			host.AssertExpectations(t)
+
+			// An error of the host stops the guest, which makes no other call.
+			host = mock.NewHost()
+			host.Expect("", "tests.storage", "set", setArgs.ToBuffer())
+			host.Expect("", "tests.log", "write", nil).ReturnError(errors.New("log full"))
+			m = mockGuest(t, lang.guest, host)
+			_, err = m.TestNamespaces(ctx, "key", "value")
+			// wapc-go v0.2.1 drops the error text of the guest.
+			assert.Error(t, err, "host error was not returned")
+			host.AssertExpectations(t)
+			assert.Len(t, host.Calls(), 2)
+
+			// A host call outliving the invocation fails with its context.
+			host = mock.NewHost()
+			host.Expect("", "tests.storage", "set", nil).After(time.Minute)
+			m = mockGuest(t, lang.guest, host)
+			timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			defer cancel()
+			_, err = m.TestNamespaces(timeout, "key", "value")
+			assert.Error(t, err, "the delayed host call did not fail")
+			assert.Len(t, host.Calls(), 1)
+		})
+	}
+}
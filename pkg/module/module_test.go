@@ -2,7 +2,7 @@ package module_test
 
 import (
 	"context"
-	"io/ioutil"
+	"errors"
 	"math"
 	"strings"
 	"testing"
@@ -12,23 +12,39 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/wapc/wapc-go"
 
+	"github.com/wapc/language-tests/build"
+	"github.com/wapc/language-tests/pkg/mock"
 	"github.com/wapc/language-tests/pkg/module"
 )
 
+// language is a guest, by its name in the build package.
+type language struct {
+	name  string
+	guest string
+}
+
+var languages = []language{
+	{"TinyGo", "tinygo"},
+	{"AssemblyScript", "assemblyscript"},
+	{"Rust", "rust"},
+}
+
 func TestTinyGo(t *testing.T) {
-	testLanguage(t, "../../build/tinygo.wasm")
+	testLanguage(t, "tinygo")
 }
 
 func TestAssemblyScript(t *testing.T) {
-	testLanguage(t, "../../build/assemblyscript.wasm")
+	testLanguage(t, "assemblyscript")
 }
 
 func TestRust(t *testing.T) {
-	testLanguage(t, "../../build/rust.wasm")
+	testLanguage(t, "rust")
 }
 
-func testLanguage(t *testing.T, wasmFile string) {
-	wapcModule, err := getModule(wasmFile)
+func testLanguage(t *testing.T, guest string) {
+	// None of the tested operations call the host.
+	host := mock.NewHost()
+	wapcModule, err := getModule(guest, host.HostCall)
 	require.NoError(t, err, "could load Wasm module")
 	defer wapcModule.Close()
 	wapcInstance, err := wapcModule.Instantiate()
@@ -36,16 +52,27 @@ func testLanguage(t *testing.T, wasmFile string) {
 	m := module.New(wapcInstance)
 	testEcho(t, m)
 	testDecode(t, m)
+	host.AssertExpectations(t)
 }
 
-func getModule(wasmFile string) (*wapc.Module, error) {
-	code, err := ioutil.ReadFile(wasmFile)
+// guestCode returns the code of `guest`, skipping the test if the guest has
+// not been built.
+func guestCode(t *testing.T, guest string) []byte {
+	t.Helper()
+	g, err := build.Get(guest)
+	if errors.Is(err, build.ErrNotBuilt) {
+		t.Skipf("%s has not been built; build it with build.sh", guest)
+	}
+	require.NoError(t, err)
+	return g.Code
+}
+
+func getModule(guest string, hostCallHandler wapc.HostCallHandler) (*wapc.Module, error) {
+	g, err := build.Get(guest)
 	if err != nil {
 		return nil, err
 	}
-	wapcModule, err := wapc.New(code, func(ctx context.Context, binding, namespace, operation string, payload []byte) ([]byte, error) {
-		return []byte("unimplemented"), nil
-	})
+	wapcModule, err := wapc.New(g.Code, hostCallHandler)
 	if err != nil {
 		return nil, err
 	}
@@ -55,9 +82,36 @@ func getModule(wasmFile string) (*wapc.Module, error) {
 	return wapcModule, nil
 }
 
-func testEcho(t *testing.T, m *module.Module) {
-	ctx := context.Background()
-	expected := module.Tests{
+// echoModules compiles each guest once for the tests using echoHost, and
+// serves the streams of their streaming operations.
+var echoModules = newEchoModules()
+
+func newEchoModules() *module.Cache {
+	c := module.NewCache(module.HandleStreams(echoHost))
+	c.SetLogger(wapc.Println)
+	c.SetWriter(wapc.Print)
+	return c
+}
+
+// echoModule returns the module of `guest` using echoHost. The module is
+// closed after the tests.
+func echoModule(t *testing.T, guest string) *wapc.Module {
+	t.Helper()
+	wapcModule, err := echoModules.Module(guestCode(t, guest))
+	require.NoError(t, err, "could load Wasm module")
+	return wapcModule
+}
+
+// instantiate creates an instance of `guest` using echoHost.
+func instantiate(t *testing.T, guest string) *wapc.Instance {
+	t.Helper()
+	wapcInstance, err := echoModules.Instantiate(guestCode(t, guest))
+	require.NoError(t, err, "could instantiate module")
+	return wapcInstance
+}
+
+func newTests() module.Tests {
+	return module.Tests{
 		Required: module.Required{
 			BoolValue:   true,
 			U8Value:     math.MaxUint8,
@@ -108,6 +162,11 @@ func testEcho(t *testing.T, m *module.Module) {
 			ListObjectsOptional: []*module.Thing{{Value: "test"}},
 		},
 	}
+}
+
+func testEcho(t *testing.T, m *module.Module) {
+	ctx := context.Background()
+	expected := newTests()
 	actual, err := m.TestFunction(ctx, expected.Required, expected.Optional, expected.Maps, expected.Lists)
 	require.NoError(t, err, "could not invoke testFunction")
 
@@ -127,57 +186,7 @@ func testEcho(t *testing.T, m *module.Module) {
 
 func testDecode(t *testing.T, m *module.Module) {
 	ctx := context.Background()
-	expected := module.Tests{
-		Required: module.Required{
-			BoolValue:   true,
-			U8Value:     math.MaxUint8,
-			U16Value:    math.MaxUint16,
-			U32Value:    math.MaxUint32,
-			U64Value:    math.MaxUint64,
-			S8Value:     math.MinInt8,
-			S16Value:    math.MinInt16,
-			S32Value:    math.MinInt32,
-			S64Value:    math.MinInt64,
-			F32Value:    math.MaxFloat32,
-			F64Value:    math.MaxFloat64,
-			StringValue: "test",
-			BytesValue:  []byte("test"),
-			ObjectValue: module.Thing{
-				Value: "test",
-			},
-		},
-		Optional: module.Optional{
-			U8Value:     pointer.ToUint8(math.MaxUint8),
-			U16Value:    pointer.ToUint16(math.MaxUint16),
-			U32Value:    pointer.ToUint32(math.MaxUint32),
-			U64Value:    pointer.ToUint64(math.MaxUint64),
-			S8Value:     pointer.ToInt8(math.MinInt8),
-			S16Value:    pointer.ToInt16(math.MinInt16),
-			S32Value:    pointer.ToInt32(math.MinInt32),
-			S64Value:    pointer.ToInt64(math.MinInt64),
-			F32Value:    pointer.ToFloat32(math.MaxFloat32),
-			F64Value:    pointer.ToFloat64(math.MaxFloat64),
-			StringValue: pointer.ToString("test"),
-			BytesValue:  []byte("test"),
-			ObjectValue: &module.Thing{
-				Value: "test",
-			},
-		},
-		Maps: module.Maps{
-			MapStringPrimative: map[uint32]string{
-				1234: "test",
-			},
-			MapU64Primative: map[uint32]uint64{
-				5678: 01234,
-			},
-		},
-		Lists: module.Lists{
-			ListStrings:         []string{"test"},
-			ListU64s:            []uint64{1234},
-			ListObjects:         []module.Thing{{Value: "test"}},
-			ListObjectsOptional: []*module.Thing{{Value: "test"}},
-		},
-	}
+	expected := newTests()
 	actual, err := m.TestDecode(ctx, expected)
 	require.NoError(t, err, "could not invoke testFunction")
 
@@ -0,0 +1,139 @@
+package module_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	tinygomsgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/module"
+	guest "github.com/wapc/language-tests/tinygo/module"
+)
+
+type memoryStorage map[string]string
+
+func (s memoryStorage) Get(ctx context.Context, key string) (*string, error) {
+	if value, ok := s[key]; ok {
+		return &value, nil
+	}
+	return nil, nil
+}
+
+func (s memoryStorage) Set(ctx context.Context, key string, value string) error {
+	if key == "" {
+		return errors.New("empty key")
+	}
+	s[key] = value
+	return nil
+}
+
+type memoryLog []string
+
+func (l *memoryLog) Get(ctx context.Context, index uint32) (*string, error) {
+	if int(index) < len(*l) {
+		return &(*l)[index], nil
+	}
+	return nil, nil
+}
+
+func (l *memoryLog) Write(ctx context.Context, message string) (uint32, error) {
+	*l = append(*l, message)
+	return uint32(len(*l) - 1), nil
+}
+
+func marshal(t *testing.T, v interface{}) []byte {
+	payload, err := msgpack.Marshal(v)
+	require.NoError(t, err)
+	return payload
+}
+
+// TestRouter checks that host calls reach the implementation of their
+// namespace, including operations with the same name in two namespaces.
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	storage := memoryStorage{"key": "stored"}
+	log := &memoryLog{"logged"}
+	router := module.Router{Storage: storage, Log: log}
+
+	response, err := router.HostCall(ctx, "", "tests.storage", "get", marshal(t, "key"))
+	require.NoError(t, err)
+	assert.Equal(t, marshal(t, "stored"), response)
+	response, err = router.HostCall(ctx, "", "tests.log", "get", marshal(t, uint32(0)))
+	require.NoError(t, err)
+	assert.Equal(t, marshal(t, "logged"), response)
+	response, err = router.HostCall(ctx, "", "tests.storage", "get", marshal(t, "missing"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xc0}, response)
+
+	// The TinyGo bindings encode the arguments the router decodes.
+	args := guest.StorageSetArgs{Key: "new", Value: "value"}
+	response, err = router.HostCall(ctx, "", "tests.storage", "set", args.ToBuffer())
+	require.NoError(t, err)
+	assert.Empty(t, response)
+	assert.Equal(t, "value", storage["new"])
+	_, err = router.HostCall(ctx, "", "tests.storage", "set", marshal(t, map[string]string{"value": "value"}))
+	assert.EqualError(t, err, "empty key", "implementation error was not returned")
+
+	response, err = router.HostCall(ctx, "", "tests.log", "write", marshal(t, "message"))
+	require.NoError(t, err)
+	decoder := tinygomsgpack.NewDecoder(response)
+	index, err := decoder.ReadUint32()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), index)
+	assert.Equal(t, memoryLog{"logged", "message"}, *log)
+
+	_, err = router.HostCall(ctx, "", "tests.log", "set", nil)
+	assert.Equal(t, &module.UnknownOperationError{Namespace: "tests.log", Operation: "set"}, err)
+	_, err = router.HostCall(ctx, "", "tests", "testUnary", nil)
+	assert.Equal(t, &module.UnknownOperationError{Namespace: "tests", Operation: "testUnary"}, err)
+	_, err = router.HostCall(ctx, "", "tests.storage", "get", []byte{0xc1})
+	assert.Error(t, err, "invalid payload was decoded")
+
+	// Other namespaces, and namespaces without an implementation, go to the
+	// fallback.
+	router = module.Router{Storage: storage, Fallback: echoHost}
+	response, err = router.HostCall(ctx, "", "tests", "testUnary", []byte{0x80})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x80}, response)
+	_, err = router.HostCall(ctx, "", "tests.log", "get", marshal(t, uint32(0)))
+	assert.EqualError(t, err, "unimplemented")
+}
+
+func TestNamespaces(t *testing.T) {
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			storage := memoryStorage{}
+			log := &memoryLog{"first"}
+			router := module.Router{Storage: storage, Log: log, Fallback: echoHost}
+			wapcModule, err := getModule(lang.guest, router.HostCall)
+			require.NoError(t, err, "could load Wasm module")
+			defer wapcModule.Close()
+			wapcInstance, err := wapcModule.Instantiate()
+			require.NoError(t, err, "could instantiate module")
+			defer wapcInstance.Close()
+			m := module.New(wapcInstance)
+
+			results, err := m.TestNamespaces(context.Background(), "key", "value")
+			require.NoError(t, err, "could not invoke testNamespaces")
+			assert.Equal(t, module.NamespaceResults{
+				Stored: pointer.ToString("value"),
+				Logged: pointer.ToString("key=value"),
+				Index:  1,
+			}, results)
+			assert.Equal(t, memoryStorage{"key": "value"}, storage)
+			assert.Equal(t, memoryLog{"first", "key=value"}, *log)
+
+			// wapc-go v0.2.1 drops the error text of the guest, so the
+			// error of the host cannot be told apart from other failures.
+			_, err = m.TestNamespaces(context.Background(), "", "value")
+			assert.Error(t, err, "host error was not returned")
+		})
+	}
+}
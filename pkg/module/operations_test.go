@@ -0,0 +1,98 @@
+package module_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+
+	"github.com/wapc/language-tests/pkg/module"
+)
+
+// echoOperations are the unary operations that echo a primitive, with the
+// values to send them.
+var echoOperations = map[string][]interface{}{
+	"testUnaryString": {"", "test", "héllo wörld"},
+	"testUnaryU64":    {uint64(0), uint64(127), uint64(math.MaxUint64)},
+	"testUnaryBool":   {true, false},
+	"testUnaryBytes":  {[]byte{}, []byte{0, 1, 255}},
+}
+
+// TestOperations checks operations without arguments, without a return value
+// and with primitive, list, map and optional arguments and returns.
+func TestOperations(t *testing.T) {
+	ctx := context.Background()
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			wapcInstance := instantiate(t, lang.guest)
+			defer wapcInstance.Close()
+			m := module.New(wapcInstance)
+
+			actual, err := m.TestNoArgs(ctx)
+			require.NoError(t, err, "could not invoke testNoArgs")
+			assert.Equal(t, "ok", actual)
+			// Operations without arguments do not read the payload.
+			response, err := wapcInstance.Invoke(ctx, "testNoArgs", []byte{0xc1})
+			require.NoError(t, err, "testNoArgs read the payload")
+			assert.Equal(t, []byte{0xa2, 'o', 'k'}, response)
+
+			require.NoError(t, m.TestNoArgsVoid(ctx), "could not invoke testNoArgsVoid")
+			response, err = wapcInstance.Invoke(ctx, "testNoArgsVoid", []byte{})
+			require.NoError(t, err)
+			assert.Empty(t, response, "void operation returned a payload")
+
+			assert.NoError(t, m.TestVoid(ctx, "test"), "could not invoke testVoid")
+			// wapc-go v0.2.1 drops the error text of the guest.
+			assert.Error(t, m.TestVoid(ctx, "error"), "void operation did not fail")
+
+			for operation, values := range echoOperations {
+				for _, value := range values {
+					payload, err := msgpack.Marshal(value)
+					require.NoError(t, err)
+					response, err := wapcInstance.Invoke(ctx, operation, payload)
+					if assert.NoErrorf(t, err, "could not invoke %s with %v", operation, value) {
+						assert.Equalf(t, payload, response, "%s changed %v", operation, value)
+					}
+				}
+			}
+			s, err := m.TestUnaryString(ctx, "test")
+			assert.NoError(t, err)
+			assert.Equal(t, "test", s)
+			u, err := m.TestUnaryU64(ctx, math.MaxUint64)
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(math.MaxUint64), u)
+			b, err := m.TestUnaryBool(ctx, true)
+			assert.NoError(t, err)
+			assert.True(t, b)
+			bytes, err := m.TestUnaryBytes(ctx, []byte{1, 2, 3})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte{1, 2, 3}, bytes)
+
+			list, err := m.TestReturnList(ctx, "item", 3)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"item0", "item1", "item2"}, list)
+			list, err = m.TestReturnList(ctx, "item", 0)
+			assert.NoError(t, err)
+			assert.Empty(t, list)
+			_, err = m.TestReturnList(ctx, "item", math.MaxUint32)
+			assert.Error(t, err)
+
+			lengths, err := m.TestReturnMap(ctx, []string{"a", "bb", ""})
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]uint64{"a": 1, "bb": 2, "": 0}, lengths)
+
+			optional, err := m.TestReturnOptional(ctx, pointer.ToString("test"))
+			assert.NoError(t, err)
+			assert.Equal(t, pointer.ToString("test"), optional)
+			optional, err = m.TestReturnOptional(ctx, nil)
+			assert.NoError(t, err)
+			assert.Nil(t, optional)
+		})
+	}
+}
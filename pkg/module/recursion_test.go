@@ -0,0 +1,106 @@
+package module_test
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	tinygomsgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/module"
+	guest "github.com/wapc/language-tests/tinygo/module"
+)
+
+// recursionDepths are the nesting levels every guest must echo. The deepest
+// one nests 2000 objects through Branch and Leaf, within the MaxDepth of 4096
+// of the guests. malformedCases nests objects beyond it.
+var recursionDepths = []int{1, 10, 100, 1000}
+
+// newTrees nests `depth` levels through each recursive field: Node.next,
+// Node.children and Branch.leaves/Leaf.branch. Lists are empty rather than
+// nil since guests do not preserve the difference.
+func newTrees(depth int) module.Trees {
+	var next *module.Node
+	children := []module.Node{}
+	var branch *module.Branch
+	for i := depth; i > 0; i-- {
+		value := strconv.Itoa(i)
+		next = &module.Node{Value: value, Children: []module.Node{}, Next: next}
+		children = []module.Node{{Value: value, Children: children}}
+		branch = &module.Branch{Leaves: []module.Leaf{{Value: value, Branch: branch}}}
+	}
+	return module.Trees{
+		Node: module.Node{
+			Value:    "root",
+			Children: children,
+			Next:     next,
+		},
+		Branch: *branch,
+	}
+}
+
+func TestRecursionEncoding(t *testing.T) {
+	for _, depth := range append(recursionDepths, 10000) {
+		trees := newTrees(depth)
+		hostEncoded, err := msgpack.Marshal(&trees)
+		require.NoError(t, err)
+
+		var actual module.Trees
+		require.NoErrorf(t, msgpack.Unmarshal(hostEncoded, &actual), "host could not decode depth %d", depth)
+		reencoded, err := msgpack.Marshal(&actual)
+		require.NoError(t, err)
+		assert.Truef(t, bytes.Equal(hostEncoded, reencoded), "host changed depth %d", depth)
+
+		decoder := tinygomsgpack.NewDecoder(hostEncoded)
+		decoded, err := guest.DecodeTrees(&decoder)
+		if depth > guest.MaxDepth {
+			assert.Equalf(t, &guest.DepthError{Type: "Node"}, err, "TinyGo decoded depth %d", depth)
+			continue
+		}
+		require.NoErrorf(t, err, "TinyGo could not decode depth %d", depth)
+		assert.Truef(t, bytes.Equal(hostEncoded, decoded.ToBuffer()), "TinyGo changed depth %d", depth)
+	}
+
+	// The depth is counted down after each object, so that a payload that
+	// failed does not make the next one fail.
+	trees := newTrees(1000)
+	encoded, err := msgpack.Marshal(&trees)
+	require.NoError(t, err)
+	decoder := tinygomsgpack.NewDecoder(encoded)
+	_, err = guest.DecodeTrees(&decoder)
+	assert.NoError(t, err)
+}
+
+// TestRecursion checks that each Wasm guest echoes deeply nested recursive
+// and mutually recursive types, where a recursive Decode overflows the stack
+// of the guest rather than that of a native test. The payloads are compared
+// as bytes since a diff of thousands of nested structs is unreadable. Guests
+// built before testRecursion skip it, or fail with -strict-artifacts.
+func TestRecursion(t *testing.T) {
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			wapcModule := echoModule(t, lang.guest)
+
+			for _, depth := range recursionDepths {
+				trees := newTrees(depth)
+				payload, err := msgpack.Marshal(&trees)
+				require.NoError(t, err)
+
+				// A stack overflow traps, so each depth gets a new instance.
+				wapcInstance, err := wapcModule.Instantiate()
+				require.NoError(t, err, "could instantiate module")
+				response, err := wapcInstance.Invoke(context.Background(), "testRecursion", payload)
+				wapcInstance.Close()
+				if assert.NoErrorf(t, err, "could not echo depth %d", depth) {
+					assert.Truef(t, bytes.Equal(payload, response), "depth %d was not preserved", depth)
+				}
+			}
+		})
+	}
+}
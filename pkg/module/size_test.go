@@ -0,0 +1,35 @@
+package module_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wapc/language-tests/pkg/size"
+)
+
+// TestSizes fails when a guest grew more than size.DefaultThreshold over
+// build/sizes.json. Update the baseline with `go run ./cmd/wasmsize -update`
+// after an intended change. Stale guests are skipped, since their baseline
+// does not measure the current sources.
+func TestSizes(t *testing.T) {
+	baseline, err := size.ReadBaseline("../../build/sizes.json")
+	require.NoError(t, err)
+	for _, lang := range append(languages, languagesV2...) {
+		name := lang.guest + ".wasm"
+		t.Run(lang.guest, func(t *testing.T) {
+			code := guestCode(t, lang.guest)
+			requireFresh(t, lang.guest)
+			was, ok := baseline[name]
+			if !ok {
+				t.Skipf("%s has no baseline; add it with go run ./cmd/wasmsize -update", name)
+			}
+			s, err := size.Measure(code)
+			require.NoError(t, err)
+			t.Logf("total %d, code %d, data %d, custom %d, other %d (%s)",
+				s.Total, s.Code, s.Data, s.Custom, s.Other, size.Delta(was.Total, s.Total))
+			assert.Empty(t, size.Compare(was, s, size.DefaultThreshold))
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package module_test
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	"github.com/wapc/wapc-go"
+
+	"github.com/wapc/language-tests/pkg/module"
+	guest "github.com/wapc/language-tests/tinygo/module"
+)
+
+func things(prefix string, count int) []module.Thing {
+	var things []module.Thing
+	for i := 0; i < count; i++ {
+		things = append(things, module.Thing{Value: prefix + strconv.Itoa(i)})
+	}
+	return things
+}
+
+func summarize(label string, things []module.Thing) module.ThingSummary {
+	summary := module.ThingSummary{Label: label, Count: uint32(len(things))}
+	checksum := fnv.New32a()
+	for _, thing := range things {
+		summary.Size += uint64(len(thing.Value))
+		checksum.Write([]byte(thing.Value))
+	}
+	summary.Checksum = checksum.Sum32()
+	return summary
+}
+
+// readAll reads the items of `stream` until its end.
+func readAll(stream module.Iterator[module.Thing]) ([]module.Thing, error) {
+	var items []module.Thing
+	for stream.Next() {
+		items = append(items, stream.Item())
+	}
+	return items, stream.Err()
+}
+
+// testStreams checks the streaming operations of `m`.
+func testStreams(t *testing.T, m *module.Module) {
+	ctx := context.Background()
+	for _, count := range []int{0, 1, 7, 8, 10000} {
+		items, err := readAll(m.StreamThings(ctx, "thing", uint32(count), nil))
+		require.NoError(t, err)
+		assert.Equal(t, things("thing", count), items, "wrong items for %d things", count)
+
+		sent := things("sent", count)
+		summary, err := m.CollectThings(ctx, "label", nil, module.Items(sent...))
+		require.NoError(t, err)
+		assert.Equal(t, summarize("label", sent), summary, "wrong summary for %d things", count)
+	}
+}
+
+func TestStreamGuests(t *testing.T) {
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			wapcInstance := instantiate(t, lang.guest)
+			defer wapcInstance.Close()
+			m := module.New(wapcInstance)
+			testStreams(t, m)
+
+			// A stream closed early stops the guest, which remains usable.
+			stream := m.StreamThings(context.Background(), "thing", 10000, nil)
+			for i := 0; i < 100 && stream.Next(); i++ {
+			}
+			assert.NoError(t, stream.Close())
+			// wapc-go v0.2.1 drops the error text of the guest.
+			items, err := readAll(m.StreamThings(context.Background(), "thing", 100, pointer.ToUint32(50)))
+			assert.Error(t, err)
+			assert.Equal(t, things("thing", 50), items)
+			_, err = m.CollectThings(context.Background(), "label", pointer.ToUint32(50), module.Items(things("thing", 100)...))
+			assert.Error(t, err)
+
+			// Piping a stream into a guest that returns early stops the guest
+			// producing it.
+			producer := instantiate(t, lang.guest)
+			defer producer.Close()
+			stream = module.New(producer).StreamThings(context.Background(), "thing", 10000, nil)
+			_, err = m.CollectThings(context.Background(), "label", pointer.ToUint32(10), stream)
+			assert.Error(t, err)
+			assert.NoError(t, stream.Err())
+			assert.False(t, stream.Next())
+		})
+	}
+}
+
+// invokerFunc is an Invoker calling itself.
+type invokerFunc func(ctx context.Context, operation string, payload []byte) ([]byte, error)
+
+func (f invokerFunc) Invoke(ctx context.Context, operation string, payload []byte) ([]byte, error) {
+	return f(ctx, operation, payload)
+}
+
+// TestStreamReceiveAfterCancel checks that a guest cannot receive the frames
+// of a stream after cancelling it.
+func TestStreamReceiveAfterCancel(t *testing.T) {
+	ctx := context.Background()
+	host := module.HandleStreams(echoHost)
+	var guestErr error
+	_, err := module.SendStream[module.Void, module.Thing, module.Void](ctx, invokerFunc(func(ctx context.Context, operation string, payload []byte) ([]byte, error) {
+		if _, err := host(ctx, "", module.StreamNamespace, "cancel", []byte{}); err != nil {
+			return nil, err
+		}
+		_, guestErr = host(ctx, "", module.StreamNamespace, "receive", []byte{})
+		return msgpack.Marshal(module.Void{})
+	}), "collect", module.Void{}, module.Items(things("thing", 10)...))
+	require.NoError(t, err)
+	assert.EqualError(t, guestErr, "stream cancelled by the guest")
+}
+
+// rawGuest sends the frames of `frames` to the host, as a guest streaming
+// things.
+type rawGuest struct {
+	host   wapc.HostCallHandler
+	frames []guest.ThingFrame
+}
+
+func (g *rawGuest) Invoke(ctx context.Context, operation string, payload []byte) ([]byte, error) {
+	for _, f := range g.frames {
+		if _, err := g.host(ctx, "", guest.StreamNamespace, "send", f.ToBuffer()); err != nil {
+			return nil, err
+		}
+	}
+	return []byte{}, nil
+}
+
+func TestStreamFrames(t *testing.T) {
+	host := module.HandleStreams(echoHost)
+	thing := guest.Thing{Value: "thing"}
+	cases := []struct {
+		name   string
+		frames []guest.ThingFrame
+		items  int
+		err    error
+	}{
+		{"in order", []guest.ThingFrame{
+			{Seq: 0, Items: []guest.Thing{thing, thing}},
+			{Seq: 1},
+			{Seq: 2, Items: []guest.Thing{thing}, End: true},
+		}, 3, nil},
+		{"out of order", []guest.ThingFrame{
+			{Seq: 0, Items: []guest.Thing{thing}},
+			{Seq: 2, Items: []guest.Thing{thing}, End: true},
+		}, 1, &module.FrameOrderError{Seq: 2, Expected: 1}},
+		{"truncated", []guest.ThingFrame{
+			{Seq: 0, Items: []guest.Thing{thing}},
+		}, 1, module.ErrTruncatedStream},
+		{"after the end", []guest.ThingFrame{
+			{Seq: 0, End: true},
+			{Seq: 1, Items: []guest.Thing{thing}},
+		}, 0, errors.New("stream frame after the last one")},
+	}
+	for _, c := range cases {
+		stream := module.OpenStream[module.Void, module.Thing](context.Background(), &rawGuest{host, c.frames}, "streamThings", module.Void{})
+		items, err := readAll(stream)
+		assert.Equal(t, c.err, err, c.name)
+		assert.Len(t, items, c.items, c.name)
+	}
+
+	// The guest checks the order of the frames it receives.
+	frames := [][]byte{
+		encodeFrame(t, 0, false, module.Thing{Value: "a"}),
+		encodeFrame(t, 2, true, module.Thing{Value: "b"}),
+	}
+	reader := guest.NewThingStreamReader(func() ([]byte, error) {
+		frame := frames[0]
+		frames = frames[1:]
+		return frame, nil
+	}, func() error { return nil })
+	require.True(t, reader.Next())
+	assert.Equal(t, guest.Thing{Value: "a"}, reader.Item())
+	assert.False(t, reader.Next())
+	assert.Equal(t, &guest.FrameOrderError{Seq: 2, Expected: 1}, reader.Err())
+	assert.EqualError(t, reader.Err(), "stream frame 2 out of order, expected 1")
+}
+
+// encodeFrame encodes a frame as the host does.
+func encodeFrame(t *testing.T, seq uint32, end bool, items ...module.Thing) []byte {
+	encoded, err := msgpack.Marshal(map[string]interface{}{"seq": seq, "items": items, "end": end})
+	require.NoError(t, err)
+	return encoded
+}
+
+func TestHandleStreams(t *testing.T) {
+	ctx := context.Background()
+	host := module.HandleStreams(echoHost)
+	_, err := host(ctx, "", module.StreamNamespace, "send", []byte{})
+	assert.Equal(t, module.ErrNoStream, err)
+	_, err = (&module.Router{}).HostCall(ctx, "", module.StreamNamespace, "receive", []byte{})
+	assert.Equal(t, module.ErrNoStream, err)
+
+	// Other host calls go to the next handler.
+	payload, err := host(ctx, "", "tests", "testUnary", []byte("payload"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("payload"), payload)
+}
@@ -0,0 +1,133 @@
+package module_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	tinygomsgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/module"
+	"github.com/wapc/language-tests/tinygo/ext"
+	guest "github.com/wapc/language-tests/tinygo/module"
+)
+
+// The headers of the three timestamp formats.
+var (
+	timestamp32 = []byte{0xd6, 0xff}
+	timestamp64 = []byte{0xd7, 0xff}
+	timestamp96 = []byte{0xc7, 12, 0xff}
+)
+
+var timeCases = []struct {
+	name   string
+	time   time.Time
+	header []byte
+}{
+	{"epoch", time.Unix(0, 0), timestamp32},
+	{"whole seconds", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), timestamp32},
+	{"last 32-bit second", time.Unix(1<<32-1, 0), timestamp32},
+	{"nanoseconds", time.Date(2020, 1, 2, 3, 4, 5, 123456789, time.UTC), timestamp64},
+	{"one nanosecond", time.Unix(0, 1), timestamp64},
+	{"first second after 32 bits", time.Unix(1<<32, 0), timestamp64},
+	{"last 64-bit time", time.Unix(1<<34-1, 999999999), timestamp64},
+	{"first second after 64 bits", time.Unix(1<<34, 0), timestamp96},
+	{"one nanosecond before epoch", time.Unix(-1, 999999999), timestamp96},
+	{"pre-epoch", time.Date(1900, 6, 15, 12, 0, 0, 1, time.UTC), timestamp96},
+	{"zero time", time.Time{}, timestamp96},
+	{"far future", time.Date(9999, 12, 31, 23, 59, 59, 999999999, time.UTC), timestamp96},
+}
+
+func newTimes(tm time.Time) module.Times {
+	return module.Times{
+		Time:         tm,
+		TimeOptional: &tm,
+		Times:        []time.Time{tm, time.Unix(0, 0)},
+	}
+}
+
+// TestTimestampEncoding checks that the host and the TinyGo bindings pick the
+// same timestamp format and preserve the time to the nanosecond.
+func TestTimestampEncoding(t *testing.T) {
+	for _, c := range timeCases {
+		hostEncoded, err := msgpack.Marshal(c.time)
+		require.NoError(t, err)
+		assert.Equalf(t, c.header, hostEncoded[:len(c.header)], "host used the wrong format for %s", c.name)
+
+		var hostDecoded time.Time
+		require.NoError(t, msgpack.Unmarshal(hostEncoded, &hostDecoded))
+		assert.Truef(t, c.time.Equal(hostDecoded), "host changed %s to %s", c.name, hostDecoded)
+
+		decoder := tinygomsgpack.NewDecoder(hostEncoded)
+		decoded, err := ext.ReadTime(&decoder)
+		require.NoErrorf(t, err, "TinyGo could not read %s", c.name)
+		assert.Truef(t, c.time.Equal(decoded), "TinyGo changed %s to %s", c.name, decoded)
+
+		var sizer tinygomsgpack.Sizer
+		ext.WriteTime(&sizer, c.time)
+		buffer := make([]byte, sizer.Len())
+		encoder := tinygomsgpack.NewEncoder(buffer)
+		ext.WriteTime(&encoder, c.time)
+		assert.Equalf(t, hostEncoded, buffer, "TinyGo encoded %s differently", c.name)
+
+		times := newTimes(c.time)
+		hostEncoded, err = msgpack.Marshal(&times)
+		require.NoError(t, err)
+		decoder = tinygomsgpack.NewDecoder(hostEncoded)
+		decodedTimes, err := guest.DecodeTimes(&decoder)
+		require.NoError(t, err)
+		assert.Equalf(t, hostEncoded, decodedTimes.ToBuffer(), "TinyGo encoded Times with %s differently", c.name)
+	}
+
+	decoder := tinygomsgpack.NewDecoder([]byte{0xd5, 0xff, 0, 0})
+	_, err := ext.ReadTime(&decoder)
+	assert.Error(t, err, "16-bit timestamp was accepted")
+	decoder = tinygomsgpack.NewDecoder([]byte{0xd6, 0x01, 0, 0, 0, 0})
+	_, err = ext.ReadTime(&decoder)
+	assert.Error(t, err, "extension type 1 was read as a timestamp")
+}
+
+// timeDeviations records, by language, guests that fail on any payload
+// holding a datetime.
+var timeDeviations = map[string]bool{
+	// @wapc/as-msgpack cannot read or write extension types, so the
+	// generated Timestamp aborts the guest.
+	"AssemblyScript": true,
+}
+
+// TestTimes checks that each guest echoes timestamps of every format
+// unchanged.
+func TestTimes(t *testing.T) {
+	ctx := context.Background()
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			wapcInstance := instantiate(t, lang.guest)
+			defer wapcInstance.Close()
+			m := module.New(wapcInstance)
+
+			for _, c := range timeCases {
+				times := newTimes(c.time)
+				actual, err := m.TestTimes(ctx, times)
+				if timeDeviations[lang.name] {
+					assert.Errorf(t, err, "%s was accepted", c.name)
+					continue
+				}
+				if assert.NoErrorf(t, err, "could not invoke testTimes with %s", c.name) {
+					assert.Truef(t, c.time.Equal(actual.Time), "%s was changed to %s", c.name, actual.Time)
+				}
+
+				payload, err := msgpack.Marshal(&times)
+				require.NoError(t, err)
+				response, err := wapcInstance.Invoke(ctx, "testTimes", payload)
+				if assert.NoError(t, err) {
+					assert.Equalf(t, payload, response, "%s was encoded differently", c.name)
+				}
+			}
+		})
+	}
+}
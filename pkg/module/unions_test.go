@@ -0,0 +1,155 @@
+package module_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	tinygomsgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/module"
+	guest "github.com/wapc/language-tests/tinygo/module"
+)
+
+var shapes = map[string]module.Shape{
+	"circle": {Circle: &module.Circle{Radius: 1.5}},
+	"square": {Square: &module.Square{Side: 2}},
+}
+
+// invalidShapes hold no variant or more than one.
+var invalidShapes = map[string]module.Shape{
+	"empty": {},
+	"both":  {Circle: &module.Circle{Radius: 1.5}, Square: &module.Square{Side: 2}},
+}
+
+func newUnions(shape module.Shape) module.Unions {
+	return module.Unions{
+		Shape:         shape,
+		ShapeOptional: &shape,
+		Shapes:        []module.Shape{shape, {Square: &module.Square{Side: 3}}},
+	}
+}
+
+var circle = raw{}.str("Circle").mapSize(1).str("radius").code(0xcb, math.Float64bits(1.5), 8)
+
+// invalidPayloads are encoded shapes that hold no variant of the schema or
+// more than one, and the variants the error reports.
+var invalidPayloads = map[string]struct {
+	payload  raw
+	variants []string
+}{
+	"empty":   {raw{}.mapSize(0), nil},
+	"nil":     {raw{}.mapSize(1).str("Circle").code(0xc0, 0, 0), nil},
+	"both":    {raw{}.mapSize(2).bytes(circle...).str("Square").mapSize(1).str("side").code(0xcb, math.Float64bits(2), 8), []string{"Circle", "Square"}},
+	"unknown": {raw{}.mapSize(1).str("Triangle").mapSize(1).str("base").code(0xcb, math.Float64bits(1), 8), []string{"Triangle"}},
+}
+
+// withShape is a Unions payload whose shape is `shape`.
+func withShape(shape raw) raw {
+	square := raw{}.mapSize(1).str("Square").mapSize(1).str("side").code(0xcb, math.Float64bits(3), 8)
+	return raw{}.mapSize(3).
+		str("shape").bytes(shape...).
+		str("shapeOptional").code(0xc0, 0, 0).
+		str("shapes").arraySize(1).bytes(square...)
+}
+
+func TestUnionWireFormat(t *testing.T) {
+	encoded, err := msgpack.Marshal(shapes["circle"])
+	require.NoError(t, err)
+	assert.Equal(t, []byte(raw{}.mapSize(1).bytes(circle...)), encoded)
+}
+
+// TestInvalidUnions checks that the host and the TinyGo bindings refuse to
+// encode, decode or validate a union that does not hold exactly one variant.
+func TestInvalidUnions(t *testing.T) {
+	for name, shape := range invalidShapes {
+		shape := shape
+		_, err := msgpack.Marshal(&shape)
+		assert.IsTypef(t, &module.UnionError{}, err, "host encoded %s", name)
+		assert.IsTypef(t, &module.UnionError{}, shape.Validate(), "host validated %s", name)
+
+		guestShape := guest.Shape{}
+		if shape.Circle != nil {
+			guestShape.Circle = &guest.Circle{Radius: shape.Circle.Radius}
+		}
+		if shape.Square != nil {
+			guestShape.Square = &guest.Square{Side: shape.Square.Side}
+		}
+		var sizer tinygomsgpack.Sizer
+		assert.IsTypef(t, &guest.UnionError{}, guestShape.Encode(&sizer), "TinyGo encoded %s", name)
+		assert.Equalf(t, []byte(raw{}.mapSize(0)), guestShape.ToBuffer(), "TinyGo encoded %s as a valid union", name)
+		assert.IsTypef(t, &guest.UnionError{}, guestShape.Validate(), "TinyGo validated %s", name)
+	}
+
+	for name, c := range invalidPayloads {
+		var shape module.Shape
+		assert.Equalf(t, &module.UnionError{Type: "Shape", Variants: c.variants}, msgpack.Unmarshal(c.payload, &shape),
+			"host decoded %s", name)
+		var unions module.Unions
+		assert.Errorf(t, msgpack.Unmarshal(withShape(c.payload), &unions), "host decoded %s in a type", name)
+
+		decoder := tinygomsgpack.NewDecoder(c.payload)
+		_, err := guest.DecodeShape(&decoder)
+		assert.Equalf(t, &guest.UnionError{Type: "Shape", Variants: c.variants}, err, "TinyGo decoded %s", name)
+		decoder = tinygomsgpack.NewDecoder(withShape(c.payload))
+		_, err = guest.DecodeUnions(&decoder)
+		assert.Errorf(t, err, "TinyGo decoded %s in a type", name)
+	}
+}
+
+// TestUnionEncoding checks that the host and the TinyGo bindings produce the
+// same bytes for every variant.
+func TestUnionEncoding(t *testing.T) {
+	for name, shape := range shapes {
+		unions := newUnions(shape)
+		hostEncoded, err := msgpack.Marshal(&unions)
+		require.NoError(t, err)
+
+		decoder := tinygomsgpack.NewDecoder(hostEncoded)
+		decoded, err := guest.DecodeUnions(&decoder)
+		require.NoErrorf(t, err, "TinyGo could not decode %s", name)
+		assert.Equalf(t, hostEncoded, decoded.ToBuffer(), "TinyGo encoded %s differently", name)
+
+		var actual module.Unions
+		require.NoError(t, msgpack.Unmarshal(hostEncoded, &actual))
+		assert.Equalf(t, unions, actual, "host changed %s", name)
+	}
+}
+
+// TestUnions checks that each guest echoes every variant with the same
+// encoding as the host and rejects unions without exactly one variant.
+func TestUnions(t *testing.T) {
+	ctx := context.Background()
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			wapcInstance := instantiate(t, lang.guest)
+			defer wapcInstance.Close()
+			m := module.New(wapcInstance)
+
+			for name, shape := range shapes {
+				unions := newUnions(shape)
+				actual, err := m.TestUnions(ctx, unions)
+				if assert.NoErrorf(t, err, "could not invoke testUnions with %s", name) {
+					assert.Equalf(t, unions, actual, "%s was not preserved", name)
+				}
+
+				payload, err := msgpack.Marshal(&unions)
+				require.NoError(t, err)
+				response, err := wapcInstance.Invoke(ctx, "testUnions", payload)
+				if assert.NoError(t, err) {
+					assert.Equalf(t, payload, response, "%s was encoded differently", name)
+				}
+			}
+
+			for name, c := range invalidPayloads {
+				_, err := wapcInstance.Invoke(ctx, "testUnions", withShape(c.payload))
+				assert.Errorf(t, err, "%s was accepted", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package module_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	tinygomsgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/pkg/module"
+	guest "github.com/wapc/language-tests/tinygo/module"
+)
+
+func newValidated() module.Validated {
+	return module.Validated{
+		Name:     "test",
+		Age:      150,
+		Score:    1,
+		Offset:   -10,
+		Code:     "ABC",
+		Nickname: pointer.ToString("tester"),
+		Version:  pointer.ToString("1.20-rc.1"),
+		Digest:   []byte{1, 2, 3, 4},
+		Tags:     []string{"a", "b", "c"},
+		Children: []module.Validated{},
+	}
+}
+
+var validationCases = []struct {
+	name     string
+	modify   func(*module.Validated)
+	expected []module.FieldError
+}{
+	{"valid", func(*module.Validated) {}, nil},
+	{"lower bounds", func(v *module.Validated) {
+		v.Name = "a"
+		v.Age = 0
+		v.Score = 0
+		v.Offset = -10
+		v.Tags = []string{}
+		v.Nickname = nil
+		v.Version = nil
+	}, nil},
+	{"characters", func(v *module.Validated) {
+		v.Name = strings.Repeat("é", 16)
+	}, nil},
+	{"too many characters", func(v *module.Validated) {
+		v.Name = strings.Repeat("é", 17)
+	}, []module.FieldError{{Path: "name", Message: "length must be at most 16"}}},
+	{"empty", func(v *module.Validated) {
+		v.Name = ""
+		v.Digest = []byte{}
+	}, []module.FieldError{
+		{Path: "name", Message: "length must be at least 1"},
+		{Path: "digest", Message: "length must be at least 4"},
+	}},
+	{"out of range", func(v *module.Validated) {
+		v.Age = 151
+		v.Score = -0.5
+		v.Offset = 11
+	}, []module.FieldError{
+		{Path: "age", Message: "must be at most 150"},
+		{Path: "score", Message: "must be at least 0"},
+		{Path: "offset", Message: "must be at most 10"},
+	}},
+	{"not a number", func(v *module.Validated) {
+		v.Score = math.NaN()
+	}, []module.FieldError{
+		{Path: "score", Message: "must be at least 0"},
+	}},
+	{"patterns", func(v *module.Validated) {
+		v.Code = "ABCD"
+		v.Nickname = pointer.ToString("Tester1")
+	}, []module.FieldError{
+		{Path: "code", Message: "must match ^[A-Z]{3}$"},
+		{Path: "nickname", Message: "must match ^[a-z]+$"},
+	}},
+	{"alternation", func(v *module.Validated) {
+		v.Version = pointer.ToString("0.1")
+	}, nil},
+	{"groups", func(v *module.Validated) {
+		v.Version = pointer.ToString("01.2+")
+	}, []module.FieldError{
+		{Path: "version", Message: `must match ^(0|[1-9][0-9]*)\.[0-9]+([-+][^ ]+)?$`},
+	}},
+	{"every constraint of a field", func(v *module.Validated) {
+		v.Nickname = pointer.ToString("Too long nickname")
+	}, []module.FieldError{
+		{Path: "nickname", Message: "length must be at most 8"},
+		{Path: "nickname", Message: "must match ^[a-z]+$"},
+	}},
+	{"too many elements", func(v *module.Validated) {
+		v.Tags = []string{"a", "b", "c", "d"}
+		v.Digest = []byte{1, 2, 3, 4, 5}
+	}, []module.FieldError{
+		{Path: "digest", Message: "length must be at most 4"},
+		{Path: "tags", Message: "length must be at most 3"},
+	}},
+	{"nested", func(v *module.Validated) {
+		valid := newValidated()
+		invalid := newValidated()
+		invalid.Name = ""
+		grandchild := newValidated()
+		grandchild.Code = "abc"
+		invalid.Children = []module.Validated{grandchild}
+		parent := newValidated()
+		parent.Offset = 100
+		v.Age = 200
+		v.Children = []module.Validated{valid, invalid}
+		v.Parent = &parent
+	}, []module.FieldError{
+		{Path: "age", Message: "must be at most 150"},
+		{Path: "children[1].name", Message: "length must be at least 1"},
+		{Path: "children[1].children[0].code", Message: "must match ^[A-Z]{3}$"},
+		{Path: "parent.offset", Message: "must be at most 10"},
+	}},
+}
+
+// TestValidationConstraints checks that the host and the TinyGo bindings
+// report the same violations, in the same order, with the same messages.
+func TestValidationConstraints(t *testing.T) {
+	for _, c := range validationCases {
+		validated := newValidated()
+		c.modify(&validated)
+		err := validated.Validate()
+		if c.expected == nil {
+			assert.NoErrorf(t, err, "host rejected %s", c.name)
+		} else {
+			assert.Equalf(t, &module.ValidationError{Fields: c.expected}, err, "host reported %s differently", c.name)
+		}
+
+		payload, err := msgpack.Marshal(&validated)
+		require.NoError(t, err)
+		decoder := tinygomsgpack.NewDecoder(payload)
+		decoded, err := guest.DecodeValidated(&decoder)
+		require.NoError(t, err)
+		guestErr := decoded.Validate()
+		if c.expected == nil {
+			assert.NoErrorf(t, guestErr, "TinyGo rejected %s", c.name)
+			continue
+		}
+		expected := &guest.ValidationError{}
+		for _, f := range c.expected {
+			expected.Fields = append(expected.Fields, guest.FieldError{Path: f.Path, Message: f.Message})
+		}
+		assert.Equalf(t, expected, guestErr, "TinyGo reported %s differently", c.name)
+		assert.EqualError(t, guestErr, validated.Validate().Error())
+	}
+
+	err := (&module.ValidationError{Fields: validationCases[4].expected}).Error()
+	assert.Equal(t, "validation failed: name length must be at least 1; digest length must be at least 4", err)
+}
+
+func TestValidation(t *testing.T) {
+	ctx := context.Background()
+
+	// The host checks arguments before invoking the guest.
+	invalid := newValidated()
+	invalid.Code = ""
+	_, err := module.New(nil).TestValidation(ctx, invalid)
+	assert.IsType(t, &module.ValidationError{}, err)
+
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			wapcInstance := instantiate(t, lang.guest)
+			defer wapcInstance.Close()
+			m := module.New(wapcInstance)
+
+			for _, c := range validationCases {
+				validated := newValidated()
+				c.modify(&validated)
+				if c.expected == nil {
+					actual, err := m.TestValidation(ctx, validated)
+					if assert.NoErrorf(t, err, "guest rejected %s", c.name) {
+						assert.Equalf(t, validated, actual, "%s was not preserved", c.name)
+					}
+					continue
+				}
+
+				// Bypass the host's check to test the guest's. wapc-go v0.2.1
+				// drops the error text of the guest, so the reported fields
+				// are only compared in-process, by TestValidationConstraints.
+				payload, err := msgpack.Marshal(&validated)
+				require.NoError(t, err)
+				_, err = wapcInstance.Invoke(ctx, "testValidation", payload)
+				assert.Errorf(t, err, "guest accepted %s", c.name)
+			}
+		})
+	}
+}
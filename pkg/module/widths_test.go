@@ -0,0 +1,227 @@
+package module_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v4"
+	"github.com/wapc/wapc-go"
+
+	"github.com/wapc/language-tests/pkg/module"
+)
+
+// encoding is one legal MsgPack representation of a number.
+type encoding struct {
+	width string
+	value raw
+}
+
+// intEncodings returns every MsgPack integer format that can represent `v`.
+func intEncodings(v int64) []encoding {
+	var encodings []encoding
+	if v >= 0 && v <= 127 {
+		encodings = append(encodings, encoding{"positive fixint", raw{byte(v)}})
+	}
+	if v >= -32 && v < 0 {
+		encodings = append(encodings, encoding{"negative fixint", raw{byte(v)}})
+	}
+	if v >= 0 {
+		u := uint64(v)
+		if u <= math.MaxUint8 {
+			encodings = append(encodings, encoding{"uint8", raw{}.code(0xcc, u, 1)})
+		}
+		if u <= math.MaxUint16 {
+			encodings = append(encodings, encoding{"uint16", raw{}.code(0xcd, u, 2)})
+		}
+		if u <= math.MaxUint32 {
+			encodings = append(encodings, encoding{"uint32", raw{}.code(0xce, u, 4)})
+		}
+		encodings = append(encodings, encoding{"uint64", raw{}.code(0xcf, u, 8)})
+	}
+	if v >= math.MinInt8 && v <= math.MaxInt8 {
+		encodings = append(encodings, encoding{"int8", raw{}.code(0xd0, uint64(v), 1)})
+	}
+	if v >= math.MinInt16 && v <= math.MaxInt16 {
+		encodings = append(encodings, encoding{"int16", raw{}.code(0xd1, uint64(v), 2)})
+	}
+	if v >= math.MinInt32 && v <= math.MaxInt32 {
+		encodings = append(encodings, encoding{"int32", raw{}.code(0xd2, uint64(v), 4)})
+	}
+	encodings = append(encodings, encoding{"int64", raw{}.code(0xd3, uint64(v), 8)})
+	return encodings
+}
+
+// uintEncodings is `intEncodings` for values above `math.MaxInt64`.
+func uintEncodings(u uint64) []encoding {
+	if u <= math.MaxInt64 {
+		return intEncodings(int64(u))
+	}
+	return []encoding{{"uint64", raw{}.code(0xcf, u, 8)}}
+}
+
+// floatEncodings returns the MsgPack float formats that represent `f` exactly.
+func floatEncodings(f float64) []encoding {
+	var encodings []encoding
+	if float64(float32(f)) == f {
+		encodings = append(encodings, encoding{"float32", raw{}.code(0xca, uint64(math.Float32bits(float32(f))), 4)})
+	}
+	return append(encodings, encoding{"float64", raw{}.code(0xcb, math.Float64bits(f), 8)})
+}
+
+type widthCase struct {
+	field string
+	// expected is the decoded value, of the field's Go type.
+	expected  interface{}
+	encodings []encoding
+}
+
+func widthCases() []widthCase {
+	var cases []widthCase
+	add := func(field string, expected interface{}, encodings ...[]encoding) {
+		var all []encoding
+		for _, e := range encodings {
+			all = append(all, e...)
+		}
+		cases = append(cases, widthCase{field, expected, all})
+	}
+
+	add("u8Value", uint8(1), intEncodings(1))
+	add("u8Value", uint8(math.MaxUint8), intEncodings(math.MaxUint8))
+	add("u16Value", uint16(1), intEncodings(1))
+	add("u16Value", uint16(math.MaxUint16), intEncodings(math.MaxUint16))
+	add("u32Value", uint32(1), intEncodings(1))
+	add("u32Value", uint32(math.MaxUint32), intEncodings(math.MaxUint32))
+	add("u64Value", uint64(1), intEncodings(1))
+	add("u64Value", uint64(math.MaxUint64), uintEncodings(math.MaxUint64))
+	add("s8Value", int8(-1), intEncodings(-1))
+	add("s8Value", int8(math.MaxInt8), intEncodings(math.MaxInt8))
+	add("s8Value", int8(math.MinInt8), intEncodings(math.MinInt8))
+	add("s16Value", int16(-1), intEncodings(-1))
+	add("s16Value", int16(math.MaxInt16), intEncodings(math.MaxInt16))
+	add("s16Value", int16(math.MinInt16), intEncodings(math.MinInt16))
+	add("s32Value", int32(-1), intEncodings(-1))
+	add("s32Value", int32(math.MaxInt32), intEncodings(math.MaxInt32))
+	add("s32Value", int32(math.MinInt32), intEncodings(math.MinInt32))
+	add("s64Value", int64(-1), intEncodings(-1))
+	add("s64Value", int64(math.MaxInt64), intEncodings(math.MaxInt64))
+	add("s64Value", int64(math.MinInt64), intEncodings(math.MinInt64))
+	add("f32Value", float32(1.5), floatEncodings(1.5))
+	add("f32Value", float32(2), floatEncodings(2), intEncodings(2))
+	add("f64Value", float64(1.5), floatEncodings(1.5))
+	add("f64Value", float64(2), floatEncodings(2), intEncodings(2))
+	add("f64Value", float64(math.MaxFloat64), floatEncodings(math.MaxFloat64))
+	return cases
+}
+
+const (
+	preserved = "preserved"
+	changed   = "changed"
+)
+
+// widthOutcome sends `value` as `field` of the `section` object to testUnary
+// and reports whether it was rejected, or accepted and echoed back unchanged.
+func widthOutcome(t *testing.T, wapcInstance *wapc.Instance, section, field string, value raw, expected interface{}) string {
+	payload := testsWith(t, section, field, value)
+	response, err := wapcInstance.Invoke(context.Background(), "testUnary", payload)
+	if err != nil {
+		return string(rejected)
+	}
+	var tests module.Tests
+	if err := msgpack.Unmarshal(response, &tests); err != nil {
+		return string(rejected)
+	}
+	actual := fieldValue(tests, section, field)
+	if actual.Kind() == reflect.Ptr {
+		if actual.IsNil() {
+			return changed
+		}
+		actual = actual.Elem()
+	}
+	if reflect.DeepEqual(actual.Interface(), expected) {
+		return preserved
+	}
+	return changed
+}
+
+// fieldValue returns the value of the struct field tagged `field`.
+func fieldValue(tests module.Tests, section, field string) reflect.Value {
+	v := reflect.ValueOf(tests.Required)
+	if section == "optional" {
+		v = reflect.ValueOf(tests.Optional)
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Tag.Get("msgpack") == field {
+			return v.Field(i)
+		}
+	}
+	panic("unknown field " + field)
+}
+
+// sameFamily reports whether `width` is a MsgPack format of the same family
+// as the Go type of `expected`: unsigned integers, signed integers, float32
+// or float64. Positive fixint belongs to both integer families.
+func sameFamily(expected interface{}, width string) bool {
+	switch reflect.ValueOf(expected).Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return width == "positive fixint" || strings.HasPrefix(width, "uint")
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strings.HasSuffix(width, "fixint") || strings.HasPrefix(width, "int")
+	case reflect.Float32:
+		return width == "float32"
+	case reflect.Float64:
+		return width == "float64"
+	}
+	return false
+}
+
+// widthDeviations records, by language, the outcome of values encoded in a
+// format outside the field type's family. Languages that are not listed
+// follow the policy.
+var widthDeviations = map[string]string{
+	// ReadUint*/ReadInt* only accept their own family and ReadFloat32/64
+	// only their own width, and the generated Decode returns the error.
+	"TinyGo": string(rejected),
+	// The decoder reads an integer only in a format of its type's family and
+	// a float only in its width, and aborts on anything else.
+	"AssemblyScript": string(rejected),
+}
+
+// TestIntegerWidths checks the width leniency policy: a guest must accept a
+// number in any MsgPack format that can represent the value exactly and
+// preserve the value. The matrix of outcomes is logged as a report.
+func TestIntegerWidths(t *testing.T) {
+	cases := widthCases()
+	for _, lang := range languages {
+		lang := lang
+		t.Run(lang.name, func(t *testing.T) {
+			requireFresh(t, lang.guest)
+			wapcModule := echoModule(t, lang.guest)
+
+			var report strings.Builder
+			for _, section := range []string{"required", "optional"} {
+				for _, c := range cases {
+					for _, e := range c.encodings {
+						wapcInstance, err := wapcModule.Instantiate()
+						require.NoError(t, err, "could instantiate module")
+						actual := widthOutcome(t, wapcInstance, section, c.field, e.value, c.expected)
+						wapcInstance.Close()
+						fmt.Fprintf(&report, "%-8s %-11s %-22v %-15s %s\n", section, c.field, c.expected, e.width, actual)
+
+						expected := preserved
+						if deviation, ok := widthDeviations[lang.name]; ok && !sameFamily(c.expected, e.width) {
+							expected = deviation
+						}
+						assert.Equalf(t, expected, actual, "%s.%s = %v encoded as %s", section, c.field, c.expected, e.width)
+					}
+				}
+			}
+			t.Logf("%s integer width report:\n%s", lang.name, report.String())
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Package scalar has the Go types that the generated host bindings use for
+// schema aliases that are mapped to user types in cmd/codegen.
+package scalar
+
+import (
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v4"
+
+	"github.com/wapc/language-tests/internal/uuid"
+)
+
+// UUID is an RFC 4122 UUID. It is encoded as a string in its canonical
+// form, the wire format of the `UUID` alias.
+type UUID [16]byte
+
+// ParseUUID parses the canonical form of a UUID, such as
+// "6ba7b810-9dad-11d1-80b4-00c04fd430c8". Uppercase digits are rejected.
+func ParseUUID(s string) (UUID, error) {
+	u, ok := uuid.Parse(s)
+	if !ok {
+		return UUID{}, fmt.Errorf("scalar: invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+func (u UUID) String() string {
+	return uuid.Format(u)
+}
+
+func (u UUID) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.EncodeString(u.String())
+}
+
+func (u *UUID) DecodeMsgpack(dec *msgpack.Decoder) error {
+	s, err := dec.DecodeString()
+	if err != nil {
+		return err
+	}
+	*u, err = ParseUUID(s)
+	return err
+}
@@ -0,0 +1,115 @@
+// Package size measures the sections of the guests and compares them with a
+// committed baseline, so that changes that bloat the guests, and with them
+// the cold-start time of the host, are caught.
+package size
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/wapc/language-tests/pkg/abi"
+)
+
+// Sizes is the size of a guest in bytes, broken down by section. Other
+// counts the remaining sections, such as types, imports and exports, along
+// with the headers of all sections.
+type Sizes struct {
+	Total  int `json:"total"`
+	Code   int `json:"code"`
+	Data   int `json:"data"`
+	Custom int `json:"custom"`
+	Other  int `json:"other"`
+}
+
+// Measure returns the sizes of the WebAssembly binary `code`.
+func Measure(code []byte) (Sizes, error) {
+	sections, err := abi.Sections(code)
+	if err != nil {
+		return Sizes{}, err
+	}
+	s := Sizes{Total: len(code)}
+	for _, section := range sections {
+		switch section.ID {
+		case 0:
+			s.Custom += section.Size
+		case 10:
+			s.Code += section.Size
+		case 11:
+			s.Data += section.Size
+		}
+	}
+	s.Other = s.Total - s.Code - s.Data - s.Custom
+	return s, nil
+}
+
+// Baseline holds the committed sizes of the guests by file name, such as
+// "tinygo.wasm".
+type Baseline map[string]Sizes
+
+// ReadBaseline reads a baseline written by Write.
+func ReadBaseline(file string) (Baseline, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Write writes the baseline as JSON, sorted by file name.
+func (b Baseline) Write(file string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// Names returns the file names of the baseline, sorted.
+func (b Baseline) Names() []string {
+	names := make([]string, 0, len(b))
+	for name := range b {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultThreshold is the growth, in percent, that Compare allows.
+const DefaultThreshold = 5.0
+
+// Compare returns a problem for every size of `current` that grew more than
+// `threshold` percent over `baseline`. Shrinking is never a problem, but the
+// baseline should then be updated so that it keeps catching growth.
+func Compare(baseline, current Sizes, threshold float64) []string {
+	var problems []string
+	check := func(name string, was, is int) {
+		if is <= was || was > 0 && 100*float64(is-was)/float64(was) <= threshold {
+			return
+		}
+		problems = append(problems, fmt.Sprintf("%s grew from %d to %d bytes (%s), over the %g%% threshold",
+			name, was, is, Delta(was, is), threshold))
+	}
+	check("total", baseline.Total, current.Total)
+	check("code", baseline.Code, current.Code)
+	check("data", baseline.Data, current.Data)
+	check("custom", baseline.Custom, current.Custom)
+	check("other", baseline.Other, current.Other)
+	return problems
+}
+
+// Delta formats the change from `was` to `is` in percent, such as "+1.5%".
+func Delta(was, is int) string {
+	if was == 0 {
+		if is == 0 {
+			return "+0.0%"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", 100*float64(is-was)/float64(was))
+}
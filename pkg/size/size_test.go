@@ -0,0 +1,56 @@
+package size_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wapc/language-tests/pkg/size"
+)
+
+func TestMeasure(t *testing.T) {
+	code := []byte("\x00asm\x01\x00\x00\x00")
+	code = append(code, 0, 6, 4, 'n', 'a', 'm', 'e', 0) // custom
+	code = append(code, 1, 4, 1, 0x60, 0, 0)            // type
+	code = append(code, 10, 2, 0, 0)                    // code
+	code = append(code, 11, 3, 0, 0, 0)                 // data
+	s, err := size.Measure(code)
+	require.NoError(t, err)
+	assert.Equal(t, size.Sizes{Total: 31, Code: 2, Data: 3, Custom: 6, Other: 20}, s)
+
+	_, err = size.Measure(code[:len(code)-1])
+	assert.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	baseline := size.Sizes{Total: 1000, Code: 600, Data: 100, Custom: 0, Other: 300}
+	assert.Empty(t, size.Compare(baseline, baseline, size.DefaultThreshold))
+	assert.Empty(t, size.Compare(baseline, size.Sizes{Total: 500}, size.DefaultThreshold))
+	assert.Empty(t, size.Compare(baseline, size.Sizes{Total: 1050, Code: 630, Data: 100, Other: 300}, size.DefaultThreshold))
+	assert.Equal(t, []string{
+		"total grew from 1000 to 1100 bytes (+10.0%), over the 5% threshold",
+		"code grew from 600 to 700 bytes (+16.7%), over the 5% threshold",
+		"custom grew from 0 to 10 bytes (new), over the 5% threshold",
+	}, size.Compare(baseline, size.Sizes{Total: 1100, Code: 700, Data: 90, Custom: 10, Other: 300}, size.DefaultThreshold))
+	assert.Empty(t, size.Compare(baseline, size.Sizes{Total: 1100, Code: 600, Data: 100, Other: 300}, 10))
+}
+
+func TestBaseline(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sizes.json")
+	b := size.Baseline{
+		"tinygo.wasm": {Total: 3, Code: 1, Data: 1, Other: 1},
+		"rust.wasm":   {Total: 2, Code: 2},
+	}
+	require.NoError(t, b.Write(file))
+	read, err := size.ReadBaseline(file)
+	require.NoError(t, err)
+	assert.Equal(t, b, read)
+	assert.Equal(t, []string{"rust.wasm", "tinygo.wasm"}, read.Names())
+
+	data, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"code": 2`)
+}
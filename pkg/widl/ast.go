@@ -0,0 +1,254 @@
+// Package widl parses WIDL, the interface definition language of the waPC
+// code generators, into an AST with the position of every declaration.
+package widl
+
+import (
+	"strconv"
+)
+
+// Pos is a position in a schema. Lines and columns start at 1 and columns
+// count bytes.
+type Pos struct {
+	Line   int
+	Column int
+}
+
+func (p Pos) String() string {
+	return strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+}
+
+// Document is a parsed schema.
+type Document struct {
+	Namespace  *Namespace
+	Interfaces []*Interface
+	Types      []*Type
+	Enums      []*Enum
+	Unions     []*Union
+	Aliases    []*Alias
+}
+
+// Interface returns the interface the guest exports, the one that is not a
+// role.
+func (d *Document) Interface() *Interface {
+	for _, i := range d.Interfaces {
+		if !i.Role {
+			return i
+		}
+	}
+	return nil
+}
+
+// Type returns the type named `name`, or nil.
+func (d *Document) Type(name string) *Type {
+	for _, t := range d.Types {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Namespace is the `namespace "name"` declaration.
+type Namespace struct {
+	Pos         Pos
+	Name        string
+	Description string
+}
+
+// Interface is an `interface` or a `role` declaration. Roles are implemented
+// by the host and have a name.
+type Interface struct {
+	Pos         Pos
+	Name        string
+	Description string
+	Role        bool
+	Annotations []*Annotation
+	Operations  []*Operation
+}
+
+// Namespace returns the namespace of a role from its @namespace annotation.
+func (i *Interface) Namespace() string {
+	if a := Find(i.Annotations, "namespace"); a != nil && len(a.Arguments) == 1 {
+		return a.Arguments[0].Value.Text
+	}
+	return ""
+}
+
+// Operation is an operation of an interface. Unary operations take their
+// single parameter as the payload, `name{param: type}`, instead of as a map
+// of arguments. Operations stream in one direction at most: either one
+// parameter or the result may be a Stream.
+type Operation struct {
+	Pos         Pos
+	Name        string
+	Description string
+	Unary       bool
+	Parameters  []*Field
+	// Returns is nil for operations returning `void`.
+	Returns     *TypeRef
+	Annotations []*Annotation
+}
+
+// StreamParameter returns the parameter that is a stream, or nil.
+func (o *Operation) StreamParameter() *Field {
+	for _, p := range o.Parameters {
+		if p.Type.Kind == Stream {
+			return p
+		}
+	}
+	return nil
+}
+
+// StreamResult reports whether the operation returns a stream.
+func (o *Operation) StreamResult() bool {
+	return o.Returns != nil && o.Returns.Kind == Stream
+}
+
+// Type is a `type` declaration.
+type Type struct {
+	Pos         Pos
+	Name        string
+	Description string
+	Fields      []*Field
+	Annotations []*Annotation
+}
+
+// Field is a field of a type or a parameter of an operation.
+type Field struct {
+	Pos         Pos
+	Name        string
+	Description string
+	Type        *TypeRef
+	// Default is nil for fields without a default value.
+	Default     *Value
+	Annotations []*Annotation
+}
+
+// Enum is an `enum` declaration.
+type Enum struct {
+	Pos         Pos
+	Name        string
+	Description string
+	Values      []*EnumValue
+}
+
+// EnumValue is a value of an enum.
+type EnumValue struct {
+	Pos         Pos
+	Name        string
+	Description string
+	Index       int
+}
+
+// Union is a `union` declaration.
+type Union struct {
+	Pos         Pos
+	Name        string
+	Description string
+	Members     []*TypeRef
+}
+
+// Alias is an `alias` declaration.
+type Alias struct {
+	Pos         Pos
+	Name        string
+	Description string
+	Type        *TypeRef
+}
+
+// Kind is the kind of a type reference.
+type Kind int
+
+const (
+	// Named refers to a built-in or declared type by name.
+	Named Kind = iota
+	// List is `[Elem]`.
+	List
+	// Map is `{Key:Elem}`.
+	Map
+	// Stream is `stream Elem`, a sequence of items sent in frames. Only the
+	// parameters and the result of an operation can be streams.
+	Stream
+)
+
+// TypeRef is a reference to a type.
+type TypeRef struct {
+	Pos      Pos
+	Kind     Kind
+	Name     string
+	Key      *TypeRef
+	Elem     *TypeRef
+	Optional bool
+}
+
+// String returns the reference in WIDL syntax.
+func (t *TypeRef) String() string {
+	var s string
+	switch t.Kind {
+	case List:
+		s = "[" + t.Elem.String() + "]"
+	case Map:
+		s = "{" + t.Key.String() + ":" + t.Elem.String() + "}"
+	case Stream:
+		s = "stream " + t.Elem.String()
+	default:
+		s = t.Name
+	}
+	if t.Optional {
+		s += "?"
+	}
+	return s
+}
+
+// Annotation is an annotation such as `@range(min: 0, max: 1)`.
+type Annotation struct {
+	Pos       Pos
+	Name      string
+	Arguments []*Argument
+}
+
+// Argument returns the value of the argument named `name`, or nil.
+func (a *Annotation) Argument(name string) *Value {
+	for _, arg := range a.Arguments {
+		if arg.Name == name {
+			return arg.Value
+		}
+	}
+	return nil
+}
+
+// Find returns the annotation named `name`, or nil.
+func Find(annotations []*Annotation, name string) *Annotation {
+	for _, a := range annotations {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Argument is an argument of an annotation. The single argument of
+// `@pattern("...")` has no name.
+type Argument struct {
+	Name  string
+	Value *Value
+}
+
+// ValueKind is the kind of a literal value.
+type ValueKind int
+
+const (
+	String ValueKind = iota
+	Number
+	Bool
+	// Identifier is an unquoted name, such as an enum value used as a default.
+	Identifier
+)
+
+// Value is a literal value. Text holds strings unquoted and the other kinds as
+// written.
+type Value struct {
+	Pos  Pos
+	Kind ValueKind
+	Text string
+}
@@ -0,0 +1,162 @@
+package widl
+
+import (
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenPunct
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokenIdent:
+		return "identifier"
+	case tokenString:
+		return "string"
+	case tokenNumber:
+		return "number"
+	case tokenPunct:
+		return "punctuation"
+	}
+	return "end of file"
+}
+
+type token struct {
+	pos  Pos
+	kind tokenKind
+	// text is unquoted for strings.
+	text string
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of file"
+	case tokenString:
+		return strconv.Quote(t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+// Error is a syntax error at a position in a schema.
+type Error struct {
+	Pos Pos
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+type lexer struct {
+	src    string
+	offset int
+	pos    Pos
+}
+
+func (l *lexer) peekByte(n int) byte {
+	if l.offset+n < len(l.src) {
+		return l.src[l.offset+n]
+	}
+	return 0
+}
+
+func (l *lexer) advance() {
+	if l.src[l.offset] == '\n' {
+		l.pos.Line++
+		l.pos.Column = 1
+	} else {
+		l.pos.Column++
+	}
+	l.offset++
+}
+
+// skip skips whitespace and `#` and `//` comments.
+func (l *lexer) skip() {
+	for l.offset < len(l.src) {
+		c := l.src[l.offset]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			l.advance()
+		case c == '#' || c == '/' && l.peekByte(1) == '/':
+			for l.offset < len(l.src) && l.src[l.offset] != '\n' {
+				l.advance()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func (l *lexer) next() (token, error) {
+	l.skip()
+	t := token{pos: l.pos}
+	if l.offset >= len(l.src) {
+		return t, nil
+	}
+	start := l.offset
+	c := l.src[l.offset]
+	switch {
+	case isLetter(c):
+		for l.offset < len(l.src) && (isLetter(l.src[l.offset]) || isDigit(l.src[l.offset])) {
+			l.advance()
+		}
+		t.kind = tokenIdent
+	case isDigit(c) || c == '-' && isDigit(l.peekByte(1)):
+		l.advance()
+		for l.offset < len(l.src) && (isDigit(l.src[l.offset]) || l.src[l.offset] == '.') {
+			l.advance()
+		}
+		t.kind = tokenNumber
+	case c == '"':
+		return l.string()
+	case strings.IndexByte("{}()[]:,=?@|", c) >= 0:
+		l.advance()
+		t.kind = tokenPunct
+	default:
+		return t, &Error{t.pos, "unexpected character " + strconv.QuoteRune(rune(c))}
+	}
+	t.text = l.src[start:l.offset]
+	return t, nil
+}
+
+// string reads a string literal. Only `\"` and `\\` are escapes, so that
+// regular expressions can be written without doubling backslashes.
+func (l *lexer) string() (token, error) {
+	t := token{pos: l.pos, kind: tokenString}
+	l.advance()
+	var b strings.Builder
+	for {
+		if l.offset >= len(l.src) || l.src[l.offset] == '\n' {
+			return t, &Error{t.pos, "unterminated string"}
+		}
+		c := l.src[l.offset]
+		l.advance()
+		switch {
+		case c == '"':
+			t.text = b.String()
+			return t, nil
+		case c == '\\' && (l.peekByte(0) == '"' || l.peekByte(0) == '\\'):
+			b.WriteByte(l.src[l.offset])
+			l.advance()
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
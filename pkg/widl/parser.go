@@ -0,0 +1,488 @@
+package widl
+
+import (
+	"strconv"
+)
+
+// Parse parses a schema. The error, if any, is an *Error with the position of
+// the first syntax error.
+func Parse(src []byte) (*Document, error) {
+	p := parser{lexer: lexer{src: string(src), pos: Pos{1, 1}}}
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	return p.document()
+}
+
+type parser struct {
+	lexer lexer
+	tok   token
+}
+
+func (p *parser) read() (err error) {
+	p.tok, err = p.lexer.next()
+	return err
+}
+
+func (p *parser) errorf(pos Pos, msg string) error {
+	return &Error{pos, msg}
+}
+
+func (p *parser) is(punct string) bool {
+	return p.tok.kind == tokenPunct && p.tok.text == punct
+}
+
+// expect reads the punctuation `punct`.
+func (p *parser) expect(punct string) error {
+	if !p.is(punct) {
+		return p.errorf(p.tok.pos, "expected '"+punct+"', found "+p.tok.String())
+	}
+	return p.read()
+}
+
+// token reads a token of kind `kind`.
+func (p *parser) token(kind tokenKind) (token, error) {
+	t := p.tok
+	if t.kind != kind {
+		return t, p.errorf(t.pos, "expected "+kind.String()+", found "+t.String())
+	}
+	return t, p.read()
+}
+
+// description reads an optional description string.
+func (p *parser) description() (string, error) {
+	if p.tok.kind != tokenString {
+		return "", nil
+	}
+	t := p.tok
+	return t.text, p.read()
+}
+
+func (p *parser) document() (*Document, error) {
+	doc := &Document{}
+	for p.tok.kind != tokenEOF {
+		desc, err := p.description()
+		if err != nil {
+			return nil, err
+		}
+		keyword, err := p.token(tokenIdent)
+		if err != nil {
+			return nil, err
+		}
+		switch keyword.text {
+		case "namespace":
+			if doc.Namespace != nil {
+				return nil, p.errorf(keyword.pos, "namespace already declared at "+doc.Namespace.Pos.String())
+			}
+			name, err := p.token(tokenString)
+			if err != nil {
+				return nil, err
+			}
+			doc.Namespace = &Namespace{keyword.pos, name.text, desc}
+		case "interface", "role":
+			i, err := p.iface(keyword, desc)
+			if err != nil {
+				return nil, err
+			}
+			doc.Interfaces = append(doc.Interfaces, i)
+		case "type":
+			t, err := p.typ(keyword, desc)
+			if err != nil {
+				return nil, err
+			}
+			doc.Types = append(doc.Types, t)
+		case "enum":
+			e, err := p.enum(keyword, desc)
+			if err != nil {
+				return nil, err
+			}
+			doc.Enums = append(doc.Enums, e)
+		case "union":
+			u, err := p.union(keyword, desc)
+			if err != nil {
+				return nil, err
+			}
+			doc.Unions = append(doc.Unions, u)
+		case "alias":
+			a, err := p.alias(keyword, desc)
+			if err != nil {
+				return nil, err
+			}
+			doc.Aliases = append(doc.Aliases, a)
+		default:
+			return nil, p.errorf(keyword.pos, "unknown declaration "+keyword.String())
+		}
+	}
+	return doc, nil
+}
+
+func (p *parser) iface(keyword token, desc string) (*Interface, error) {
+	i := &Interface{Pos: keyword.pos, Description: desc, Role: keyword.text == "role"}
+	if i.Role || p.tok.kind == tokenIdent {
+		name, err := p.token(tokenIdent)
+		if err != nil {
+			return nil, err
+		}
+		i.Name = name.text
+	}
+	var err error
+	if i.Annotations, err = p.annotations(); err != nil {
+		return nil, err
+	}
+	if i.Role && i.Namespace() == "" {
+		return nil, p.errorf(keyword.pos, "role "+i.Name+" has no @namespace")
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.is("}") {
+		op, err := p.operation()
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range i.Operations {
+			if other.Name == op.Name {
+				return nil, p.errorf(op.Pos, "operation "+op.Name+" already declared at "+other.Pos.String())
+			}
+		}
+		i.Operations = append(i.Operations, op)
+	}
+	return i, p.read()
+}
+
+func (p *parser) operation() (*Operation, error) {
+	desc, err := p.description()
+	if err != nil {
+		return nil, err
+	}
+	name, err := p.token(tokenIdent)
+	if err != nil {
+		return nil, err
+	}
+	op := &Operation{Pos: name.pos, Name: name.text, Description: desc}
+	closing := ")"
+	if p.is("{") {
+		op.Unary = true
+		closing = "}"
+	} else if !p.is("(") {
+		return nil, p.errorf(p.tok.pos, "expected '(' or '{', found "+p.tok.String())
+	}
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	for !p.is(closing) {
+		if len(op.Parameters) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		param, err := p.field(true)
+		if err != nil {
+			return nil, err
+		}
+		if param.Type.Kind == Stream {
+			if op.Unary {
+				return nil, p.errorf(param.Pos, "unary operation "+op.Name+" cannot take a stream")
+			}
+			if other := op.StreamParameter(); other != nil {
+				return nil, p.errorf(param.Pos, "operation "+op.Name+" already takes the stream "+other.Name)
+			}
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+	if op.Unary && len(op.Parameters) != 1 {
+		return nil, p.errorf(op.Pos, "unary operation "+op.Name+" must have one parameter")
+	}
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	if p.is(":") {
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokenIdent && p.tok.text == "void" {
+			err = p.read()
+		} else {
+			op.Returns, err = p.streamRef(true)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if op.StreamResult() && op.StreamParameter() != nil {
+			return nil, p.errorf(op.Returns.Pos, "operation "+op.Name+" cannot both take and return a stream")
+		}
+	}
+	op.Annotations, err = p.annotations()
+	return op, err
+}
+
+func (p *parser) typ(keyword token, desc string) (*Type, error) {
+	name, err := p.token(tokenIdent)
+	if err != nil {
+		return nil, err
+	}
+	t := &Type{Pos: keyword.pos, Name: name.text, Description: desc}
+	if t.Annotations, err = p.annotations(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.is("}") {
+		f, err := p.field(false)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range t.Fields {
+			if other.Name == f.Name {
+				return nil, p.errorf(f.Pos, "field "+f.Name+" already declared at "+other.Pos.String())
+			}
+		}
+		t.Fields = append(t.Fields, f)
+	}
+	return t, p.read()
+}
+
+// field reads `[description] name: type [= default] [annotations]`. The type
+// of the parameters of an operation, `stream`, may be a stream.
+func (p *parser) field(stream bool) (*Field, error) {
+	desc, err := p.description()
+	if err != nil {
+		return nil, err
+	}
+	name, err := p.token(tokenIdent)
+	if err != nil {
+		return nil, err
+	}
+	f := &Field{Pos: name.pos, Name: name.text, Description: desc}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if f.Type, err = p.streamRef(stream); err != nil {
+		return nil, err
+	}
+	if p.is("=") {
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		if f.Default, err = p.value(); err != nil {
+			return nil, err
+		}
+	}
+	f.Annotations, err = p.annotations()
+	return f, err
+}
+
+// streamRef reads a type, or `stream type` if `stream` is set. Within the
+// signature of an operation, stream is a keyword.
+func (p *parser) streamRef(stream bool) (*TypeRef, error) {
+	if p.tok.kind != tokenIdent || p.tok.text != "stream" {
+		return p.typeRef()
+	}
+	t := &TypeRef{Pos: p.tok.pos, Kind: Stream}
+	if !stream {
+		return nil, p.errorf(t.Pos, "streams are only allowed in the signature of an operation")
+	}
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	var err error
+	t.Elem, err = p.typeRef()
+	return t, err
+}
+
+func (p *parser) typeRef() (*TypeRef, error) {
+	t := &TypeRef{Pos: p.tok.pos}
+	var err error
+	switch {
+	case p.is("["):
+		t.Kind = List
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		if t.Elem, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	case p.is("{"):
+		t.Kind = Map
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		if t.Key, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if t.Elem, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("}"); err != nil {
+			return nil, err
+		}
+	case p.tok.kind == tokenIdent:
+		t.Name = p.tok.text
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, p.errorf(t.Pos, "expected type, found "+p.tok.String())
+	}
+	if p.is("?") {
+		t.Optional = true
+		return t, p.read()
+	}
+	return t, nil
+}
+
+func (p *parser) value() (*Value, error) {
+	v := &Value{Pos: p.tok.pos, Text: p.tok.text}
+	switch p.tok.kind {
+	case tokenString:
+		v.Kind = String
+	case tokenNumber:
+		v.Kind = Number
+		if _, err := strconv.ParseFloat(v.Text, 64); err != nil {
+			return nil, p.errorf(v.Pos, "invalid number "+p.tok.String())
+		}
+	case tokenIdent:
+		v.Kind = Identifier
+		if v.Text == "true" || v.Text == "false" {
+			v.Kind = Bool
+		}
+	default:
+		return nil, p.errorf(v.Pos, "expected value, found "+p.tok.String())
+	}
+	return v, p.read()
+}
+
+// annotations reads `@name` and `@name(args)` annotations.
+func (p *parser) annotations() ([]*Annotation, error) {
+	var annotations []*Annotation
+	for p.is("@") {
+		pos := p.tok.pos
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+		name, err := p.token(tokenIdent)
+		if err != nil {
+			return nil, err
+		}
+		a := &Annotation{Pos: pos, Name: name.text}
+		if p.is("(") {
+			if err := p.read(); err != nil {
+				return nil, err
+			}
+			for !p.is(")") {
+				if len(a.Arguments) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+				arg, err := p.argument()
+				if err != nil {
+					return nil, err
+				}
+				a.Arguments = append(a.Arguments, arg)
+			}
+			if err := p.read(); err != nil {
+				return nil, err
+			}
+		}
+		annotations = append(annotations, a)
+	}
+	return annotations, nil
+}
+
+// argument reads `name: value` or a single unnamed value.
+func (p *parser) argument() (*Argument, error) {
+	arg := &Argument{}
+	first, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if first.Kind != Identifier || !p.is(":") {
+		arg.Value = first
+		return arg, nil
+	}
+	if err := p.read(); err != nil {
+		return nil, err
+	}
+	arg.Name = first.Text
+	arg.Value, err = p.value()
+	return arg, err
+}
+
+func (p *parser) enum(keyword token, desc string) (*Enum, error) {
+	name, err := p.token(tokenIdent)
+	if err != nil {
+		return nil, err
+	}
+	e := &Enum{Pos: keyword.pos, Name: name.text, Description: desc}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.is("}") {
+		desc, err := p.description()
+		if err != nil {
+			return nil, err
+		}
+		name, err := p.token(tokenIdent)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		index, err := p.token(tokenNumber)
+		if err != nil {
+			return nil, err
+		}
+		i, err := strconv.Atoi(index.text)
+		if err != nil {
+			return nil, p.errorf(index.pos, "invalid enum index "+index.String())
+		}
+		e.Values = append(e.Values, &EnumValue{name.pos, name.text, desc, i})
+	}
+	return e, p.read()
+}
+
+func (p *parser) union(keyword token, desc string) (*Union, error) {
+	name, err := p.token(tokenIdent)
+	if err != nil {
+		return nil, err
+	}
+	u := &Union{Pos: keyword.pos, Name: name.text, Description: desc}
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	for {
+		member, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		u.Members = append(u.Members, member)
+		if !p.is("|") {
+			return u, nil
+		}
+		if err := p.read(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) alias(keyword token, desc string) (*Alias, error) {
+	name, err := p.token(tokenIdent)
+	if err != nil {
+		return nil, err
+	}
+	a := &Alias{Pos: keyword.pos, Name: name.text, Description: desc}
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	a.Type, err = p.typeRef()
+	return a, err
+}
@@ -0,0 +1,177 @@
+package widl_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wapc/language-tests/pkg/drift"
+	"github.com/wapc/language-tests/pkg/module"
+	"github.com/wapc/language-tests/pkg/modulev2"
+	"github.com/wapc/language-tests/pkg/widl"
+	guest "github.com/wapc/language-tests/tinygo/module"
+	guestv2 "github.com/wapc/language-tests/tinygo/v2/module"
+)
+
+func parse(t *testing.T, file string) *widl.Document {
+	src, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	doc, err := widl.Parse(src)
+	require.NoError(t, err)
+	return doc
+}
+
+// TestGeneratedTypes checks that the parsed schemas declare the operations
+// and types generated in pkg/module, pkg/modulev2 and the TinyGo bindings.
+func TestGeneratedTypes(t *testing.T) {
+	doc := parse(t, "../../schema.widl")
+	assert.Equal(t, "tests", doc.Namespace.Name)
+	assert.Empty(t, drift.Host{Module: &module.Module{}, Router: module.Router{}}.Check(doc))
+	assert.Empty(t, drift.TinyGo{
+		Handlers: guest.Handlers{},
+		Host:     &guest.Host{},
+		Roles: map[string]interface{}{
+			"Storage": &guest.StorageHost{},
+			"Log":     &guest.LogHost{},
+		},
+	}.Check(doc))
+
+	doc = parse(t, "../../schema.v2.widl")
+	assert.Empty(t, drift.Host{Module: &modulev2.Module{}}.Check(doc))
+	assert.Empty(t, drift.TinyGo{Handlers: guestv2.Handlers{}, Host: &guestv2.Host{}}.Check(doc))
+}
+
+func TestParse(t *testing.T) {
+	doc, err := widl.Parse([]byte(`namespace "example"
+
+# A comment
+"The interface"
+interface {
+  "Adds two numbers"
+  add(a: i64, b: i64): i64
+  echo{value: [string]?}: {string:[u8]}
+  notify(): void
+  watch(prefix: string): stream Point
+  upload(name: string, points: stream Point?): u32
+}
+
+role Store @namespace("example.store") {
+  get{key: string}: bytes?
+}
+
+type Point @immutable {
+  x: f64 = 1.5
+  "The label"
+  label: string = "none" @length(max: 8) @pattern("^[a-z\\\"]*$")
+  kind: Kind = circle
+}
+
+enum Kind {
+  circle = 0
+  "Four sides"
+  square = 1
+}
+
+union Shape = Point | Other
+
+alias Id = string
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, &widl.Namespace{Pos: widl.Pos{Line: 1, Column: 1}, Name: "example"}, doc.Namespace)
+	require.Len(t, doc.Interfaces, 2)
+	i := doc.Interface()
+	assert.Equal(t, "The interface", i.Description)
+	assert.Equal(t, widl.Pos{Line: 5, Column: 1}, i.Pos)
+	require.Len(t, i.Operations, 5)
+	add := i.Operations[0]
+	assert.Equal(t, "Adds two numbers", add.Description)
+	assert.Equal(t, widl.Pos{Line: 7, Column: 3}, add.Pos)
+	assert.False(t, add.Unary)
+	require.Len(t, add.Parameters, 2)
+	assert.Equal(t, "b", add.Parameters[1].Name)
+	assert.Equal(t, widl.Pos{Line: 7, Column: 15}, add.Parameters[1].Pos)
+	assert.Equal(t, "i64", add.Returns.String())
+	echo := i.Operations[1]
+	assert.True(t, echo.Unary)
+	assert.Equal(t, "[string]?", echo.Parameters[0].Type.String())
+	assert.Equal(t, "{string:[u8]}", echo.Returns.String())
+	assert.Equal(t, widl.Map, echo.Returns.Kind)
+	assert.Equal(t, "u8", echo.Returns.Elem.Elem.Name)
+	assert.Nil(t, i.Operations[2].Returns)
+	assert.Empty(t, i.Operations[2].Parameters)
+	watch := i.Operations[3]
+	assert.True(t, watch.StreamResult())
+	assert.Nil(t, watch.StreamParameter())
+	assert.Equal(t, "stream Point", watch.Returns.String())
+	assert.Equal(t, widl.Pos{Line: 10, Column: 26}, watch.Returns.Pos)
+	upload := i.Operations[4]
+	assert.False(t, upload.StreamResult())
+	require.NotNil(t, upload.StreamParameter())
+	assert.Equal(t, "points", upload.StreamParameter().Name)
+	assert.Equal(t, widl.Stream, upload.Parameters[1].Type.Kind)
+	assert.Equal(t, "stream Point?", upload.Parameters[1].Type.String())
+	assert.True(t, upload.Parameters[1].Type.Elem.Optional)
+
+	store := doc.Interfaces[1]
+	assert.True(t, store.Role)
+	assert.Equal(t, "Store", store.Name)
+	assert.Equal(t, "example.store", store.Namespace())
+
+	point := doc.Type("Point")
+	require.NotNil(t, point)
+	assert.Equal(t, "immutable", point.Annotations[0].Name)
+	require.Len(t, point.Fields, 3)
+	assert.Equal(t, &widl.Value{Pos: widl.Pos{Line: 19, Column: 12}, Kind: widl.Number, Text: "1.5"}, point.Fields[0].Default)
+	label := point.Fields[1]
+	assert.Equal(t, "The label", label.Description)
+	assert.Equal(t, widl.String, label.Default.Kind)
+	assert.Equal(t, "none", label.Default.Text)
+	require.Len(t, label.Annotations, 2)
+	assert.Equal(t, "8", label.Annotations[0].Argument("max").Text)
+	assert.Equal(t, `^[a-z\"]*$`, widl.Find(label.Annotations, "pattern").Arguments[0].Value.Text)
+	assert.Equal(t, widl.Identifier, point.Fields[2].Default.Kind)
+
+	require.Len(t, doc.Enums, 1)
+	assert.Equal(t, &widl.EnumValue{Pos: widl.Pos{Line: 28, Column: 3}, Name: "square", Description: "Four sides", Index: 1}, doc.Enums[0].Values[1])
+	require.Len(t, doc.Unions, 1)
+	assert.Len(t, doc.Unions[0].Members, 2)
+	require.Len(t, doc.Aliases, 1)
+	assert.Equal(t, "string", doc.Aliases[0].Type.Name)
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		src      string
+		expected string
+	}{
+		{`namespace example`, `1:11: expected string, found 'example'`},
+		{"namespace \"a\"\nnamespace \"b\"", `2:1: namespace already declared at 1:1`},
+		{"interface {\n  op(a: string: string\n}", `2:15: expected ',', found ':'`},
+		{"interface {\n  op{a: string, b: string}: string\n}", `2:3: unary operation op must have one parameter`},
+		{"interface {\n  op(): string\n  op(): string\n}", `3:3: operation op already declared at 2:3`},
+		{"type A {\n  a: [string\n}", `3:1: expected ']', found '}'`},
+		{"type A {\n  a: string\n  a: string\n}", `3:3: field a already declared at 2:3`},
+		{"type A {\n  a: string = \n}", `3:1: expected value, found '}'`},
+		{"type A {\n  a: {string string}\n}", `2:14: expected ':', found 'string'`},
+		{"interface {\n  op{a: stream string}: string\n}", `2:6: unary operation op cannot take a stream`},
+		{"interface {\n  op(a: stream string, b: stream u8): string\n}", `2:24: operation op already takes the stream a`},
+		{"interface {\n  op(a: stream string): stream string\n}", `2:25: operation op cannot both take and return a stream`},
+		{"type A {\n  a: stream string\n}", `2:6: streams are only allowed in the signature of an operation`},
+		{`role A { }`, `1:1: role A has no @namespace`},
+		{`enum E { a = x }`, `1:14: expected number, found 'x'`},
+		{`alias A = `, `1:11: expected type, found end of file`},
+		{`type A { a: string } $`, `1:22: unexpected character '$'`},
+		{"\"unterminated\ntype A {}", `1:1: unterminated string`},
+		{`struct A {}`, `1:1: unknown declaration 'struct'`},
+	}
+	for _, c := range cases {
+		_, err := widl.Parse([]byte(c.src))
+		if assert.Errorf(t, err, "%q was parsed", c.src) {
+			assert.IsType(t, &widl.Error{}, err)
+			assert.Equalf(t, c.expected, err.Error(), "wrong error for %q", c.src)
+		}
+	}
+}
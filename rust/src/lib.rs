@@ -2,12 +2,36 @@ pub mod generated;
 extern crate wapc_guest as guest;
 use generated::*;
 use guest::prelude::*;
+use serde_bytes::ByteBuf;
+use std::collections::HashMap;
 
 #[no_mangle]
 pub fn wapc_init() {
     Handlers::register_test_function(test_function);
     Handlers::register_test_unary(test_unary);
     Handlers::register_test_decode(test_decode);
+    Handlers::register_test_host_call(test_host_call);
+    Handlers::register_test_enums(test_enums);
+    Handlers::register_test_unions(test_unions);
+    Handlers::register_test_recursion(test_recursion);
+    Handlers::register_test_collections(test_collections);
+    Handlers::register_test_times(test_times);
+    Handlers::register_test_aliases(test_aliases);
+    Handlers::register_test_defaults(test_defaults);
+    Handlers::register_test_validation(test_validation);
+    Handlers::register_test_no_args(test_no_args);
+    Handlers::register_test_no_args_void(test_no_args_void);
+    Handlers::register_test_void(test_void);
+    Handlers::register_test_unary_string(test_unary_string);
+    Handlers::register_test_unary_u64(test_unary_u64);
+    Handlers::register_test_unary_bool(test_unary_bool);
+    Handlers::register_test_unary_bytes(test_unary_bytes);
+    Handlers::register_test_return_list(test_return_list);
+    Handlers::register_test_return_map(test_return_map);
+    Handlers::register_test_return_optional(test_return_optional);
+    Handlers::register_test_namespaces(test_namespaces);
+    Handlers::register_stream_things(stream_things);
+    Handlers::register_collect_things(collect_things);
 }
 
 fn test_function(
@@ -16,15 +40,17 @@ fn test_function(
     maps: Maps,
     lists: Lists,
 ) -> HandlerResult<Tests> {
-    let mut tests = Tests::default();
-    tests.required = required;
-    tests.optional = optional;
-    tests.maps = maps;
-    tests.lists = lists;
-    Ok(tests)
+    // Echo arguments
+    Ok(Tests {
+        required,
+        optional,
+        maps,
+        lists,
+    })
 }
 
 fn test_unary(tests: Tests) -> HandlerResult<Tests> {
+    // Echo input
     Ok(tests)
 }
 
@@ -56,7 +82,173 @@ tests.required.s64_value,
 tests.required.f32_value as f64,
 tests.required.f64_value,
 tests.required.string_value,
-tests.required.string_value,
+String::from_utf8_lossy(&tests.required.bytes_value),
 );
     Ok("{".to_owned() + &ret.to_string() + "}")
-}
\ No newline at end of file
+}
+
+fn test_host_call(tests: Tests) -> HandlerResult<Tests> {
+    // Round trip through the host
+    Host::new("").test_unary(tests)
+}
+
+fn test_enums(enums: Enums) -> HandlerResult<Enums> {
+    // Echo input
+    Ok(enums)
+}
+
+fn test_unions(unions: Unions) -> HandlerResult<Unions> {
+    // Echo input
+    Ok(unions)
+}
+
+fn test_recursion(trees: Trees) -> HandlerResult<Trees> {
+    // Echo input
+    Ok(trees)
+}
+
+fn test_collections(collections: Collections) -> HandlerResult<Collections> {
+    // Echo input
+    Ok(collections)
+}
+
+fn test_times(times: Times) -> HandlerResult<Times> {
+    // Echo input
+    Ok(times)
+}
+
+fn test_aliases(aliases: Aliases) -> HandlerResult<Aliases> {
+    // Echo input
+    Ok(aliases)
+}
+
+fn test_defaults(defaults: Defaults) -> HandlerResult<Defaults> {
+    // Echo input
+    Ok(defaults)
+}
+
+fn test_validation(validated: Validated) -> HandlerResult<Validated> {
+    // Echo input
+    Ok(validated)
+}
+
+fn test_no_args() -> HandlerResult<String> {
+    Ok("ok".to_string())
+}
+
+fn test_no_args_void() -> HandlerResult<()> {
+    Ok(())
+}
+
+fn test_void(value: String) -> HandlerResult<()> {
+    if value == "error" {
+        return Err("testVoid failed".into());
+    }
+    Ok(())
+}
+
+fn test_unary_string(value: String) -> HandlerResult<String> {
+    // Echo input
+    Ok(value)
+}
+
+fn test_unary_u64(value: u64) -> HandlerResult<u64> {
+    // Echo input
+    Ok(value)
+}
+
+fn test_unary_bool(value: bool) -> HandlerResult<bool> {
+    // Echo input
+    Ok(value)
+}
+
+fn test_unary_bytes(value: ByteBuf) -> HandlerResult<ByteBuf> {
+    // Echo input
+    Ok(value)
+}
+
+/// MAX_LIST_COUNT bounds the count of test_return_list, which comes from the
+/// client, so that it cannot make the guest run out of memory.
+const MAX_LIST_COUNT: u32 = 10000;
+
+fn test_return_list(prefix: String, count: u32) -> HandlerResult<Vec<String>> {
+    if count > MAX_LIST_COUNT {
+        return Err(format!("count {} is above {}", count, MAX_LIST_COUNT).into());
+    }
+    Ok((0..count).map(|i| format!("{}{}", prefix, i)).collect())
+}
+
+fn test_return_map(keys: Vec<String>) -> HandlerResult<HashMap<String, u64>> {
+    Ok(keys
+        .into_iter()
+        .map(|key| {
+            let length = key.len() as u64;
+            (key, length)
+        })
+        .collect())
+}
+
+fn test_return_optional(value: Option<String>) -> HandlerResult<Option<String>> {
+    // Echo input
+    Ok(value)
+}
+
+fn test_namespaces(key: String, value: String) -> HandlerResult<NamespaceResults> {
+    let storage = StorageHost::new("");
+    let log = LogHost::new("");
+    storage.set(key.clone(), value.clone())?;
+    let index = log.write(format!("{}={}", key, value))?;
+    // Both namespaces have a get operation.
+    Ok(NamespaceResults {
+        stored: storage.get(key)?,
+        logged: log.get(index)?,
+        index,
+    })
+}
+
+fn stream_things(
+    prefix: String,
+    count: u32,
+    fail_at: Option<u32>,
+    stream: &mut ThingStreamWriter,
+) -> HandlerResult<()> {
+    for i in 0..count {
+        if fail_at == Some(i) {
+            return Err(format!("failed at {}", i).into());
+        }
+        stream.send(Thing {
+            value: format!("{}{}", prefix, i),
+        })?;
+    }
+    Ok(())
+}
+
+fn collect_things(
+    label: String,
+    fail_at: Option<u32>,
+    things: &mut ThingStreamReader,
+) -> HandlerResult<ThingSummary> {
+    let mut summary = ThingSummary {
+        label,
+        ..Default::default()
+    };
+    // FNV-1a
+    let mut checksum: u32 = 0x811c9dc5;
+    loop {
+        if fail_at == Some(summary.count) {
+            return Err(format!("failed at {}", summary.count).into());
+        }
+        let thing = match things.next() {
+            Some(thing) => thing,
+            None => break,
+        };
+        summary.count += 1;
+        summary.size += thing.value.len() as u64;
+        for b in thing.value.bytes() {
+            checksum = (checksum ^ u32::from(b)).wrapping_mul(0x01000193);
+        }
+    }
+    things.err()?;
+    summary.checksum = checksum;
+    Ok(summary)
+}
@@ -0,0 +1,102 @@
+package msgpack
+
+import "math"
+
+// ReadExt reads the type and data of the next extension. The data aliases the
+// buffer of the decoder, like the result of ReadByteArray.
+func (d *Decoder) ReadExt() (int8, []byte, error) {
+	length, err := d.readExtLength()
+	if err != nil {
+		return 0, nil, err
+	}
+	typ, err := d.reader.GetInt8()
+	if err != nil {
+		return 0, nil, err
+	}
+	data, err := d.reader.GetBytes(length)
+	if err != nil {
+		return 0, nil, err
+	}
+	return typ, data, nil
+}
+
+func (d *Decoder) readExtLength() (uint32, error) {
+	prefix, err := d.reader.GetUint8()
+	if err != nil {
+		return 0, err
+	}
+	return d.extLength(prefix)
+}
+
+// extLength returns the length of the data of an extension with the prefix
+// `prefix`, reading it after the prefix for the variable length formats.
+func (d *Decoder) extLength(prefix byte) (uint32, error) {
+	switch prefix {
+	case FormatFixExt1:
+		return 1, nil
+	case FormatFixExt2:
+		return 2, nil
+	case FormatFixExt4:
+		return 4, nil
+	case FormatFixExt8:
+		return 8, nil
+	case FormatFixExt16:
+		return 16, nil
+	case FormatExt8:
+		v, err := d.reader.GetUint8()
+		return uint32(v), err
+	case FormatExt16:
+		v, err := d.reader.GetUint16()
+		return uint32(v), err
+	case FormatExt32:
+		return d.reader.GetUint32()
+	}
+	return 0, ReadError{"bad prefix for extension"}
+}
+
+// WriteExt writes an extension in the smallest format that holds `data`.
+func (e *Encoder) WriteExt(typ int8, data []byte) {
+	length := uint32(len(data))
+	switch length {
+	case 1:
+		e.reader.SetUint8(FormatFixExt1)
+	case 2:
+		e.reader.SetUint8(FormatFixExt2)
+	case 4:
+		e.reader.SetUint8(FormatFixExt4)
+	case 8:
+		e.reader.SetUint8(FormatFixExt8)
+	case 16:
+		e.reader.SetUint8(FormatFixExt16)
+	default:
+		if length <= math.MaxUint8 {
+			e.reader.SetUint8(FormatExt8)
+			e.reader.SetUint8(uint8(length))
+		} else if length <= math.MaxUint16 {
+			e.reader.SetUint8(FormatExt16)
+			e.reader.SetUint16(uint16(length))
+		} else {
+			e.reader.SetUint8(FormatExt32)
+			e.reader.SetUint32(length)
+		}
+	}
+	e.reader.SetInt8(typ)
+	e.reader.SetBytes(data)
+}
+
+func (s *Sizer) WriteExt(typ int8, data []byte) {
+	length := uint32(len(data))
+	switch length {
+	case 1, 2, 4, 8, 16:
+		s.length += 2
+	default:
+		if length <= math.MaxUint8 {
+			s.length += 3
+		} else if length <= math.MaxUint16 {
+			s.length += 4
+		} else {
+			s.length += 6
+		}
+	}
+	s.length += length
+}
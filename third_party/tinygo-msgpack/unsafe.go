@@ -0,0 +1,16 @@
+//go:build !purego && !appengine && !wasm
+// +build !purego,!appengine,!wasm
+
+package msgpack
+
+// UnsafeString returns the byte slice as a string. Outside of Wasm, where
+// only tests run, it copies the bytes.
+func UnsafeString(b []byte) string {
+	return string(b)
+}
+
+// UnsafeBytes returns the string as a byte slice. Outside of Wasm, where only
+// tests run, it copies the string.
+func UnsafeBytes(s string) []byte {
+	return []byte(s)
+}
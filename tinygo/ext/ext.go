@@ -0,0 +1,78 @@
+// Package ext maps MsgPack extension types to Go values for the TinyGo
+// guests, including the timestamp extension used for `datetime`.
+package ext
+
+import (
+	"errors"
+
+	msgpack "github.com/wapc/tinygo-msgpack"
+)
+
+// Extension is a value encoded as MsgPack extension `ExtensionType()`.
+type Extension interface {
+	ExtensionType() int8
+	MarshalExtension() []byte
+	UnmarshalExtension(data []byte) error
+}
+
+var (
+	errUnregistered = errors.New("ext: extension type is not registered")
+
+	registry = map[int8]func() Extension{}
+)
+
+// Register makes `Decode` return extensions of type `typ` as the value
+// returned by `new`. Negative types are reserved by the MsgPack
+// specification, so only the timestamp type, -1, may be registered among
+// them.
+func Register(typ int8, new func() Extension) error {
+	if typ < 0 && typ != TimestampType {
+		return errors.New("ext: extension types below 0 are reserved")
+	}
+	if _, ok := registry[typ]; ok {
+		return errors.New("ext: extension type is already registered")
+	}
+	registry[typ] = new
+	return nil
+}
+
+// Decode reads an extension of any registered type.
+func Decode(decoder *msgpack.Decoder) (Extension, error) {
+	typ, data, err := readExt(decoder)
+	if err != nil {
+		return nil, err
+	}
+	new, ok := registry[typ]
+	if !ok {
+		return nil, errUnregistered
+	}
+	e := new()
+	return e, e.UnmarshalExtension(data)
+}
+
+// Read reads an extension into `e`, which must be of the same type.
+func Read(decoder *msgpack.Decoder, e Extension) error {
+	typ, data, err := readExt(decoder)
+	if err != nil {
+		return err
+	}
+	if typ != e.ExtensionType() {
+		return errors.New("ext: unexpected extension type")
+	}
+	return e.UnmarshalExtension(data)
+}
+
+// Write writes `e` as an extension.
+func Write(encoder msgpack.Writer, e Extension) {
+	encoder.WriteExt(e.ExtensionType(), e.MarshalExtension())
+}
+
+// readExt reads the type and data of the next extension, copying the data so
+// that it does not alias the payload.
+func readExt(decoder *msgpack.Decoder) (int8, []byte, error) {
+	typ, data, err := decoder.ReadExt()
+	if err != nil {
+		return 0, nil, err
+	}
+	return typ, append([]byte(nil), data...), nil
+}
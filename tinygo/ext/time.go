@@ -0,0 +1,76 @@
+package ext
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	msgpack "github.com/wapc/tinygo-msgpack"
+)
+
+// TimestampType is the extension type of MsgPack timestamps.
+const TimestampType int8 = -1
+
+// Timestamp is a time encoded as the MsgPack timestamp extension.
+type Timestamp time.Time
+
+func (t *Timestamp) ExtensionType() int8 {
+	return TimestampType
+}
+
+// MarshalExtension uses the 32-bit format for whole seconds from 1970 to
+// 2106, the 64-bit format for other times from 1970 to 2514 and the 96-bit
+// format for the rest.
+func (t *Timestamp) MarshalExtension() []byte {
+	tm := time.Time(*t)
+	secs := uint64(tm.Unix())
+	if secs>>34 == 0 {
+		data := uint64(tm.Nanosecond())<<34 | secs
+		if data>>32 == 0 {
+			b := make([]byte, 4)
+			binary.BigEndian.PutUint32(b, uint32(data))
+			return b
+		}
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, data)
+		return b
+	}
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint32(b, uint32(tm.Nanosecond()))
+	binary.BigEndian.PutUint64(b[4:], secs)
+	return b
+}
+
+func (t *Timestamp) UnmarshalExtension(data []byte) error {
+	switch len(data) {
+	case 4:
+		*t = Timestamp(time.Unix(int64(binary.BigEndian.Uint32(data)), 0).UTC())
+	case 8:
+		data64 := binary.BigEndian.Uint64(data)
+		*t = Timestamp(time.Unix(int64(data64&0x3ffffffff), int64(data64>>34)).UTC())
+	case 12:
+		nsec := binary.BigEndian.Uint32(data)
+		sec := binary.BigEndian.Uint64(data[4:])
+		*t = Timestamp(time.Unix(int64(sec), int64(nsec)).UTC())
+	default:
+		return errors.New("ext: invalid timestamp length")
+	}
+	return nil
+}
+
+func init() {
+	registry[TimestampType] = func() Extension { return new(Timestamp) }
+}
+
+// ReadTime reads a MsgPack timestamp.
+func ReadTime(decoder *msgpack.Decoder) (time.Time, error) {
+	var t Timestamp
+	err := Read(decoder, &t)
+	return time.Time(t), err
+}
+
+// WriteTime writes `t` as a MsgPack timestamp.
+func WriteTime(encoder msgpack.Writer, t time.Time) {
+	ts := Timestamp(t)
+	Write(encoder, &ts)
+}
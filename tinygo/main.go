@@ -1,17 +1,44 @@
 package main
 
 import (
+	"errors"
+	"hash/fnv"
 	"strconv"
 
 	"github.com/wapc/language-tests/tinygo/module"
 )
 
+// handlers are the handlers of the guest, which main registers.
+var handlers = module.Handlers{
+	TestFunction:       testFunction,
+	TestUnary:          testUnary,
+	TestDecode:         testDecode,
+	TestHostCall:       testHostCall,
+	TestEnums:          testEnums,
+	TestUnions:         testUnions,
+	TestRecursion:      testRecursion,
+	TestCollections:    testCollections,
+	TestTimes:          testTimes,
+	TestAliases:        testAliases,
+	TestDefaults:       testDefaults,
+	TestValidation:     testValidation,
+	TestNoArgs:         testNoArgs,
+	TestNoArgsVoid:     testNoArgsVoid,
+	TestVoid:           testVoid,
+	TestUnaryString:    testUnaryString,
+	TestUnaryU64:       testUnaryU64,
+	TestUnaryBool:      testUnaryBool,
+	TestUnaryBytes:     testUnaryBytes,
+	TestReturnList:     testReturnList,
+	TestReturnMap:      testReturnMap,
+	TestReturnOptional: testReturnOptional,
+	TestNamespaces:     testNamespaces,
+	StreamThings:       streamThings,
+	CollectThings:      collectThings,
+}
+
 func main() {
-	module.Handlers{
-		TestFunction: testFunction,
-		TestUnary:    testUnary,
-		TestDecode:   testDecode,
-	}.Register()
+	handlers.Register()
 }
 
 func testFunction(required module.Required, optional module.Optional, maps module.Maps, lists module.Lists) (module.Tests, error) {
@@ -47,3 +74,167 @@ func testDecode(tests module.Tests) (string, error) {
 	ret += "}"
 	return ret, nil
 }
+
+func testHostCall(tests module.Tests) (module.Tests, error) {
+	// Round trip through the host
+	return module.NewHost("").TestUnary(tests)
+}
+
+func testEnums(enums module.Enums) (module.Enums, error) {
+	// Echo input
+	return enums, nil
+}
+
+func testUnions(unions module.Unions) (module.Unions, error) {
+	// Echo input
+	return unions, nil
+}
+
+func testRecursion(trees module.Trees) (module.Trees, error) {
+	// Echo input
+	return trees, nil
+}
+
+func testCollections(collections module.Collections) (module.Collections, error) {
+	// Echo input
+	return collections, nil
+}
+
+func testTimes(times module.Times) (module.Times, error) {
+	// Echo input
+	return times, nil
+}
+
+func testAliases(aliases module.Aliases) (module.Aliases, error) {
+	// Echo input
+	return aliases, nil
+}
+
+func testDefaults(defaults module.Defaults) (module.Defaults, error) {
+	// Echo input
+	return defaults, nil
+}
+
+func testValidation(validated module.Validated) (module.Validated, error) {
+	// Echo input
+	return validated, nil
+}
+
+func testNoArgs() (string, error) {
+	return "ok", nil
+}
+
+func testNoArgsVoid() error {
+	return nil
+}
+
+func testVoid(value string) error {
+	if value == "error" {
+		return errors.New("testVoid failed")
+	}
+	return nil
+}
+
+func testUnaryString(value string) (string, error) {
+	// Echo input
+	return value, nil
+}
+
+func testUnaryU64(value uint64) (uint64, error) {
+	// Echo input
+	return value, nil
+}
+
+func testUnaryBool(value bool) (bool, error) {
+	// Echo input
+	return value, nil
+}
+
+func testUnaryBytes(value []byte) ([]byte, error) {
+	// Echo input
+	return value, nil
+}
+
+// maxListCount bounds the count of testReturnList, which comes from the
+// client, so that it cannot make the guest run out of memory.
+const maxListCount = 10000
+
+func testReturnList(prefix string, count uint32) ([]string, error) {
+	if count > maxListCount {
+		return nil, errors.New("count " + strconv.FormatUint(uint64(count), 10) + " is above " + strconv.Itoa(maxListCount))
+	}
+	list := make([]string, count)
+	for i := range list {
+		list[i] = prefix + strconv.Itoa(i)
+	}
+	return list, nil
+}
+
+func testReturnMap(keys []string) (map[string]uint64, error) {
+	lengths := make(map[string]uint64, len(keys))
+	for _, key := range keys {
+		lengths[key] = uint64(len(key))
+	}
+	return lengths, nil
+}
+
+func testReturnOptional(value *string) (*string, error) {
+	// Echo input
+	return value, nil
+}
+
+func testNamespaces(key string, value string) (module.NamespaceResults, error) {
+	var results module.NamespaceResults
+	storage := module.NewStorageHost("")
+	log := module.NewLogHost("")
+	if err := storage.Set(key, value); err != nil {
+		return results, err
+	}
+	index, err := log.Write(key + "=" + value)
+	if err != nil {
+		return results, err
+	}
+	results.Index = index
+	// Both namespaces have a get operation.
+	if results.Stored, err = storage.Get(key); err != nil {
+		return results, err
+	}
+	if results.Logged, err = log.Get(index); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+func streamThings(prefix string, count uint32, failAt *uint32, stream *module.ThingStreamWriter) error {
+	for i := uint32(0); i < count; i++ {
+		if failAt != nil && i == *failAt {
+			return errors.New("failed at " + strconv.FormatUint(uint64(i), 10))
+		}
+		if err := stream.Send(module.Thing{Value: prefix + strconv.FormatUint(uint64(i), 10)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectThings(label string, failAt *uint32, things *module.ThingStreamReader) (module.ThingSummary, error) {
+	summary := module.ThingSummary{Label: label}
+	checksum := fnv.New32a()
+	for {
+		if failAt != nil && summary.Count == *failAt {
+			return module.ThingSummary{}, errors.New("failed at " + strconv.FormatUint(uint64(*failAt), 10))
+		}
+		if !things.Next() {
+			break
+		}
+		value := things.Item().Value
+		summary.Count++
+		summary.Size += uint64(len(value))
+		checksum.Write([]byte(value))
+	}
+	if err := things.Err(); err != nil {
+		return module.ThingSummary{}, err
+	}
+	summary.Checksum = checksum.Sum32()
+	return summary, nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"testing"
+
+	"github.com/AlekSi/pointer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wapc/wapc-go"
+	guest "github.com/wapc/wapc-guest-tinygo"
+
+	host "github.com/wapc/language-tests/pkg/module"
+	"github.com/wapc/language-tests/tinygo/module"
+)
+
+// nativeGuest runs the handlers of the guest natively through the generated
+// wrappers, sending their host calls to `hostCall`.
+type nativeGuest struct {
+	hostCall  wapc.HostCallHandler
+	functions guest.Functions
+	// err is the error returned by the last wrapper.
+	err error
+}
+
+func newNativeGuest(hostCall wapc.HostCallHandler) *nativeGuest {
+	return &nativeGuest{hostCall: hostCall, functions: handlers.Functions()}
+}
+
+func (g *nativeGuest) Invoke(ctx context.Context, operation string, payload []byte) ([]byte, error) {
+	function, ok := g.functions[operation]
+	if !ok {
+		return nil, errors.New("unknown operation " + operation)
+	}
+	// The guest runs one operation at a time, as an instance does.
+	previous := module.StreamHostCall
+	module.StreamHostCall = func(binding, namespace, operation string, payload []byte) ([]byte, error) {
+		return g.hostCall(ctx, binding, namespace, operation, payload)
+	}
+	defer func() { module.StreamHostCall = previous }()
+	var response []byte
+	response, g.err = function(payload)
+	return response, g.err
+}
+
+// setChunkSize sets the chunk size of the stream writers for a test.
+func setChunkSize(t *testing.T, size int) {
+	previous := module.StreamChunkSize
+	module.StreamChunkSize = size
+	t.Cleanup(func() { module.StreamChunkSize = previous })
+}
+
+// streamHost serves the streams of the guest and fails its other host calls.
+func streamHost() wapc.HostCallHandler {
+	return (&host.Router{}).HostCall
+}
+
+func things(prefix string, count int) []host.Thing {
+	var things []host.Thing
+	for i := 0; i < count; i++ {
+		things = append(things, host.Thing{Value: prefix + strconv.Itoa(i)})
+	}
+	return things
+}
+
+func summarize(label string, things []host.Thing) host.ThingSummary {
+	summary := host.ThingSummary{Label: label, Count: uint32(len(things))}
+	checksum := fnv.New32a()
+	for _, thing := range things {
+		summary.Size += uint64(len(thing.Value))
+		checksum.Write([]byte(thing.Value))
+	}
+	summary.Checksum = checksum.Sum32()
+	return summary
+}
+
+// readAll reads the items of `stream` until its end.
+func readAll(stream host.Iterator[host.Thing]) ([]host.Thing, error) {
+	var items []host.Thing
+	for stream.Next() {
+		items = append(items, stream.Item())
+	}
+	return items, stream.Err()
+}
+
+func TestStreams(t *testing.T) {
+	setChunkSize(t, 7)
+	m := host.New(newNativeGuest(streamHost()), host.CallOptions(host.WithChunkSize(3)))
+	ctx := context.Background()
+	for _, count := range []int{0, 1, 7, 8, 10000} {
+		items, err := readAll(m.StreamThings(ctx, "thing", uint32(count), nil))
+		require.NoError(t, err)
+		assert.Equal(t, things("thing", count), items, "wrong items for %d things", count)
+
+		sent := things("sent", count)
+		summary, err := m.CollectThings(ctx, "label", nil, host.Items(sent...))
+		require.NoError(t, err)
+		assert.Equal(t, summarize("label", sent), summary, "wrong summary for %d things", count)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	setChunkSize(t, 16)
+	g := newNativeGuest(streamHost())
+	m := host.New(g)
+	ctx := context.Background()
+
+	// The items sent before an error are received first.
+	items, err := readAll(m.StreamThings(ctx, "thing", 100, pointer.ToUint32(50)))
+	assert.EqualError(t, err, "failed at 50")
+	assert.Equal(t, things("thing", 50), items)
+
+	_, err = m.CollectThings(ctx, "label", pointer.ToUint32(50), host.Items(things("thing", 100)...))
+	assert.EqualError(t, err, "failed at 50")
+
+	// An error of the items ends the guest's stream and is returned.
+	failure := errors.New("no more things")
+	_, err = m.CollectThings(ctx, "label", nil, &failingIterator{host.Items(things("thing", 100)...), failure})
+	assert.Equal(t, failure, err)
+	assert.Equal(t, failure, g.err, "the guest did not read the error")
+}
+
+// failingIterator fails with `err` after its items.
+type failingIterator struct {
+	host.Iterator[host.Thing]
+	err error
+}
+
+func (f *failingIterator) Err() error {
+	return f.err
+}
+
+func TestStreamCancel(t *testing.T) {
+	setChunkSize(t, 4)
+	g := newNativeGuest(streamHost())
+	m := host.New(g)
+
+	// Closing a stream fails the guest's next frame.
+	stream := m.StreamThings(context.Background(), "thing", 100, nil)
+	for i := 0; i < 10; i++ {
+		require.True(t, stream.Next())
+		assert.Equal(t, "thing"+strconv.Itoa(i), stream.Item().Value)
+	}
+	assert.NoError(t, stream.Close())
+	assert.NoError(t, stream.Err())
+	assert.False(t, stream.Next())
+	assert.EqualError(t, g.err, "stream closed by the host")
+
+	// Cancelling the context stops the stream with its error.
+	ctx, cancel := context.WithCancel(context.Background())
+	stream = m.StreamThings(ctx, "thing", 100, nil)
+	for i := 0; i < 10; i++ {
+		require.True(t, stream.Next())
+	}
+	cancel()
+	items, err := readAll(stream)
+	assert.Equal(t, context.Canceled, err)
+	assert.Less(t, len(items), 90, "the stream was not stopped")
+	assert.Equal(t, context.Canceled, g.err)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	_, err = m.CollectThings(ctx, "label", nil, &cancellingIterator{host.Items(things("thing", 100)...), 10, cancel})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, g.err)
+}
+
+// cancellingIterator calls cancel after `after` items.
+type cancellingIterator struct {
+	host.Iterator[host.Thing]
+	after  int
+	cancel context.CancelFunc
+}
+
+func (c *cancellingIterator) Next() bool {
+	if c.after == 0 {
+		c.cancel()
+	}
+	c.after--
+	return c.Iterator.Next()
+}
+
+// closingIterator counts the items read and whether it was closed.
+type closingIterator struct {
+	host.Iterator[host.Thing]
+	read   int
+	closed bool
+}
+
+func (c *closingIterator) Next() bool {
+	c.read++
+	return c.Iterator.Next()
+}
+
+func (c *closingIterator) Close() error {
+	c.closed = true
+	return nil
+}
+
+// TestStreamEarlyReturn checks that a guest returning before the end of the
+// stream it reads stops the items of the host.
+func TestStreamEarlyReturn(t *testing.T) {
+	setChunkSize(t, 4)
+	ctx := context.Background()
+	m := host.New(newNativeGuest(streamHost()), host.CallOptions(host.WithChunkSize(4)))
+
+	items := &closingIterator{Iterator: host.Items(things("thing", 100)...)}
+	_, err := m.CollectThings(ctx, "label", pointer.ToUint32(10), items)
+	assert.EqualError(t, err, "failed at 10")
+	assert.True(t, items.closed, "the items were not closed")
+	assert.Less(t, items.read, 20, "the items were read after the guest returned")
+
+	// Reading to the end does not close the items.
+	items = &closingIterator{Iterator: host.Items(things("thing", 10)...)}
+	_, err = m.CollectThings(ctx, "label", nil, items)
+	require.NoError(t, err)
+	assert.False(t, items.closed)
+}
@@ -0,0 +1,43 @@
+// Package scalar has the Go types that the generated TinyGo bindings use for
+// schema aliases that are mapped to user types in cmd/codegen.
+package scalar
+
+import (
+	"errors"
+
+	msgpack "github.com/wapc/tinygo-msgpack"
+
+	"github.com/wapc/language-tests/internal/uuid"
+)
+
+var errInvalidUUID = errors.New("scalar: invalid UUID")
+
+// UUID is an RFC 4122 UUID. It is encoded as a string in its canonical
+// form, the wire format of the `UUID` alias.
+type UUID [16]byte
+
+// ParseUUID parses the canonical form of a UUID, such as
+// "6ba7b810-9dad-11d1-80b4-00c04fd430c8". Uppercase digits are rejected.
+func ParseUUID(s string) (UUID, error) {
+	u, ok := uuid.Parse(s)
+	if !ok {
+		return UUID{}, errInvalidUUID
+	}
+	return u, nil
+}
+
+func (u UUID) String() string {
+	return uuid.Format(u)
+}
+
+func DecodeUUID(decoder *msgpack.Decoder) (UUID, error) {
+	s, err := decoder.ReadString()
+	if err != nil {
+		return UUID{}, err
+	}
+	return ParseUUID(s)
+}
+
+func EncodeUUID(encoder msgpack.Writer, u UUID) {
+	encoder.WriteString(u.String())
+}